@@ -2,11 +2,23 @@ package cmd
 
 import (
 	"calendar/internal/calendar"
+	"calendar/internal/changes"
 	"calendar/internal/config"
 	"calendar/internal/event/repository"
 	"calendar/internal/handlers"
+	"calendar/internal/metrics"
+	"calendar/internal/reminder"
 	"calendar/internal/server"
+	"calendar/internal/tracing"
+	"calendar/internal/trash"
+	"calendar/internal/webhook"
 	"calendar/logger"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	_ "calendar/docs"
 )
@@ -15,26 +27,132 @@ import (
 // @title Calendar Events
 // @version 1.0
 // @description Сервис для управления событиями календаря
-// @host http://localhost:8080
+// @host localhost:8080
 // @BasePath /
 // @schemes http
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT, подписанный HS256 ключом JWT_SECRET, в виде "Bearer <token>". Claim sub — ID пользователя, exp обязателен.
 func StartService() {
-	cfg := config.LoadCfg()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, config.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logger.InitLogger(cfg.Level, cfg.LogToFile, cfg.LogFilePath)
+	defer logger.Close()
+
+	// SIGINT и SIGTERM останавливают сервер; SIGHUP сервер обрабатывает сам.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	eventRepository := repository.NewEventRepository(logger.AppLogger)
-	serviceCalendar := calendar.NewServiceCalendar(eventRepository, logger.AppLogger)
-	handler := handlers.NewHandlers(serviceCalendar, logger.AppLogger)
+	storage, err := newStorage(cfg)
+	if err != nil {
+		logger.AppLogger.Error("failed to init storage", "error", err)
+		return
+	}
+	defer storage.Close()
 
-	serv := server.NewServer(handler, cfg, logger.AppLogger)
+	tracer, err := newTracer(cfg)
+	if err != nil {
+		logger.AppLogger.Error("failed to init tracer", "error", err)
+		return
+	}
+
+	registry := metrics.NewRegistry()
+	storage = repository.Instrument(storage, registry)
+
+	bus := changes.NewBus(cfg.StreamReplaySize)
+	webhookNetworks, _ := cfg.WebhookNetworks()
+	dispatcher := webhook.NewDispatcher(storage, webhook.NewGuard(webhookNetworks), cfg.WebhookTimeout,
+		cfg.WebhookWorkers, cfg.WebhookMaxAttempts, cfg.WebhookRetryBase, tracer, logger.AppLogger)
+	dispatcher.Start()
+	storage = repository.Publish(storage, bus, dispatcher)
+
+	serviceCalendar := calendar.NewServiceCalendar(storage, logger.AppLogger)
+	handler := handlers.NewHandlers(serviceCalendar, bus, dispatcher, cfg.StreamHeartbeat, logger.AppLogger)
+
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		logger.AppLogger.Error("failed to init reminder notifier", "error", err)
+		return
+	}
+
+	scheduler := reminder.NewScheduler(serviceCalendar, notifier,
+		cfg.ReminderInterval, cfg.ReminderLookback, tracer, logger.AppLogger)
+	scheduler.Start()
+
+	purger := trash.NewPurger(serviceCalendar, cfg.TrashRetention, cfg.TrashPurgeInterval, tracer, logger.AppLogger)
+	purger.Start()
+
+	serv := server.NewServer(handler, cfg, registry, tracer, logger.AppLogger)
+	serv.OnShutdown(scheduler.Stop)
+	serv.OnShutdown(purger.Stop)
+	serv.OnShutdown(dispatcher.Stop)
+	serv.OnReload(func() (*config.Config, error) {
+		return config.Load(os.Args[1:])
+	})
 
 	logger.AppLogger.Info("starting server",
 		"on port", cfg.Port,
+		"storage", cfg.StorageType,
+		"reminder_notifier", cfg.ReminderNotifier,
+		"trace_exporter", cfg.TraceExporter,
 		"path log file", cfg.LogFilePath,
+		"metrics_url", "http://localhost:"+cfg.Port+"/metrics",
 		"swagger_url", "http://localhost:"+cfg.Port+"/swagger/index.html",
 	)
 
-	if err := serv.Start(); err != nil {
-		logger.AppLogger.Error("server failed start", "error", err)
+	if err := serv.Start(ctx); err != nil {
+		logger.AppLogger.Error("server stopped with error", "error", err)
+	}
+}
+
+func newStorage(cfg *config.Config) (repository.Storage, error) {
+	switch cfg.StorageType {
+	case "", config.StorageMemory:
+		return repository.NewEventRepository(logger.AppLogger), nil
+	case config.StorageSQLite:
+		return repository.NewSQLiteRepository(cfg.SQLitePath, logger.AppLogger)
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", cfg.StorageType)
+	}
+}
+
+func newNotifier(cfg *config.Config) (reminder.Notifier, error) {
+	switch cfg.ReminderNotifier {
+	case "", config.NotifierLog:
+		return reminder.NewLogNotifier(logger.AppLogger), nil
+	case config.NotifierWebhook:
+		if cfg.ReminderWebhookURL == "" {
+			return nil, fmt.Errorf("REMINDER_WEBHOOK_URL is required for webhook notifier")
+		}
+		return reminder.NewWebhookNotifier(cfg.ReminderWebhookURL, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", cfg.ReminderNotifier)
+	}
+}
+
+func newTracer(cfg *config.Config) (*tracing.Tracer, error) {
+	switch cfg.TraceExporter {
+	case "", config.TraceExporterNone:
+		return tracing.NewTracer(nil), nil
+	case config.TraceExporterStdout:
+		return tracing.NewTracer(tracing.NewStdoutExporter()), nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.TraceExporter)
 	}
 }
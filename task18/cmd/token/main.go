@@ -0,0 +1,35 @@
+// Команда token выпускает JWT для пользователя, подписанный ключом JWT_SECRET из .env.
+//
+//	go run ./cmd/token -user 1 -ttl 24h
+package main
+
+import (
+	"calendar/internal/middleware"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userID := flag.Int("user", 0, "ID пользователя")
+	ttl := flag.Duration("ttl", 24*time.Hour, "срок действия токена")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" || *userID <= 0 {
+		fmt.Fprintln(os.Stderr, "JWT_SECRET and positive -user are required")
+		os.Exit(2)
+	}
+
+	token, err := middleware.NewToken([]byte(secret), *userID, *ttl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(token)
+}
@@ -0,0 +1,194 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Attendees возвращает приглашённых на событие и сводку их ответов. Список
+// видят все, кому доступно событие; у замены вхождения это участники серии.
+func (sc *ServiceCalendar) Attendees(ctx context.Context, eventID, userID int) ([]repository.Attendee, repository.RSVPSummary, error) {
+	ctx, span := tracing.Start(ctx, "calendar.Attendees")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	found, err := sc.accessibleEvent(ctx, eventID, userID, false)
+	if err != nil {
+		return nil, repository.RSVPSummary{}, err
+	}
+
+	attendees, err := sc.repo.ListAttendees(ctx, attendeeEventID(found))
+	if err != nil {
+		return nil, repository.RSVPSummary{}, err
+	}
+
+	return attendees, summarizeRSVP(attendees), nil
+}
+
+// InviteAttendee приглашает пользователя attendeeID на событие; приглашать
+// может тот, кто может менять событие. Серия приглашает на все вхождения,
+// на отдельную замену вхождения пригласить нельзя. Повторное приглашение
+// не меняет ответ.
+func (sc *ServiceCalendar) InviteAttendee(ctx context.Context, eventID, userID, attendeeID int) (repository.Attendee, error) {
+	ctx, span := tracing.Start(ctx, "calendar.InviteAttendee")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	found, err := sc.accessibleEvent(ctx, eventID, userID, true)
+	if err != nil {
+		return repository.Attendee{}, err
+	}
+	if found.RecurringEventID != 0 {
+		return repository.Attendee{}, fmt.Errorf("%w: attendees are invited to the whole series", repository.ErrInvalidDataInput)
+	}
+	if attendeeID == found.UserID {
+		return repository.Attendee{}, event.ErrInviteOrganizer
+	}
+
+	return sc.repo.AddAttendee(ctx, repository.Attendee{
+		EventID:     found.ID,
+		OrganizerID: found.UserID,
+		UserID:      attendeeID,
+	})
+}
+
+// RemoveAttendee отменяет приглашение пользователя attendeeID. Это может тот,
+// кто может менять событие, или сам приглашённый.
+func (sc *ServiceCalendar) RemoveAttendee(ctx context.Context, eventID, userID, attendeeID int) error {
+	ctx, span := tracing.Start(ctx, "calendar.RemoveAttendee")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	found, err := sc.accessibleEvent(ctx, eventID, userID, userID != attendeeID)
+	if err != nil {
+		return err
+	}
+
+	return sc.repo.RemoveAttendee(ctx, attendeeEventID(found), attendeeID)
+}
+
+// RespondToInvitation сохраняет ответ пользователя на приглашение. Отклонённое
+// событие пропадает из его выборок за день, неделю и месяц.
+func (sc *ServiceCalendar) RespondToInvitation(ctx context.Context, eventID, userID int, status string) (repository.Attendee, error) {
+	ctx, span := tracing.Start(ctx, "calendar.RespondToInvitation")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	found, err := sc.accessibleEvent(ctx, eventID, userID, false)
+	if err != nil {
+		return repository.Attendee{}, err
+	}
+
+	return sc.repo.SetAttendeeStatus(ctx, attendeeEventID(found), userID, status)
+}
+
+// Invitations возвращает приглашения пользователя вместе с событиями.
+// Приглашения на удалённые события пропускаются.
+func (sc *ServiceCalendar) Invitations(ctx context.Context, userID int) ([]repository.Invitation, error) {
+	ctx, span := tracing.Start(ctx, "calendar.Invitations")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	attendees, err := sc.repo.ListInvitations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]repository.Invitation, 0, len(attendees))
+	for _, attendee := range attendees {
+		found, err := sc.repo.GetEvent(ctx, attendee.EventID, attendee.OrganizerID)
+		if errors.Is(err, repository.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, repository.Invitation{
+			Event:       found,
+			Status:      attendee.Status,
+			RespondedAt: attendee.RespondedAt,
+		})
+	}
+
+	return invitations, nil
+}
+
+// invitedEvent находит событие eventID среди событий, на которые приглашён
+// пользователь, и замен вхождений их серий.
+func (sc *ServiceCalendar) invitedEvent(ctx context.Context, eventID, userID int) (repository.Event, error) {
+	invitations, err := sc.repo.ListInvitations(ctx, userID)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	checked := make(map[int]bool)
+	for _, invitation := range invitations {
+		if checked[invitation.OrganizerID] {
+			continue
+		}
+		checked[invitation.OrganizerID] = true
+
+		found, err := sc.repo.GetEvent(ctx, eventID, invitation.OrganizerID)
+		if errors.Is(err, repository.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return repository.Event{}, err
+		}
+
+		for _, other := range invitations {
+			if other.OrganizerID == invitation.OrganizerID && other.EventID == attendeeEventID(found) {
+				return found, nil
+			}
+		}
+	}
+
+	return repository.Event{}, repository.ErrEventNotFound
+}
+
+// resetRSVPs сбрасывает ответы приглашённых на перенесённое событие. Ошибка
+// не отменяет сохранённое изменение и только пишется в лог.
+func (sc *ServiceCalendar) resetRSVPs(ctx context.Context, e repository.Event) {
+	if err := sc.repo.ResetAttendees(ctx, attendeeEventID(e)); err != nil {
+		sc.log.Warn("failed to reset attendee responses",
+			"event_id", e.ID,
+			"user_id", e.UserID,
+			"error", err,
+		)
+	}
+}
+
+// attendeeEventID — событие, к которому привязаны участники: у замены
+// вхождения это её серия.
+func attendeeEventID(e repository.Event) int {
+	if e.RecurringEventID != 0 {
+		return e.RecurringEventID
+	}
+	return e.ID
+}
+
+func summarizeRSVP(attendees []repository.Attendee) repository.RSVPSummary {
+	var summary repository.RSVPSummary
+	for _, attendee := range attendees {
+		switch attendee.Status {
+		case repository.RSVPAccepted:
+			summary.Accepted++
+		case repository.RSVPDeclined:
+			summary.Declined++
+		case repository.RSVPTentative:
+			summary.Tentative++
+		default:
+			summary.NeedsAction++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_Attendees(t *testing.T) {
+	service := NewServiceCalendar(repository.NewEventRepository(testLogger()), testLogger())
+	monday := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	standup, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: monday, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	review, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: monday.Add(4 * time.Hour), Title: "Review"})
+	require.NoError(t, err)
+
+	t.Run("organizer invites attendees", func(t *testing.T) {
+		attendee, err := service.InviteAttendee(t.Context(), standup.ID, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, repository.RSVPNeedsAction, attendee.Status)
+		assert.Equal(t, 1, attendee.OrganizerID)
+
+		_, err = service.InviteAttendee(t.Context(), review.ID, 1, 2)
+		require.NoError(t, err)
+		_, err = service.InviteAttendee(t.Context(), review.ID, 1, 3)
+		require.NoError(t, err)
+
+		_, err = service.InviteAttendee(t.Context(), review.ID, 1, 1)
+		assert.ErrorIs(t, err, event.ErrInviteOrganizer)
+	})
+
+	t.Run("attendee sees invited events", func(t *testing.T) {
+		events, err := service.GetEventsForWeek(t.Context(), 2, 0, monday)
+		require.NoError(t, err)
+		assert.Len(t, events, 6)
+
+		found, err := service.GetEvent(t.Context(), review.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "Review", found.Title)
+	})
+
+	t.Run("attendee cannot change or invite", func(t *testing.T) {
+		_, err := service.UpdateEvent(t.Context(), repository.Event{ID: review.ID, UserID: 2, Date: monday, Title: "Mine"})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = service.InviteAttendee(t.Context(), review.ID, 2, 4)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	t.Run("responses are summarized", func(t *testing.T) {
+		_, err := service.RespondToInvitation(t.Context(), review.ID, 2, repository.RSVPAccepted)
+		require.NoError(t, err)
+		_, err = service.RespondToInvitation(t.Context(), review.ID, 3, repository.RSVPTentative)
+		require.NoError(t, err)
+
+		attendees, summary, err := service.Attendees(t.Context(), review.ID, 1)
+		require.NoError(t, err)
+		assert.Len(t, attendees, 2)
+		assert.Equal(t, repository.RSVPSummary{Accepted: 1, Tentative: 1}, summary)
+
+		_, _, err = service.Attendees(t.Context(), review.ID, 4)
+		assert.ErrorIs(t, err, repository.ErrEventNotFound)
+	})
+
+	t.Run("declined series is hidden", func(t *testing.T) {
+		_, err := service.RespondToInvitation(t.Context(), standup.ID, 2, repository.RSVPDeclined)
+		require.NoError(t, err)
+
+		events, err := service.GetEventsForWeek(t.Context(), 2, 0, monday)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, review.ID, events[0].ID)
+
+		invitations, err := service.Invitations(t.Context(), 2)
+		require.NoError(t, err)
+		assert.Len(t, invitations, 2)
+	})
+
+	t.Run("rescheduling resets responses", func(t *testing.T) {
+		_, err := service.UpdateEvent(t.Context(), repository.Event{ID: review.ID, UserID: 1, Date: review.Date, Title: "Design review"})
+		require.NoError(t, err)
+		_, summary, err := service.Attendees(t.Context(), review.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Accepted)
+
+		_, err = service.UpdateEvent(t.Context(), repository.Event{ID: review.ID, UserID: 1, Date: review.Date.Add(time.Hour), Title: "Design review"})
+		require.NoError(t, err)
+		_, summary, err = service.Attendees(t.Context(), review.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, repository.RSVPSummary{NeedsAction: 2}, summary)
+	})
+
+	t.Run("attendee leaves event", func(t *testing.T) {
+		assert.ErrorIs(t, service.RemoveAttendee(t.Context(), review.ID, 2, 3), ErrPermissionDenied)
+		require.NoError(t, service.RemoveAttendee(t.Context(), review.ID, 3, 3))
+
+		_, err := service.GetEvent(t.Context(), review.ID, 3)
+		assert.ErrorIs(t, err, repository.ErrEventNotFound)
+	})
+}
@@ -2,49 +2,458 @@ package calendar
 
 import (
 	"calendar/internal/event/repository"
+	"calendar/internal/recurrence"
+	"calendar/internal/tracing"
+	"context"
+	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
 
 type ServiceCalendar struct {
-	repo *repository.EventRepository
+	repo repository.Storage
 	log  *slog.Logger
 }
 
-func NewServiceCalendar(repo *repository.EventRepository, logger *slog.Logger) *ServiceCalendar {
+func NewServiceCalendar(repo repository.Storage, logger *slog.Logger) *ServiceCalendar {
 	return &ServiceCalendar{
 		repo: repo,
 		log:  logger,
 	}
 }
 
-func (sc *ServiceCalendar) CreateEvent(userID int, date time.Time, title string) (repository.Event, error) {
-	if strings.TrimSpace(title) == "" {
+// CreateEvent создаёт событие пользователя. Событие календаря event.CalendarID
+// сохраняется от имени владельца календаря, если пользователь может его менять.
+func (sc *ServiceCalendar) CreateEvent(ctx context.Context, event repository.Event) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.CreateEvent")
+	defer span.End()
+	span.SetAttr("user_id", event.UserID)
+
+	if err := sc.prepareCreate(ctx, &event); err != nil {
+		return repository.Event{}, err
+	}
+
+	return sc.repo.CreateEvent(ctx, event)
+}
+
+// UpdateEvent обновляет событие или серию целиком. Если у серии не переданы
+// исключённые даты или у события не переданы напоминания, сохраняются текущие.
+// Событие открытого пользователю календаря меняется, если его роль это позволяет.
+// Перенос события сбрасывает ответы приглашённых.
+func (sc *ServiceCalendar) UpdateEvent(ctx context.Context, event repository.Event) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.UpdateEvent")
+	defer span.End()
+	span.SetAttr("user_id", event.UserID)
+
+	current, err := sc.prepareUpdate(ctx, &event)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	updated, err := sc.repo.UpdateEvent(ctx, event)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	if updated.Reschedules(current) {
+		sc.resetRSVPs(ctx, updated)
+	}
+	return updated, nil
+}
+
+// ApplyBatch атомарно применяет операции пакета: при первой ошибке ни одна
+// операция не применяется, а ошибка оборачивается в *repository.BatchError
+// с номером операции. Создание и изменение проходят те же проверки, что и
+// в CreateEvent и UpdateEvent.
+func (sc *ServiceCalendar) ApplyBatch(ctx context.Context, ops []repository.BatchOp) ([]repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.ApplyBatch")
+	defer span.End()
+	span.SetAttr("operations", len(ops))
+
+	ops = slices.Clone(ops)
+	current := make(map[int]repository.Event)
+	for i := range ops {
+		var err error
+		switch ops[i].Kind {
+		case repository.BatchCreate:
+			err = sc.prepareCreate(ctx, &ops[i].Event)
+		case repository.BatchUpdate:
+			current[i], err = sc.prepareUpdate(ctx, &ops[i].Event)
+		case repository.BatchDelete:
+			var current repository.Event
+			current, err = sc.accessibleEvent(ctx, ops[i].Event.ID, ops[i].Event.UserID, true)
+			ops[i].Event.UserID = current.UserID
+		}
+		if err != nil {
+			return nil, &repository.BatchError{Index: i, Err: err}
+		}
+	}
+
+	results, err := sc.repo.ApplyBatch(ctx, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, before := range current {
+		if results[i].Reschedules(before) {
+			sc.resetRSVPs(ctx, results[i])
+		}
+	}
+	return results, nil
+}
+
+// UpdateOccurrence переносит или переименовывает одно вхождение серии eventID,
+// приходящееся на день occurrenceDate. Остальные вхождения серии не меняются.
+// Если напоминания не переданы, вхождение наследует напоминания серии.
+// version — ожидаемая версия серии, 0 отключает проверку.
+func (sc *ServiceCalendar) UpdateOccurrence(ctx context.Context, eventID, userID int, occurrenceDate time.Time, changes repository.Event, version int) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.UpdateOccurrence")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	if strings.TrimSpace(changes.Title) == "" {
 		return repository.Event{}, repository.ErrInvalidDataInput
 	}
-	return sc.repo.CreateEvent(userID, date, title)
+
+	series, occurrence, err := sc.findOccurrence(ctx, eventID, userID, occurrenceDate, true)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	override := repository.Event{
+		Title:     changes.Title,
+		Date:      changes.Date,
+		End:       changes.End,
+		AllDay:    changes.AllDay,
+		TimeZone:  changes.TimeZone,
+		Reminders: changes.Reminders,
+	}
+	if override.Reminders == nil {
+		override.Reminders = series.Reminders
+	}
+	if err := normalizeTime(&override); err != nil {
+		return repository.Event{}, err
+	}
+	if err := normalizeReminders(&override); err != nil {
+		return repository.Event{}, err
+	}
+
+	return sc.repo.ReplaceOccurrence(ctx, eventID, series.UserID, occurrence, override, version)
 }
 
-func (sc *ServiceCalendar) UpdateEvent(eventID, userID int, date time.Time, title string) (repository.Event, error) {
-	if strings.TrimSpace(title) == "" {
+// GetEvent возвращает событие пользователя или событие открытого ему календаря.
+func (sc *ServiceCalendar) GetEvent(ctx context.Context, eventID, userID int) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.GetEvent")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	return sc.accessibleEvent(ctx, eventID, userID, false)
+}
+
+// GetOccurrence возвращает вхождение серии eventID, приходящееся на день occurrenceDate.
+func (sc *ServiceCalendar) GetOccurrence(ctx context.Context, eventID, userID int, occurrenceDate time.Time) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.GetOccurrence")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	series, occurrence, err := sc.findOccurrence(ctx, eventID, userID, occurrenceDate, false)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	result := series
+	result.Date = occurrence
+	result.End = occurrence.Add(series.EndTime().Sub(series.Date))
+	result.RecurringEventID = series.ID
+	result.OriginalDate = &occurrence
+	return result, nil
+}
+
+// DeleteEvent удаляет событие; version — ожидаемая версия события, 0 отключает проверку.
+func (sc *ServiceCalendar) DeleteEvent(ctx context.Context, eventID, userID, version int) error {
+	ctx, span := tracing.Start(ctx, "calendar.DeleteEvent")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	current, err := sc.accessibleEvent(ctx, eventID, userID, true)
+	if err != nil {
+		return err
+	}
+
+	return sc.repo.DeleteEvent(ctx, eventID, current.UserID, version)
+}
+
+// DeleteOccurrence удаляет из серии eventID одно вхождение, приходящееся на день occurrenceDate.
+// version — ожидаемая версия серии, 0 отключает проверку.
+func (sc *ServiceCalendar) DeleteOccurrence(ctx context.Context, eventID, userID int, occurrenceDate time.Time, version int) error {
+	ctx, span := tracing.Start(ctx, "calendar.DeleteOccurrence")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	series, occurrence, err := sc.findOccurrence(ctx, eventID, userID, occurrenceDate, true)
+	if err != nil {
+		return err
+	}
+
+	return sc.repo.DeleteOccurrence(ctx, eventID, series.UserID, occurrence, version)
+}
+
+// GetEventsForDay, GetEventsForWeek и GetEventsForMonth возвращают события
+// всех календарей, видимых пользователю, или только календаря calendarID,
+// если он не нулевой.
+func (sc *ServiceCalendar) GetEventsForDay(ctx context.Context, userID, calendarID int, date time.Time) ([]repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.GetEventsForDay")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	from, to := repository.DayRange(date)
+	return sc.visibleEvents(ctx, userID, calendarID, from, to)
+}
+
+func (sc *ServiceCalendar) GetEventsForWeek(ctx context.Context, userID, calendarID int, date time.Time) ([]repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.GetEventsForWeek")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	from, to := repository.WeekRange(date)
+	return sc.visibleEvents(ctx, userID, calendarID, from, to)
+}
+
+func (sc *ServiceCalendar) GetEventsForMonth(ctx context.Context, userID, calendarID int, date time.Time) ([]repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.GetEventsForMonth")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	from, to := repository.MonthRange(date)
+	return sc.visibleEvents(ctx, userID, calendarID, from, to)
+}
+
+func (sc *ServiceCalendar) withOccurrences(ctx context.Context, userID int, events []repository.Event, from, to time.Time) ([]repository.Event, error) {
+	series, err := sc.repo.GetRecurringEvents(ctx, userID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range series {
+		occurrences, err := expandSeries(s, from, to)
+		if err != nil {
+			sc.log.Warn("skipping series with broken recurrence",
+				"event_id", s.ID,
+				"recurrence", s.Recurrence,
+				"error", err,
+			)
+			continue
+		}
+		events = append(events, occurrences...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	return events, nil
+}
+
+func (sc *ServiceCalendar) findOccurrence(ctx context.Context, eventID, userID int, occurrenceDate time.Time, write bool) (repository.Event, time.Time, error) {
+	series, err := sc.accessibleEvent(ctx, eventID, userID, write)
+	if err != nil {
+		return repository.Event{}, time.Time{}, err
+	}
+
+	if !series.IsRecurring() {
+		return repository.Event{}, time.Time{}, repository.ErrEventNotFound
+	}
+
+	dayStart, _ := repository.DayRange(occurrenceDate)
+	from := time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), 0, 0, 0, 0, series.Date.Location())
+	occurrences, err := expandSeries(series, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return repository.Event{}, time.Time{}, err
+	}
+
+	// Многодневное вхождение, начавшееся накануне, тоже пересекается с этим днём.
+	for _, occurrence := range occurrences {
+		if !occurrence.Date.Before(from) {
+			return series, occurrence.Date, nil
+		}
+	}
+
+	return repository.Event{}, time.Time{}, repository.ErrEventNotFound
+}
+
+// expandSeries разворачивает серию в отдельные вхождения, пересекающиеся с [from, to),
+// пропуская исключённые даты.
+func expandSeries(series repository.Event, from, to time.Time) ([]repository.Event, error) {
+	rule, err := recurrence.Parse(series.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	windowFrom, windowTo := from, to
+	if series.AllDay {
+		windowFrom, windowTo = repository.FloatingDate(from), repository.FloatingDate(to)
+	}
+	duration := series.EndTime().Sub(series.Date)
+
+	var result []repository.Event
+	for _, date := range rule.Between(series.Date, windowFrom.Add(-duration), windowTo) {
+		if series.IsExcluded(date) {
+			continue
+		}
+
+		occurrence := series
+		occurrence.Date = date
+		occurrence.End = date.Add(duration)
+		if !occurrence.Overlaps(from, to) {
+			continue
+		}
+		occurrence.RecurringEventID = series.ID
+		original := date
+		occurrence.OriginalDate = &original
+		result = append(result, occurrence)
+	}
+
+	return result, nil
+}
+
+// prepareCreate проверяет новое событие как prepareEvent; событие календаря
+// получает UserID владельца календаря.
+func (sc *ServiceCalendar) prepareCreate(ctx context.Context, event *repository.Event) error {
+	if event.CalendarID != 0 {
+		cal, err := sc.editableCalendar(ctx, event.CalendarID, event.UserID)
+		if err != nil {
+			return err
+		}
+		event.UserID = cal.UserID
+	}
+
+	return prepareEvent(event)
+}
+
+// prepareUpdate дополняет изменённое событие текущими исключёнными датами
+// и напоминаниями, если они не переданы, и проверяет его как prepareEvent.
+// Событие получает UserID владельца и остаётся в своём календаре; возвращается
+// текущее состояние события.
+func (sc *ServiceCalendar) prepareUpdate(ctx context.Context, event *repository.Event) (repository.Event, error) {
+	if strings.TrimSpace(event.Title) == "" {
 		return repository.Event{}, repository.ErrInvalidDataInput
 	}
-	return sc.repo.UpdateEvent(eventID, userID, date, title)
+
+	current, err := sc.accessibleEvent(ctx, event.ID, event.UserID, true)
+	if err != nil {
+		return repository.Event{}, err
+	}
+	if event.CalendarID != 0 && event.CalendarID != current.CalendarID {
+		return repository.Event{}, fmt.Errorf("%w: event cannot be moved to another calendar", repository.ErrInvalidDataInput)
+	}
+	event.UserID = current.UserID
+
+	if event.IsRecurring() && event.ExDates == nil {
+		event.ExDates = current.ExDates
+	}
+	if event.Reminders == nil {
+		event.Reminders = current.Reminders
+	}
+
+	return current, prepareEvent(event)
 }
 
-func (sc *ServiceCalendar) DeleteEvent(eventID, userID int) error {
-	return sc.repo.DeleteEvent(eventID, userID)
+// prepareEvent проверяет событие перед сохранением и приводит к единому виду
+// его время, правило повторения и напоминания.
+func prepareEvent(event *repository.Event) error {
+	if strings.TrimSpace(event.Title) == "" {
+		return repository.ErrInvalidDataInput
+	}
+
+	if err := normalizeTime(event); err != nil {
+		return err
+	}
+
+	if err := normalizeRecurrence(event); err != nil {
+		return err
+	}
+
+	return normalizeReminders(event)
 }
 
-func (sc *ServiceCalendar) GetEventsForDay(userID int, date time.Time) []repository.Event {
-	return sc.repo.GetEventsForDay(userID, date)
+// normalizeTime проверяет часовой пояс события и переводит в него время начала
+// и конца. События на весь день хранятся как даты в полночь UTC. Если конец
+// не задан, событие длится весь день или не имеет длительности.
+func normalizeTime(event *repository.Event) error {
+	if event.TimeZone == "" {
+		event.TimeZone = "UTC"
+	}
+
+	loc, err := time.LoadLocation(event.TimeZone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown time zone %q", repository.ErrInvalidDataInput, event.TimeZone)
+	}
+
+	if event.AllDay {
+		event.Date = repository.FloatingDate(event.Date)
+		if event.End.IsZero() {
+			event.End = event.Date.AddDate(0, 0, 1)
+		}
+		event.End = repository.FloatingDate(event.End)
+		if !event.End.After(event.Date) {
+			return fmt.Errorf("%w: all-day event must end after its start date", repository.ErrInvalidDataInput)
+		}
+		return nil
+	}
+
+	event.Date = event.Date.In(loc)
+	if event.End.IsZero() {
+		event.End = event.Date
+	}
+	event.End = event.End.In(loc)
+	if event.End.Before(event.Date) {
+		return fmt.Errorf("%w: end must not be before start", repository.ErrInvalidDataInput)
+	}
+
+	return nil
 }
 
-func (sc *ServiceCalendar) GetEventsForWeek(userID int, date time.Time) []repository.Event {
-	return sc.repo.GetEventsForWeek(userID, date)
+// normalizeRecurrence приводит правило к каноническому виду и переносит
+// исключённые даты на время начала серии.
+func normalizeRecurrence(event *repository.Event) error {
+	if event.Recurrence == "" {
+		if len(event.ExDates) > 0 {
+			return fmt.Errorf("%w: exdates require recurrence", repository.ErrInvalidDataInput)
+		}
+		return nil
+	}
+
+	rule, err := recurrence.Parse(event.Recurrence)
+	if err != nil {
+		return fmt.Errorf("%w: %v", repository.ErrInvalidDataInput, err)
+	}
+	event.Recurrence = rule.String()
+
+	if len(event.ExDates) == 0 {
+		return nil
+	}
+
+	exDates := make([]time.Time, 0, len(event.ExDates))
+	for _, exDate := range event.ExDates {
+		start := event.Date
+		year, month, day := exDate.In(start.Location()).Date()
+		exDates = append(exDates, time.Date(year, month, day,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location()))
+	}
+	event.ExDates = exDates
+
+	return nil
 }
 
-func (sc *ServiceCalendar) GetEventsForMonth(userID int, date time.Time) []repository.Event {
-	return sc.repo.GetEventsForMonth(userID, date)
+// Ping проверяет, что хранилище событий доступно.
+func (sc *ServiceCalendar) Ping(ctx context.Context) error {
+	return sc.repo.Ping(ctx)
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func testLogger() *slog.Logger {
@@ -35,7 +36,7 @@ func TestCalendarService_Validation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := service.CreateEvent(1, testDate, tt.title)
+			_, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: testDate, Title: tt.title})
 			if tt.shouldErr {
 				assert.Error(t, err)
 				assert.Equal(t, repository.ErrInvalidDataInput, err)
@@ -53,20 +54,20 @@ func TestCalendarService_ErrorHandling(t *testing.T) {
 	testDate := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
 
 	t.Run("update non-existent event", func(t *testing.T) {
-		_, err := service.UpdateEvent(999, 1, testDate, "Title")
+		_, err := service.UpdateEvent(t.Context(), repository.Event{ID: 999, UserID: 1, Date: testDate, Title: "Title"})
 		assert.Error(t, err)
 		assert.Equal(t, repository.ErrEventNotFound, err)
 	})
 
 	t.Run("delete non-existent event", func(t *testing.T) {
-		err := service.DeleteEvent(999, 1)
+		err := service.DeleteEvent(t.Context(), 999, 1, 0)
 		assert.Error(t, err)
 		assert.Equal(t, repository.ErrEventNotFound, err)
 	})
 
 	t.Run("update event with wrong user", func(t *testing.T) {
-		event, _ := service.CreateEvent(1, testDate, "Test Event")
-		_, err := service.UpdateEvent(event.ID, 999, testDate, "New Title")
+		event, _ := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: testDate, Title: "Test Event"})
+		_, err := service.UpdateEvent(t.Context(), repository.Event{ID: event.ID, UserID: 999, Date: testDate, Title: "New Title"})
 		assert.Error(t, err)
 		assert.Equal(t, repository.ErrEventNotFound, err)
 	})
@@ -79,26 +80,29 @@ func TestCalendarService_BusinessLogic(t *testing.T) {
 	testDate := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
 
 	t.Run("event lifecycle", func(t *testing.T) {
-		event, err := service.CreateEvent(1, testDate, "Meeting")
+		event, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: testDate, Title: "Meeting"})
 		assert.NoError(t, err)
 		assert.Equal(t, "Meeting", event.Title)
 
-		events := service.GetEventsForDay(1, testDate)
+		events, err := service.GetEventsForDay(t.Context(), 1, 0, testDate)
+		assert.NoError(t, err)
 		assert.Len(t, events, 1)
 		assert.Equal(t, "Meeting", events[0].Title)
 
-		updatedEvent, err := service.UpdateEvent(event.ID, event.UserID, testDate, "Updated Meeting")
+		updatedEvent, err := service.UpdateEvent(t.Context(), repository.Event{ID: event.ID, UserID: event.UserID, Date: testDate, Title: "Updated Meeting"})
 		assert.NoError(t, err)
 		assert.Equal(t, "Updated Meeting", updatedEvent.Title)
 
-		events = service.GetEventsForDay(1, testDate)
+		events, err = service.GetEventsForDay(t.Context(), 1, 0, testDate)
+		assert.NoError(t, err)
 		assert.Len(t, events, 1)
 		assert.Equal(t, "Updated Meeting", events[0].Title)
 
-		err = service.DeleteEvent(event.ID, event.UserID)
+		err = service.DeleteEvent(t.Context(), event.ID, event.UserID, 0)
 		assert.NoError(t, err)
 
-		events = service.GetEventsForDay(1, testDate)
+		events, err = service.GetEventsForDay(t.Context(), 1, 0, testDate)
+		assert.NoError(t, err)
 		assert.Empty(t, events)
 	})
 }
@@ -116,7 +120,7 @@ func TestCalendarService_ConcurrentValidation(t *testing.T) {
 
 		for i := 0; i < iterations; i++ {
 			go func(userID int) {
-				_, err := service.CreateEvent(userID, testDate, "Valid Event")
+				_, err := service.CreateEvent(t.Context(), repository.Event{UserID: userID, Date: testDate, Title: "Valid Event"})
 				assert.NoError(t, err)
 				validDone <- true
 			}(i % 10)
@@ -124,7 +128,7 @@ func TestCalendarService_ConcurrentValidation(t *testing.T) {
 
 		for i := 0; i < iterations; i++ {
 			go func() {
-				_, err := service.CreateEvent(1, testDate, "   ")
+				_, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: testDate, Title: "   "})
 				assert.Error(t, err)
 				invalidDone <- true
 			}()
@@ -140,9 +144,214 @@ func TestCalendarService_ConcurrentValidation(t *testing.T) {
 
 		totalEvents := 0
 		for userID := 0; userID < 10; userID++ {
-			events := service.GetEventsForMonth(userID, testDate)
+			events, err := service.GetEventsForMonth(t.Context(), userID, 0, testDate)
+			assert.NoError(t, err)
 			totalEvents += len(events)
 		}
 		assert.Equal(t, iterations, totalEvents)
 	})
 }
+
+func TestCalendarService_RecurringEvents(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	start := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+	series, err := service.CreateEvent(t.Context(), repository.Event{
+		UserID:     1,
+		Date:       start,
+		Title:      "Standup",
+		Recurrence: "freq=weekly;byday=mo,we",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", series.Recurrence)
+
+	t.Run("occurrences are expanded in window", func(t *testing.T) {
+		events, err := service.GetEventsForWeek(t.Context(), 1, 0, time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, time.Date(2025, 9, 8, 9, 0, 0, 0, time.UTC), events[0].Date)
+		assert.Equal(t, time.Date(2025, 9, 10, 9, 0, 0, 0, time.UTC), events[1].Date)
+		assert.Equal(t, series.ID, events[0].RecurringEventID)
+
+		events, err = service.GetEventsForMonth(t.Context(), 1, 0, start)
+		require.NoError(t, err)
+		assert.Len(t, events, 9)
+
+		events, err = service.GetEventsForDay(t.Context(), 1, 0, time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("delete single occurrence", func(t *testing.T) {
+		err := service.DeleteOccurrence(t.Context(), series.ID, 1, time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC), 0)
+		require.NoError(t, err)
+
+		events, err := service.GetEventsForDay(t.Context(), 1, 0, time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		events, err = service.GetEventsForDay(t.Context(), 1, 0, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("edit single occurrence", func(t *testing.T) {
+		moved := time.Date(2025, 9, 9, 14, 0, 0, 0, time.UTC)
+		override, err := service.UpdateOccurrence(t.Context(), series.ID, 1, time.Date(2025, 9, 8, 0, 0, 0, 0, time.UTC),
+			repository.Event{Date: moved, Title: "Moved Standup"}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, series.ID, override.RecurringEventID)
+
+		events, err := service.GetEventsForWeek(t.Context(), 1, 0, moved)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "Moved Standup", events[0].Title)
+		assert.Equal(t, moved, events[0].Date)
+		assert.Equal(t, "Standup", events[1].Title)
+	})
+
+	t.Run("series update keeps exceptions", func(t *testing.T) {
+		_, err := service.UpdateEvent(t.Context(), repository.Event{
+			ID:         series.ID,
+			UserID:     1,
+			Date:       start,
+			Title:      "Daily Sync",
+			Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE",
+		})
+		require.NoError(t, err)
+
+		events, err := service.GetEventsForDay(t.Context(), 1, 0, time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		events, err = service.GetEventsForDay(t.Context(), 1, 0, time.Date(2025, 9, 15, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Daily Sync", events[0].Title)
+	})
+
+	t.Run("unknown occurrence", func(t *testing.T) {
+		err := service.DeleteOccurrence(t.Context(), series.ID, 1, time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC), 0)
+		assert.Equal(t, repository.ErrEventNotFound, err)
+
+		err = service.DeleteOccurrence(t.Context(), series.ID, 1, time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC), 0)
+		assert.Equal(t, repository.ErrEventNotFound, err)
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		_, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: start, Title: "Bad", Recurrence: "FREQ=HOURLY"})
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+	})
+}
+
+func TestCalendarService_TimeZones(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	t.Run("series keeps local time across DST change", func(t *testing.T) {
+		series, err := service.CreateEvent(t.Context(), repository.Event{
+			UserID:     1,
+			Date:       time.Date(2025, 10, 20, 10, 0, 0, 0, berlin),
+			End:        time.Date(2025, 10, 20, 11, 0, 0, 0, berlin),
+			TimeZone:   "Europe/Berlin",
+			Title:      "Planning",
+			Recurrence: "FREQ=WEEKLY",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Berlin", series.Date.Location().String())
+
+		events, err := service.GetEventsForMonth(t.Context(), 1, 0, time.Date(2025, 10, 1, 0, 0, 0, 0, berlin))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		for _, e := range events {
+			assert.Equal(t, 10, e.Date.Hour())
+			assert.Equal(t, time.Hour, e.End.Sub(e.Date))
+		}
+		assert.Equal(t, 8, events[0].Date.UTC().Hour())
+		assert.Equal(t, 9, events[1].Date.UTC().Hour())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		timed, err := service.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), Title: "Call"})
+		require.NoError(t, err)
+		assert.Equal(t, "UTC", timed.TimeZone)
+		assert.Equal(t, timed.Date, timed.End)
+
+		allDay, err := service.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: time.Date(2025, 9, 1, 0, 0, 0, 0, berlin), AllDay: true, Title: "Holiday"})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), allDay.Date)
+		assert.Equal(t, time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC), allDay.End)
+
+		_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: timed.Date, End: timed.Date.Add(-time.Hour), Title: "Backwards"})
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+
+		_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: timed.Date, TimeZone: "Mars/Olympus", Title: "Nowhere"})
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+	})
+
+	t.Run("multi-day occurrences overlap following days", func(t *testing.T) {
+		trip, err := service.CreateEvent(t.Context(), repository.Event{
+			UserID:     3,
+			Date:       time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC),
+			End:        time.Date(2025, 9, 8, 0, 0, 0, 0, time.UTC),
+			AllDay:     true,
+			Title:      "Weekend trip",
+			Recurrence: "FREQ=WEEKLY;BYDAY=FR",
+		})
+		require.NoError(t, err)
+
+		for day, expected := range map[int]int{5: 1, 6: 1, 7: 1, 8: 0, 12: 1} {
+			events, err := service.GetEventsForDay(t.Context(), 3, 0, time.Date(2025, 9, day, 0, 0, 0, 0, berlin))
+			require.NoError(t, err)
+			assert.Len(t, events, expected, "September %d", day)
+		}
+
+		// Вхождение адресуется днём начала, а не любым днём, который оно занимает.
+		err = service.DeleteOccurrence(t.Context(), trip.ID, 3, time.Date(2025, 9, 6, 0, 0, 0, 0, time.UTC), 0)
+		assert.Equal(t, repository.ErrEventNotFound, err)
+
+		require.NoError(t, service.DeleteOccurrence(t.Context(), trip.ID, 3, time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC), 0))
+		events, err := service.GetEventsForDay(t.Context(), 3, 0, time.Date(2025, 9, 6, 0, 0, 0, 0, berlin))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
+func TestCalendarService_ApplyBatch(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	existing, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: date, Title: "Standup", Reminders: []int{10}})
+	require.NoError(t, err)
+
+	t.Run("invalid event fails the batch before storage", func(t *testing.T) {
+		_, err := service.ApplyBatch(t.Context(), []repository.BatchOp{
+			{Kind: repository.BatchCreate, Event: repository.Event{UserID: 1, Date: date, Title: "Review"}},
+			{Kind: repository.BatchCreate, Event: repository.Event{UserID: 1, Date: date, Title: "  "}},
+		})
+
+		var batchErr *repository.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Index)
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+
+		count, err := repo.CountEvents(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("update keeps reminders like UpdateEvent", func(t *testing.T) {
+		results, err := service.ApplyBatch(t.Context(), []repository.BatchOp{
+			{Kind: repository.BatchUpdate, Event: repository.Event{ID: existing.ID, UserID: 1, Date: date, Title: "Moved"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Moved", results[0].Title)
+		assert.Equal(t, []int{10}, results[0].Reminders)
+	})
+}
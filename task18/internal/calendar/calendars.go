@@ -0,0 +1,289 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+var ErrPermissionDenied = errors.New("calendar role does not allow this action")
+
+// eventSource — события владельца ownerID, видимые пользователю: все, если
+// all, иначе только события календарей calendars и события events, на которые
+// пользователь приглашён, вместе с вхождениями их серий.
+type eventSource struct {
+	ownerID   int
+	all       bool
+	calendars []int
+	events    []int
+}
+
+func (s eventSource) includes(e repository.Event) bool {
+	return s.all || slices.Contains(s.calendars, e.CalendarID) ||
+		slices.Contains(s.events, e.ID) || (e.RecurringEventID != 0 && slices.Contains(s.events, e.RecurringEventID))
+}
+
+func (sc *ServiceCalendar) CreateCalendar(ctx context.Context, cal repository.Calendar) (repository.Calendar, error) {
+	ctx, span := tracing.Start(ctx, "calendar.CreateCalendar")
+	defer span.End()
+	span.SetAttr("user_id", cal.UserID)
+
+	return sc.repo.CreateCalendar(ctx, cal)
+}
+
+// Calendars возвращает календари пользователя и открытые ему чужие календари.
+func (sc *ServiceCalendar) Calendars(ctx context.Context, userID int) ([]repository.Calendar, error) {
+	ctx, span := tracing.Start(ctx, "calendar.Calendars")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	return sc.repo.ListCalendars(ctx, userID)
+}
+
+func (sc *ServiceCalendar) GetCalendar(ctx context.Context, calendarID, userID int) (repository.Calendar, error) {
+	ctx, span := tracing.Start(ctx, "calendar.GetCalendar")
+	defer span.End()
+	span.SetAttr("calendar_id", calendarID)
+	span.SetAttr("user_id", userID)
+
+	return sc.repo.GetCalendar(ctx, calendarID, userID)
+}
+
+// RenameCalendar переименовывает календарь; это может только владелец.
+func (sc *ServiceCalendar) RenameCalendar(ctx context.Context, calendarID, userID int, name string) (repository.Calendar, error) {
+	ctx, span := tracing.Start(ctx, "calendar.RenameCalendar")
+	defer span.End()
+	span.SetAttr("calendar_id", calendarID)
+	span.SetAttr("user_id", userID)
+
+	if _, err := sc.ownedCalendar(ctx, calendarID, userID); err != nil {
+		return repository.Calendar{}, err
+	}
+
+	return sc.repo.UpdateCalendar(ctx, repository.Calendar{ID: calendarID, UserID: userID, Name: name})
+}
+
+// DeleteCalendar удаляет календарь вместе с его событиями; это может только владелец.
+func (sc *ServiceCalendar) DeleteCalendar(ctx context.Context, calendarID, userID int) error {
+	ctx, span := tracing.Start(ctx, "calendar.DeleteCalendar")
+	defer span.End()
+	span.SetAttr("calendar_id", calendarID)
+	span.SetAttr("user_id", userID)
+
+	if _, err := sc.ownedCalendar(ctx, calendarID, userID); err != nil {
+		return err
+	}
+
+	return sc.repo.DeleteCalendar(ctx, calendarID, userID)
+}
+
+// CalendarShares возвращает, кому владелец ownerID открыл календарь.
+func (sc *ServiceCalendar) CalendarShares(ctx context.Context, calendarID, ownerID int) ([]repository.CalendarShare, error) {
+	ctx, span := tracing.Start(ctx, "calendar.CalendarShares")
+	defer span.End()
+	span.SetAttr("calendar_id", calendarID)
+	span.SetAttr("user_id", ownerID)
+
+	if _, err := sc.ownedCalendar(ctx, calendarID, ownerID); err != nil {
+		return nil, err
+	}
+
+	return sc.repo.ListCalendarShares(ctx, calendarID)
+}
+
+// ShareCalendar открывает календарь владельца ownerID пользователю share.UserID
+// с ролью share.Role или меняет роль уже открытого календаря.
+func (sc *ServiceCalendar) ShareCalendar(ctx context.Context, ownerID int, share repository.CalendarShare) (repository.CalendarShare, error) {
+	ctx, span := tracing.Start(ctx, "calendar.ShareCalendar")
+	defer span.End()
+	span.SetAttr("calendar_id", share.CalendarID)
+	span.SetAttr("user_id", ownerID)
+
+	if _, err := sc.ownedCalendar(ctx, share.CalendarID, ownerID); err != nil {
+		return repository.CalendarShare{}, err
+	}
+
+	return sc.repo.ShareCalendar(ctx, share)
+}
+
+// UnshareCalendar закрывает пользователю shareUserID доступ к календарю.
+// Это может владелец или сам пользователь, отказываясь от чужого календаря.
+func (sc *ServiceCalendar) UnshareCalendar(ctx context.Context, calendarID, userID, shareUserID int) error {
+	ctx, span := tracing.Start(ctx, "calendar.UnshareCalendar")
+	defer span.End()
+	span.SetAttr("calendar_id", calendarID)
+	span.SetAttr("user_id", userID)
+
+	cal, err := sc.repo.GetCalendar(ctx, calendarID, userID)
+	if err != nil {
+		return err
+	}
+	if cal.Role != repository.RoleOwner && userID != shareUserID {
+		return ErrPermissionDenied
+	}
+
+	return sc.repo.UnshareCalendar(ctx, calendarID, shareUserID)
+}
+
+// ownedCalendar возвращает календарь, если userID — его владелец, и
+// ErrPermissionDenied, если календарь лишь открыт пользователю.
+func (sc *ServiceCalendar) ownedCalendar(ctx context.Context, calendarID, userID int) (repository.Calendar, error) {
+	cal, err := sc.repo.GetCalendar(ctx, calendarID, userID)
+	if err != nil {
+		return repository.Calendar{}, err
+	}
+	if cal.Role != repository.RoleOwner {
+		return repository.Calendar{}, ErrPermissionDenied
+	}
+	return cal, nil
+}
+
+// editableCalendar возвращает календарь, в котором userID может менять события.
+func (sc *ServiceCalendar) editableCalendar(ctx context.Context, calendarID, userID int) (repository.Calendar, error) {
+	cal, err := sc.repo.GetCalendar(ctx, calendarID, userID)
+	if err != nil {
+		return repository.Calendar{}, err
+	}
+	if !cal.CanEdit() {
+		return repository.Calendar{}, ErrPermissionDenied
+	}
+	return cal, nil
+}
+
+// accessibleEvent находит событие eventID среди событий пользователя,
+// событий открытых ему календарей и событий, на которые он приглашён.
+// Событие возвращается с UserID владельца, под которым его хранит хранилище.
+// write требует права менять события календаря, иначе возвращается
+// ErrPermissionDenied; приглашённый события не меняет.
+func (sc *ServiceCalendar) accessibleEvent(ctx context.Context, eventID, userID int, write bool) (repository.Event, error) {
+	found, err := sc.repo.GetEvent(ctx, eventID, userID)
+	if !errors.Is(err, repository.ErrEventNotFound) {
+		return found, err
+	}
+
+	calendars, err := sc.repo.ListCalendars(ctx, userID)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	for _, cal := range calendars {
+		if cal.UserID == userID {
+			continue
+		}
+
+		found, err := sc.repo.GetEvent(ctx, eventID, cal.UserID)
+		if errors.Is(err, repository.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return repository.Event{}, err
+		}
+		if found.CalendarID != cal.ID {
+			continue
+		}
+
+		if write && !cal.CanEdit() {
+			return repository.Event{}, ErrPermissionDenied
+		}
+		return found, nil
+	}
+
+	found, err = sc.invitedEvent(ctx, eventID, userID)
+	if err != nil {
+		return repository.Event{}, err
+	}
+	if write {
+		return repository.Event{}, ErrPermissionDenied
+	}
+	return found, nil
+}
+
+// eventSources перечисляет, чьи события видит пользователь: свои, открытые
+// ему календари и события, приглашения на которые он не отклонил, или, если
+// calendarID не нулевой, только этот календарь.
+func (sc *ServiceCalendar) eventSources(ctx context.Context, userID, calendarID int) ([]eventSource, error) {
+	if calendarID != 0 {
+		cal, err := sc.repo.GetCalendar(ctx, calendarID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return []eventSource{{ownerID: cal.UserID, calendars: []int{cal.ID}}}, nil
+	}
+
+	calendars, err := sc.repo.ListCalendars(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := sc.repo.ListInvitations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := []eventSource{{ownerID: userID, all: true}}
+	byOwner := make(map[int]int)
+	source := func(ownerID int) *eventSource {
+		i, ok := byOwner[ownerID]
+		if !ok {
+			i = len(sources)
+			byOwner[ownerID] = i
+			sources = append(sources, eventSource{ownerID: ownerID})
+		}
+		return &sources[i]
+	}
+
+	for _, cal := range calendars {
+		if cal.UserID == userID {
+			continue
+		}
+		s := source(cal.UserID)
+		s.calendars = append(s.calendars, cal.ID)
+	}
+	for _, invitation := range invitations {
+		if invitation.Status == repository.RSVPDeclined {
+			continue
+		}
+		s := source(invitation.OrganizerID)
+		s.events = append(s.events, invitation.EventID)
+	}
+	return sources, nil
+}
+
+// visibleEvents возвращает события и вхождения серий, пересекающиеся с
+// [from, to), из всех календарей, видимых пользователю, или только из
+// calendarID, если он не нулевой.
+func (sc *ServiceCalendar) visibleEvents(ctx context.Context, userID, calendarID int, from, to time.Time) ([]repository.Event, error) {
+	sources, err := sc.eventSources(ctx, userID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []repository.Event
+	for _, source := range sources {
+		found, err := sc.repo.GetEventsBetween(ctx, source.ownerID, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		found, err = sc.withOccurrences(ctx, source.ownerID, found, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range found {
+			if source.includes(e) {
+				events = append(events, e)
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	return events, nil
+}
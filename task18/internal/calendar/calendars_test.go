@@ -0,0 +1,92 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_SharedCalendars(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+	monday := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	team, err := service.CreateCalendar(t.Context(), repository.Calendar{UserID: 1, Name: "team"})
+	require.NoError(t, err)
+	personal, err := service.CreateCalendar(t.Context(), repository.Calendar{UserID: 1, Name: "personal"})
+	require.NoError(t, err)
+
+	standup, err := service.CreateEvent(t.Context(), repository.Event{
+		UserID: 1, CalendarID: team.ID, Date: monday, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5",
+	})
+	require.NoError(t, err)
+	_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 1, CalendarID: personal.ID, Date: monday, Title: "Gym"})
+	require.NoError(t, err)
+
+	_, err = service.ShareCalendar(t.Context(), 1, repository.CalendarShare{CalendarID: team.ID, UserID: 2, Role: repository.RoleViewer})
+	require.NoError(t, err)
+
+	t.Run("shared series is expanded for viewer", func(t *testing.T) {
+		events, err := service.GetEventsForWeek(t.Context(), 2, 0, monday)
+		require.NoError(t, err)
+		require.Len(t, events, 5)
+		for _, e := range events {
+			assert.Equal(t, standup.ID, e.RecurringEventID)
+		}
+	})
+
+	t.Run("owner filters by calendar", func(t *testing.T) {
+		events, err := service.GetEventsForDay(t.Context(), 1, personal.ID, monday)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Gym", events[0].Title)
+
+		events, err = service.GetEventsForDay(t.Context(), 1, 0, monday)
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+
+	t.Run("hidden calendar is not found", func(t *testing.T) {
+		_, err := service.GetEventsForDay(t.Context(), 2, personal.ID, monday)
+		assert.ErrorIs(t, err, repository.ErrCalendarNotFound)
+	})
+
+	t.Run("viewer cannot change occurrences", func(t *testing.T) {
+		err := service.DeleteOccurrence(t.Context(), standup.ID, 2, monday.AddDate(0, 0, 1), 0)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = service.ApplyBatch(t.Context(), []repository.BatchOp{
+			{Kind: repository.BatchDelete, Event: repository.Event{ID: standup.ID, UserID: 2}},
+		})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	t.Run("editor changes occurrences", func(t *testing.T) {
+		_, err := service.ShareCalendar(t.Context(), 1, repository.CalendarShare{CalendarID: team.ID, UserID: 2, Role: repository.RoleEditor})
+		require.NoError(t, err)
+
+		require.NoError(t, service.DeleteOccurrence(t.Context(), standup.ID, 2, monday.AddDate(0, 0, 1), 0))
+
+		override, err := service.UpdateOccurrence(t.Context(), standup.ID, 2, monday.AddDate(0, 0, 2),
+			repository.Event{Date: monday.AddDate(0, 0, 2).Add(time.Hour), Title: "Late standup"}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, team.ID, override.CalendarID)
+		assert.Equal(t, 1, override.UserID)
+
+		events, err := service.GetEventsForWeek(t.Context(), 2, team.ID, monday)
+		require.NoError(t, err)
+		assert.Len(t, events, 4)
+	})
+
+	t.Run("events stay in their calendar", func(t *testing.T) {
+		gym, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, CalendarID: personal.ID, Date: monday, Title: "Gym"})
+		require.NoError(t, err)
+
+		gym.CalendarID = team.ID
+		_, err = service.UpdateEvent(t.Context(), gym)
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+	})
+}
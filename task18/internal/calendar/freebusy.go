@@ -0,0 +1,214 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrFreeBusyDenied — запрошена занятость пользователя, который не открыл
+// запрашивающему ни одного календаря.
+var ErrFreeBusyDenied = errors.New("free/busy is available only for users who shared a calendar with you")
+
+// FreeBusyQuery — занятость пользователей UserIDs в [From, To) по запросу
+// пользователя RequesterID.
+// WorkStart и WorkEnd — рабочие часы как смещения от начала дня в Location;
+// свободное время ищется только внутри них. Duration — длина искомых окон,
+// 0 — окна не нужны; Limit ограничивает их число.
+type FreeBusyQuery struct {
+	RequesterID int
+	UserIDs     []int
+	From        time.Time
+	To          time.Time
+	Location    *time.Location
+	WorkStart   time.Duration
+	WorkEnd     time.Duration
+	Duration    time.Duration
+	Limit       int
+}
+
+type FreeBusy struct {
+	Busy  []repository.Interval
+	Free  []repository.Interval
+	Slots []repository.Interval
+}
+
+// FreeBusy объединяет занятость пользователей и находит общие свободные окна.
+// Занятость задают только события со временем: события на весь день и события
+// нулевой длины время не занимают. Запросить можно свою занятость и занятость
+// пользователей, открывших запрашивающему календарь, иначе — ErrFreeBusyDenied.
+func (sc *ServiceCalendar) FreeBusy(ctx context.Context, query FreeBusyQuery) (FreeBusy, error) {
+	ctx, span := tracing.Start(ctx, "calendar.FreeBusy")
+	defer span.End()
+	span.SetAttr("users", len(query.UserIDs))
+
+	if !query.To.After(query.From) || query.To.After(query.From.AddDate(0, 0, event.MaxRangeDays)) {
+		return FreeBusy{}, fmt.Errorf("%w: invalid range", repository.ErrInvalidDataInput)
+	}
+	if query.WorkEnd <= query.WorkStart || query.WorkEnd > 24*time.Hour {
+		return FreeBusy{}, fmt.Errorf("%w: invalid working hours", repository.ErrInvalidDataInput)
+	}
+	if query.Location == nil {
+		query.Location = time.UTC
+	}
+	if query.Limit <= 0 {
+		query.Limit = event.DefaultPageLimit
+	}
+	if err := sc.checkFreeBusyAccess(ctx, query.RequesterID, query.UserIDs); err != nil {
+		return FreeBusy{}, err
+	}
+
+	var intervals []repository.Interval
+	for _, userID := range query.UserIDs {
+		events, err := sc.repo.GetEventsBetween(ctx, userID, query.From, query.To)
+		if err != nil {
+			return FreeBusy{}, err
+		}
+
+		events, err = sc.withOccurrences(ctx, userID, events, query.From, query.To)
+		if err != nil {
+			return FreeBusy{}, err
+		}
+
+		for _, e := range events {
+			if e.AllDay {
+				continue
+			}
+			start, end := maxTime(e.Date, query.From), minTime(e.End, query.To)
+			if end.After(start) {
+				intervals = append(intervals, repository.Interval{Start: start.In(query.Location), End: end.In(query.Location)})
+			}
+		}
+	}
+
+	result := FreeBusy{
+		Busy:  mergeIntervals(intervals),
+		Free:  []repository.Interval{},
+		Slots: []repository.Interval{},
+	}
+
+	for _, window := range workingWindows(query) {
+		for _, free := range subtractIntervals(window, result.Busy) {
+			if free.End.Sub(free.Start) < query.Duration {
+				continue
+			}
+			result.Free = append(result.Free, free)
+
+			if query.Duration == 0 {
+				continue
+			}
+			for start := free.Start; !start.Add(query.Duration).After(free.End) && len(result.Slots) < query.Limit; start = start.Add(query.Duration) {
+				result.Slots = append(result.Slots, repository.Interval{Start: start, End: start.Add(query.Duration)})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// mergeIntervals сортирует отрезки и склеивает пересекающиеся и смежные.
+func mergeIntervals(intervals []repository.Interval) []repository.Interval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+
+	merged := []repository.Interval{}
+	for _, in := range intervals {
+		if n := len(merged); n > 0 && !in.Start.After(merged[n-1].End) {
+			merged[n-1].End = maxTime(merged[n-1].End, in.End)
+			continue
+		}
+		merged = append(merged, in)
+	}
+
+	return merged
+}
+
+// workingWindows режет [From, To) на рабочие часы каждого дня. Границы
+// считаются по часам в Location, поэтому в дни перевода часов окно
+// остаётся, например, с 09:00 до 18:00 по местному времени.
+func workingWindows(query FreeBusyQuery) []repository.Interval {
+	var windows []repository.Interval
+
+	from := query.From.In(query.Location)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, query.Location)
+	for ; day.Before(query.To); day = day.AddDate(0, 0, 1) {
+		start := maxTime(atOffset(day, query.WorkStart), query.From)
+		end := minTime(atOffset(day, query.WorkEnd), query.To)
+		if end.After(start) {
+			windows = append(windows, repository.Interval{Start: start, End: end})
+		}
+	}
+
+	return windows
+}
+
+func atOffset(day time.Time, offset time.Duration) time.Time {
+	minutes := int(offset / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// subtractIntervals возвращает части window, не покрытые отсортированными
+// непересекающимися отрезками busy.
+func subtractIntervals(window repository.Interval, busy []repository.Interval) []repository.Interval {
+	var free []repository.Interval
+
+	start := window.Start
+	for _, b := range busy {
+		if !b.End.After(start) {
+			continue
+		}
+		if !b.Start.Before(window.End) {
+			break
+		}
+		if b.Start.After(start) {
+			free = append(free, repository.Interval{Start: start, End: b.Start})
+		}
+		start = b.End
+	}
+	if window.End.After(start) {
+		free = append(free, repository.Interval{Start: start, End: window.End})
+	}
+
+	return free
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// checkFreeBusyAccess проверяет, что каждый из userIDs — сам requesterID или
+// владелец календаря, открытого requesterID.
+func (sc *ServiceCalendar) checkFreeBusyAccess(ctx context.Context, requesterID int, userIDs []int) error {
+	calendars, err := sc.repo.ListCalendars(ctx, requesterID)
+	if err != nil {
+		return err
+	}
+
+	allowed := map[int]bool{requesterID: true}
+	for _, cal := range calendars {
+		allowed[cal.UserID] = true
+	}
+	for _, userID := range userIDs {
+		if !allowed[userID] {
+			return fmt.Errorf("%w: user %d", ErrFreeBusyDenied, userID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_FreeBusy(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+	monday := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	for _, e := range []repository.Event{
+		{UserID: 1, Date: at(0, 9, 0), End: at(0, 10, 0), Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=2"},
+		{UserID: 1, Date: at(0, 12, 0), End: at(0, 13, 0), Title: "Lunch"},
+		{UserID: 2, Date: at(0, 9, 30), End: at(0, 11, 0), Title: "Review"},
+		{UserID: 2, Date: at(0, 0, 0), End: at(1, 0, 0), Title: "Holiday", AllDay: true},
+		{UserID: 3, Date: at(0, 10, 0), End: at(0, 17, 0), Title: "Not asked"},
+	} {
+		_, err := service.CreateEvent(t.Context(), e)
+		require.NoError(t, err)
+	}
+	for _, ownerID := range []int{2, 3} {
+		cal, err := service.CreateCalendar(t.Context(), repository.Calendar{UserID: ownerID, Name: "work"})
+		require.NoError(t, err)
+		_, err = service.ShareCalendar(t.Context(), ownerID, repository.CalendarShare{CalendarID: cal.ID, UserID: 1, Role: repository.RoleViewer})
+		require.NoError(t, err)
+	}
+
+	query := FreeBusyQuery{
+		RequesterID: 1,
+		UserIDs:     []int{1, 2},
+		From:        monday,
+		To:          monday.AddDate(0, 0, 2),
+		WorkStart:   9 * time.Hour,
+		WorkEnd:     14 * time.Hour,
+		Duration:    time.Hour,
+		Limit:       3,
+	}
+
+	t.Run("busy intervals of all users are merged", func(t *testing.T) {
+		result, err := service.FreeBusy(t.Context(), query)
+		require.NoError(t, err)
+		assert.Equal(t, []repository.Interval{
+			{Start: at(0, 9, 0), End: at(0, 11, 0)},
+			{Start: at(0, 12, 0), End: at(0, 13, 0)},
+			{Start: at(1, 9, 0), End: at(1, 10, 0)},
+		}, result.Busy)
+	})
+
+	t.Run("free time is within working hours and fits duration", func(t *testing.T) {
+		result, err := service.FreeBusy(t.Context(), query)
+		require.NoError(t, err)
+		assert.Equal(t, []repository.Interval{
+			{Start: at(0, 11, 0), End: at(0, 12, 0)},
+			{Start: at(0, 13, 0), End: at(0, 14, 0)},
+			{Start: at(1, 10, 0), End: at(1, 14, 0)},
+		}, result.Free)
+		assert.Equal(t, []repository.Interval{
+			{Start: at(0, 11, 0), End: at(0, 12, 0)},
+			{Start: at(0, 13, 0), End: at(0, 14, 0)},
+			{Start: at(1, 10, 0), End: at(1, 11, 0)},
+		}, result.Slots)
+	})
+
+	t.Run("longer slots skip short gaps", func(t *testing.T) {
+		query := query
+		query.Duration = 90 * time.Minute
+		result, err := service.FreeBusy(t.Context(), query)
+		require.NoError(t, err)
+		require.Len(t, result.Slots, 2)
+		assert.Equal(t, at(1, 10, 0), result.Slots[0].Start)
+		assert.Equal(t, at(1, 11, 30), result.Slots[1].Start)
+	})
+
+	t.Run("working hours follow time zone", func(t *testing.T) {
+		moscow, err := time.LoadLocation("Europe/Moscow")
+		require.NoError(t, err)
+		query := query
+		query.UserIDs = []int{3}
+		query.Location = moscow
+		query.WorkStart, query.WorkEnd = 13*time.Hour, 21*time.Hour
+		query.From = time.Date(2025, 9, 1, 0, 0, 0, 0, moscow)
+		query.To = query.From.AddDate(0, 0, 1)
+
+		result, err := service.FreeBusy(t.Context(), query)
+		require.NoError(t, err)
+		require.Len(t, result.Free, 1)
+		assert.True(t, result.Free[0].Start.Equal(at(0, 17, 0)))
+		assert.True(t, result.Free[0].End.Equal(at(0, 18, 0)))
+	})
+
+	t.Run("users who did not share a calendar are denied", func(t *testing.T) {
+		query := query
+		query.RequesterID = 2
+		_, err := service.FreeBusy(t.Context(), query)
+		assert.ErrorIs(t, err, ErrFreeBusyDenied)
+
+		query.UserIDs = []int{2}
+		_, err = service.FreeBusy(t.Context(), query)
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid working hours", func(t *testing.T) {
+		query := query
+		query.WorkEnd = query.WorkStart
+		_, err := service.FreeBusy(t.Context(), query)
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+	})
+}
@@ -0,0 +1,222 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrVersionNotFound = errors.New("event version not found in history")
+
+// HistoryQuery — история событий пользователя UserID или, если EventID не
+// нулевой, одного события, от новых записей к старым. Cursor — NextCursor
+// предыдущей страницы той же выборки.
+type HistoryQuery struct {
+	UserID  int
+	EventID int
+	Limit   int
+	Cursor  string
+}
+
+// HistoryPage — страница истории; NextCursor пуст на последней странице.
+type HistoryPage struct {
+	Entries    []repository.HistoryEntry
+	NextCursor string
+}
+
+// History возвращает страницу истории изменений. История пользователя —
+// изменения его собственных событий, кто бы их ни сделал; история события
+// доступна и тем, кому открыт его календарь, в том числе после удаления.
+func (sc *ServiceCalendar) History(ctx context.Context, query HistoryQuery) (HistoryPage, error) {
+	ctx, span := tracing.Start(ctx, "calendar.History")
+	defer span.End()
+	span.SetAttr("user_id", query.UserID)
+	span.SetAttr("event_id", query.EventID)
+
+	if query.Limit <= 0 {
+		query.Limit = event.DefaultPageLimit
+	}
+	query.Limit = min(query.Limit, event.MaxPageLimit)
+
+	var beforeID int
+	if query.Cursor != "" {
+		id, err := decodeHistoryCursor(query.Cursor)
+		if err != nil {
+			return HistoryPage{}, ErrInvalidCursor
+		}
+		beforeID = id
+	}
+
+	ownerID := query.UserID
+	if query.EventID != 0 {
+		var err error
+		if ownerID, err = sc.historyOwner(ctx, query.EventID, query.UserID, false); err != nil {
+			return HistoryPage{}, err
+		}
+	}
+
+	entries, err := sc.repo.ListHistory(ctx, repository.HistoryQuery{
+		UserID:   ownerID,
+		EventID:  query.EventID,
+		BeforeID: beforeID,
+		Limit:    query.Limit + 1,
+	})
+	if err != nil {
+		return HistoryPage{}, err
+	}
+
+	page := HistoryPage{Entries: entries}
+	if len(entries) > query.Limit {
+		page.Entries = entries[:query.Limit]
+		page.NextCursor = encodeHistoryCursor(page.Entries[query.Limit-1].ID)
+	}
+
+	return page, nil
+}
+
+// RestoreEvent возвращает событие к версии version из его истории; нулевая
+// версия восстанавливает удалённое событие в состоянии перед удалением.
+// Восстановление — новое изменение со следующей версией. Событие удалённого
+// календаря восстанавливается вне календарей.
+func (sc *ServiceCalendar) RestoreEvent(ctx context.Context, eventID, userID, version int) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.RestoreEvent")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	ownerID, err := sc.historyOwner(ctx, eventID, userID, true)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	current, err := sc.repo.GetEvent(ctx, eventID, ownerID)
+	deleted := errors.Is(err, repository.ErrEventNotFound)
+	if err != nil && !deleted {
+		return repository.Event{}, err
+	}
+	if version < 0 {
+		return repository.Event{}, fmt.Errorf("%w: version must not be negative", repository.ErrInvalidDataInput)
+	}
+	if version == 0 && !deleted {
+		return repository.Event{}, fmt.Errorf("%w: version is required to restore an event that is not deleted", repository.ErrInvalidDataInput)
+	}
+
+	entries, err := sc.repo.ListHistory(ctx, repository.HistoryQuery{UserID: ownerID, EventID: eventID})
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	target, ok := findVersion(entries, version)
+	if !ok {
+		return repository.Event{}, ErrVersionNotFound
+	}
+	target.ID, target.UserID = eventID, ownerID
+
+	if !deleted {
+		target.Version = current.Version
+		return sc.repo.RestoreEvent(ctx, target)
+	}
+
+	target.Version = entries[0].Snapshot().Version
+	if target, err = sc.withoutDeletedCalendar(ctx, target); err != nil {
+		return repository.Event{}, err
+	}
+	return sc.repo.RestoreEvent(ctx, target)
+}
+
+// withoutDeletedCalendar убирает из восстанавливаемого события календарь,
+// если тот уже удалён.
+func (sc *ServiceCalendar) withoutDeletedCalendar(ctx context.Context, event repository.Event) (repository.Event, error) {
+	if event.CalendarID == 0 {
+		return event, nil
+	}
+
+	_, err := sc.repo.GetCalendar(ctx, event.CalendarID, event.UserID)
+	if errors.Is(err, repository.ErrCalendarNotFound) {
+		event.CalendarID = 0
+		return event, nil
+	}
+	return event, err
+}
+
+// historyOwner находит владельца события eventID, историю которого может
+// видеть пользователь userID. Удалённое событие ищется по истории: календарь
+// его последнего состояния должен быть открыт пользователю, как в accessibleEvent.
+func (sc *ServiceCalendar) historyOwner(ctx context.Context, eventID, userID int, write bool) (int, error) {
+	found, err := sc.accessibleEvent(ctx, eventID, userID, write)
+	if err == nil {
+		return found.UserID, nil
+	}
+	if !errors.Is(err, repository.ErrEventNotFound) {
+		return 0, err
+	}
+
+	entries, err := sc.repo.ListHistory(ctx, repository.HistoryQuery{UserID: userID, EventID: eventID, Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) > 0 {
+		return userID, nil
+	}
+
+	calendars, err := sc.repo.ListCalendars(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, cal := range calendars {
+		if cal.UserID == userID {
+			continue
+		}
+
+		entries, err := sc.repo.ListHistory(ctx, repository.HistoryQuery{UserID: cal.UserID, EventID: eventID, Limit: 1})
+		if err != nil {
+			return 0, err
+		}
+		if len(entries) == 0 || entries[0].Snapshot().CalendarID != cal.ID {
+			continue
+		}
+
+		if write && !cal.CanEdit() {
+			return 0, ErrPermissionDenied
+		}
+		return cal.UserID, nil
+	}
+
+	return 0, repository.ErrEventNotFound
+}
+
+// findVersion ищет в истории состояние события с версией version, а при
+// нулевой версии — последнее известное состояние.
+func findVersion(entries []repository.HistoryEntry, version int) (repository.Event, bool) {
+	for _, entry := range entries {
+		for _, snapshot := range []*repository.Event{entry.New, entry.Old} {
+			if snapshot != nil && (version == 0 || snapshot.Version == version) {
+				return *snapshot, true
+			}
+		}
+	}
+	return repository.Event{}, false
+}
+
+func encodeHistoryCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeHistoryCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.Atoi(string(data))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
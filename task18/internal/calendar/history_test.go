@@ -0,0 +1,100 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_History(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+	monday := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	team, err := service.CreateCalendar(t.Context(), repository.Calendar{UserID: 1, Name: "team"})
+	require.NoError(t, err)
+	_, err = service.ShareCalendar(t.Context(), 1, repository.CalendarShare{CalendarID: team.ID, UserID: 2, Role: repository.RoleEditor})
+	require.NoError(t, err)
+	_, err = service.ShareCalendar(t.Context(), 1, repository.CalendarShare{CalendarID: team.ID, UserID: 3, Role: repository.RoleViewer})
+	require.NoError(t, err)
+
+	planning, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, CalendarID: team.ID, Date: monday, Title: "Planning"})
+	require.NoError(t, err)
+	moved, err := service.UpdateEvent(t.Context(), repository.Event{ID: planning.ID, UserID: 2, CalendarID: team.ID, Date: monday.Add(2 * time.Hour), Title: "Planning"})
+	require.NoError(t, err)
+
+	t.Run("shared event history is visible", func(t *testing.T) {
+		for _, userID := range []int{1, 2, 3} {
+			page, err := service.History(t.Context(), HistoryQuery{UserID: userID, EventID: planning.ID})
+			require.NoError(t, err)
+			require.Len(t, page.Entries, 2)
+			assert.Equal(t, repository.HistoryUpdated, page.Entries[0].Action)
+			assert.True(t, monday.Equal(page.Entries[0].Old.Date))
+		}
+
+		_, err := service.History(t.Context(), HistoryQuery{UserID: 4, EventID: planning.ID})
+		assert.ErrorIs(t, err, repository.ErrEventNotFound)
+	})
+
+	t.Run("history is paged", func(t *testing.T) {
+		page, err := service.History(t.Context(), HistoryQuery{UserID: 1, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page.Entries, 1)
+		require.NotEmpty(t, page.NextCursor)
+
+		page, err = service.History(t.Context(), HistoryQuery{UserID: 1, Limit: 1, Cursor: page.NextCursor})
+		require.NoError(t, err)
+		require.Len(t, page.Entries, 1)
+		assert.Equal(t, repository.HistoryCreated, page.Entries[0].Action)
+		assert.Empty(t, page.NextCursor)
+
+		_, err = service.History(t.Context(), HistoryQuery{UserID: 1, Cursor: "bad"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("event is restored to a past version", func(t *testing.T) {
+		_, err := service.RestoreEvent(t.Context(), planning.ID, 3, planning.Version)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		_, err = service.RestoreEvent(t.Context(), planning.ID, 2, 99)
+		assert.ErrorIs(t, err, ErrVersionNotFound)
+		_, err = service.RestoreEvent(t.Context(), planning.ID, 2, 0)
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+
+		restored, err := service.RestoreEvent(t.Context(), planning.ID, 2, planning.Version)
+		require.NoError(t, err)
+		assert.True(t, monday.Equal(restored.Date))
+		assert.Equal(t, moved.Version+1, restored.Version)
+		assert.Equal(t, 1, restored.UserID)
+	})
+
+	t.Run("deleted event is restored", func(t *testing.T) {
+		require.NoError(t, service.DeleteEvent(t.Context(), planning.ID, 1, 0))
+
+		page, err := service.History(t.Context(), HistoryQuery{UserID: 2, EventID: planning.ID})
+		require.NoError(t, err)
+		assert.Equal(t, repository.HistoryDeleted, page.Entries[0].Action)
+
+		restored, err := service.RestoreEvent(t.Context(), planning.ID, 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, planning.ID, restored.ID)
+		assert.Equal(t, team.ID, restored.CalendarID)
+
+		found, err := service.GetEvent(t.Context(), planning.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, restored.Version, found.Version)
+	})
+
+	t.Run("event of deleted calendar is restored outside calendars", func(t *testing.T) {
+		require.NoError(t, service.DeleteCalendar(t.Context(), team.ID, 1))
+
+		_, err := service.RestoreEvent(t.Context(), planning.ID, 2, 0)
+		assert.ErrorIs(t, err, repository.ErrEventNotFound)
+
+		restored, err := service.RestoreEvent(t.Context(), planning.ID, 1, 0)
+		require.NoError(t, err)
+		assert.Zero(t, restored.CalendarID)
+	})
+}
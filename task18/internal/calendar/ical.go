@@ -0,0 +1,305 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/ical"
+	"calendar/internal/recurrence"
+	"calendar/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const icalProdID = "-//calendar//Calendar Events 1.0//RU"
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+var ErrOccurrenceMismatch = errors.New("RECURRENCE-ID does not match any occurrence of the series")
+
+// ExportICal записывает все события пользователя в w в формате iCalendar.
+// Изменённые вхождения серий выгружаются отдельными VEVENT с RECURRENCE-ID.
+func (sc *ServiceCalendar) ExportICal(ctx context.Context, userID int, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "calendar.ExportICal")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	events, err := sc.repo.GetUserEvents(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	overridden := make(map[int][]time.Time)
+	for _, e := range events {
+		if e.RecurringEventID != 0 && e.OriginalDate != nil {
+			overridden[e.RecurringEventID] = append(overridden[e.RecurringEventID], *e.OriginalDate)
+		}
+	}
+
+	vevents := make([]ical.VEvent, 0, len(events))
+	for _, e := range events {
+		vevent := ical.VEvent{
+			UID:          e.UID,
+			Summary:      e.Title,
+			Start:        e.Date,
+			End:          e.End,
+			AllDay:       e.AllDay,
+			RRule:        e.Recurrence,
+			RecurrenceID: e.OriginalDate,
+			Created:      e.CreatedAt,
+			LastModified: e.UpdatedAt,
+		}
+
+		// Вхождения, заменённые отдельным VEVENT, не попадают в EXDATE,
+		// иначе клиенты скроют и саму замену.
+		for _, exDate := range e.ExDates {
+			if !containsTime(overridden[e.ID], exDate) {
+				vevent.ExDates = append(vevent.ExDates, exDate)
+			}
+		}
+
+		vevents = append(vevents, vevent)
+	}
+
+	return ical.Encode(w, icalProdID, vevents)
+}
+
+// ImportICal загружает события из iCalendar-файла. События сопоставляются по UID:
+// существующие обновляются, новые создаются. Ошибка возвращается только если
+// файл не разобран целиком; ошибки отдельных событий попадают в результат.
+func (sc *ServiceCalendar) ImportICal(ctx context.Context, userID int, r io.Reader) ([]repository.ImportResult, error) {
+	ctx, span := tracing.Start(ctx, "calendar.ImportICal")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	components, err := ical.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := sc.repo.GetUserEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newImportIndex(existing)
+	results := make([]repository.ImportResult, len(components))
+
+	var instances []int
+	parsed := make([]ical.VEvent, len(components))
+	for i, component := range components {
+		results[i] = repository.ImportResult{Index: i}
+
+		vevent, err := ical.ParseEvent(component)
+		if err != nil {
+			results[i].Status, results[i].Error = ImportFailed, err.Error()
+			continue
+		}
+		parsed[i] = vevent
+		results[i].UID = vevent.UID
+
+		// Замены вхождений применяются после всех серий файла.
+		if vevent.RecurrenceID != nil {
+			instances = append(instances, i)
+			continue
+		}
+
+		results[i] = sc.importEvent(ctx, userID, vevent, idx, results[i])
+	}
+
+	for _, i := range instances {
+		results[i] = sc.importInstance(ctx, userID, parsed[i], idx, results[i])
+	}
+
+	return results, nil
+}
+
+func (sc *ServiceCalendar) importEvent(ctx context.Context, userID int, vevent ical.VEvent, idx *importIndex, result repository.ImportResult) repository.ImportResult {
+	if err := validateImported(vevent); err != nil {
+		return failed(result, err)
+	}
+
+	e := repository.Event{
+		UID:        vevent.UID,
+		UserID:     userID,
+		Title:      vevent.Summary,
+		Date:       vevent.Start,
+		End:        vevent.End,
+		AllDay:     vevent.AllDay,
+		TimeZone:   timeZoneOf(vevent),
+		Recurrence: vevent.RRule,
+		ExDates:    vevent.ExDates,
+	}
+
+	current, ok := idx.masters[vevent.UID]
+	if !ok {
+		created, err := sc.CreateEvent(ctx, e)
+		if err != nil {
+			return failed(result, err)
+		}
+		idx.masters[created.UID] = created
+
+		result.Status, result.EventID, result.UID = ImportCreated, created.ID, created.UID
+		return result
+	}
+
+	// Уже существующие замены вхождений остаются исключёнными из серии.
+	e.ID = current.ID
+	if e.IsRecurring() {
+		e.ExDates = append(e.ExDates, idx.overriddenDates(current.ID)...)
+		if e.ExDates == nil {
+			e.ExDates = []time.Time{}
+		}
+	}
+
+	updated, err := sc.UpdateEvent(ctx, e)
+	if err != nil {
+		return failed(result, err)
+	}
+	idx.masters[updated.UID] = updated
+
+	result.Status, result.EventID = ImportUpdated, updated.ID
+	return result
+}
+
+func (sc *ServiceCalendar) importInstance(ctx context.Context, userID int, vevent ical.VEvent, idx *importIndex, result repository.ImportResult) repository.ImportResult {
+	if err := validateImported(vevent); err != nil {
+		return failed(result, err)
+	}
+
+	series, ok := idx.masters[vevent.UID]
+	if !ok || !series.IsRecurring() {
+		return failed(result, fmt.Errorf("%w: series %q not found", repository.ErrEventNotFound, vevent.UID))
+	}
+
+	occurrence := *vevent.RecurrenceID
+	if current, ok := idx.overrides[overrideKey(series.ID, occurrence)]; ok {
+		current.Title = vevent.Summary
+		current.Date = vevent.Start
+		current.End = vevent.End
+		current.AllDay = vevent.AllDay
+		current.TimeZone = timeZoneOf(vevent)
+
+		updated, err := sc.UpdateEvent(ctx, current)
+		if err != nil {
+			return failed(result, err)
+		}
+		idx.overrides[overrideKey(series.ID, occurrence)] = updated
+
+		result.Status, result.EventID = ImportUpdated, updated.ID
+		return result
+	}
+
+	if !isOccurrence(series, occurrence) {
+		return failed(result, ErrOccurrenceMismatch)
+	}
+
+	override := repository.Event{
+		Title:    vevent.Summary,
+		Date:     vevent.Start,
+		End:      vevent.End,
+		AllDay:   vevent.AllDay,
+		TimeZone: timeZoneOf(vevent),
+	}
+	if err := normalizeTime(&override); err != nil {
+		return failed(result, err)
+	}
+
+	created, err := sc.repo.ReplaceOccurrence(ctx, series.ID, userID, occurrence, override, 0)
+	if err != nil {
+		return failed(result, err)
+	}
+	idx.overrides[overrideKey(series.ID, occurrence)] = created
+
+	result.Status, result.EventID = ImportCreated, created.ID
+	return result
+}
+
+func validateImported(vevent ical.VEvent) error {
+	if err := event.ValidateTitle(vevent.Summary); err != nil {
+		return err
+	}
+
+	if vevent.RRule != "" {
+		if err := event.ValidateRecurrence(vevent.RRule, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isOccurrence проверяет, порождает ли правило серии вхождение ровно в момент occurrence,
+// не учитывая исключённые даты.
+func isOccurrence(series repository.Event, occurrence time.Time) bool {
+	rule, err := recurrence.Parse(series.Recurrence)
+	if err != nil {
+		return false
+	}
+	return len(rule.Between(series.Date, occurrence, occurrence.Add(time.Nanosecond))) == 1
+}
+
+// timeZoneOf возвращает часовой пояс DTSTART; время без TZID считается временем UTC.
+func timeZoneOf(vevent ical.VEvent) string {
+	if vevent.AllDay || vevent.Start.Location() == time.Local {
+		return "UTC"
+	}
+	return vevent.Start.Location().String()
+}
+
+func failed(result repository.ImportResult, err error) repository.ImportResult {
+	result.Status = ImportFailed
+	result.Error = err.Error()
+	return result
+}
+
+type importIndex struct {
+	masters   map[string]repository.Event
+	overrides map[string]repository.Event
+}
+
+func newImportIndex(events []repository.Event) *importIndex {
+	idx := &importIndex{
+		masters:   make(map[string]repository.Event),
+		overrides: make(map[string]repository.Event),
+	}
+
+	for _, e := range events {
+		if e.RecurringEventID != 0 && e.OriginalDate != nil {
+			idx.overrides[overrideKey(e.RecurringEventID, *e.OriginalDate)] = e
+			continue
+		}
+		idx.masters[e.UID] = e
+	}
+
+	return idx
+}
+
+func (idx *importIndex) overriddenDates(seriesID int) []time.Time {
+	var dates []time.Time
+	for _, e := range idx.overrides {
+		if e.RecurringEventID == seriesID {
+			dates = append(dates, *e.OriginalDate)
+		}
+	}
+	return dates
+}
+
+func overrideKey(seriesID int, occurrence time.Time) string {
+	return fmt.Sprintf("%d/%d", seriesID, occurrence.UnixNano())
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, candidate := range times {
+		if candidate.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package calendar
+
+import (
+	"bytes"
+	"calendar/internal/event/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_ICalRoundTrip(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	start := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+	series, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: start, Title: "Standup", Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE"})
+	require.NoError(t, err)
+	_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: start.AddDate(0, 0, 2), Title: "Review, part 1"})
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteOccurrence(t.Context(), series.ID, 1, time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC), 0))
+	_, err = service.UpdateOccurrence(t.Context(), series.ID, 1, time.Date(2025, 9, 8, 0, 0, 0, 0, time.UTC),
+		repository.Event{Date: time.Date(2025, 9, 9, 15, 0, 0, 0, time.UTC), Title: "Moved Standup"}, 0)
+	require.NoError(t, err)
+
+	month := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	before, err := service.GetEventsForMonth(t.Context(), 1, 0, month)
+	require.NoError(t, err)
+
+	var exported bytes.Buffer
+	require.NoError(t, service.ExportICal(t.Context(), 1, &exported))
+	data := exported.String()
+	assert.Contains(t, data, "RRULE:FREQ=WEEKLY;BYDAY=MO,WE")
+	assert.Contains(t, data, "EXDATE:20250903T090000Z")
+	assert.NotContains(t, data, "EXDATE:20250908T090000Z")
+	assert.Contains(t, data, "RECURRENCE-ID:20250908T090000Z")
+	assert.Contains(t, data, `SUMMARY:Review\, part 1`)
+
+	t.Run("re-import updates instead of duplicating", func(t *testing.T) {
+		results, err := service.ImportICal(t.Context(), 1, strings.NewReader(data))
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for _, result := range results {
+			assert.Equal(t, ImportUpdated, result.Status, result.Error)
+		}
+
+		all, err := repo.GetUserEvents(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Len(t, all, 3)
+
+		after, err := service.GetEventsForMonth(t.Context(), 1, 0, month)
+		require.NoError(t, err)
+		assert.Equal(t, titlesAndDates(before), titlesAndDates(after))
+	})
+
+	t.Run("import into another calendar reproduces events", func(t *testing.T) {
+		results, err := service.ImportICal(t.Context(), 2, strings.NewReader(data))
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.Equal(t, ImportCreated, result.Status, result.Error)
+		}
+
+		imported, err := service.GetEventsForMonth(t.Context(), 2, 0, month)
+		require.NoError(t, err)
+		assert.Equal(t, titlesAndDates(before), titlesAndDates(imported))
+
+		events, err := repo.GetUserEvents(t.Context(), 2)
+		require.NoError(t, err)
+		for _, e := range events {
+			if e.RecurringEventID == 0 && e.IsRecurring() {
+				assert.Equal(t, series.UID, e.UID)
+			}
+		}
+	})
+}
+
+func TestCalendarService_ICalImportFailures(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:ok\r\nSUMMARY:Fine\r\nDTSTART:20250901T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:no-start\r\nSUMMARY:Broken\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:no-title\r\nDTSTART:20250901T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:bad-rule\r\nSUMMARY:Hourly\r\nDTSTART:20250901T100000Z\r\nRRULE:FREQ=HOURLY\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:orphan\r\nSUMMARY:Orphan\r\nDTSTART:20250901T100000Z\r\nRECURRENCE-ID:20250901T100000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	results, err := service.ImportICal(t.Context(), 1, strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, ImportCreated, results[0].Status)
+	assert.NotZero(t, results[0].EventID)
+	for _, result := range results[1:] {
+		assert.Equal(t, ImportFailed, result.Status)
+		assert.NotEmpty(t, result.Error)
+	}
+
+	_, err = service.ImportICal(t.Context(), 1, strings.NewReader("not a calendar"))
+	assert.Error(t, err)
+}
+
+func titlesAndDates(events []repository.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, e := range events {
+		result = append(result, e.Date.UTC().Format(time.RFC3339)+" "+e.Title)
+	}
+	return result
+}
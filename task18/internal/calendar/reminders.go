@@ -0,0 +1,137 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DueReminders возвращает напоминания всех пользователей, время срабатывания
+// которых попадает в интервал (from, to], в порядке срабатывания.
+func (sc *ServiceCalendar) DueReminders(ctx context.Context, from, to time.Time) ([]repository.DueReminder, error) {
+	ctx, span := tracing.Start(ctx, "calendar.DueReminders")
+	defer span.End()
+
+	// События на весь день начинаются в полночь своего часового пояса,
+	// которая может отстоять от хранимой полуночи UTC почти на сутки.
+	events, err := sc.repo.GetEventsWithReminders(ctx, from.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []repository.DueReminder
+	for _, e := range events {
+		if !e.IsRecurring() {
+			result = appendDue(result, e, e.ID, from, to)
+			continue
+		}
+
+		lead := time.Duration(maxReminder(e.Reminders)) * time.Minute
+		occurrences, err := expandSeries(e, from, to.Add(lead+24*time.Hour))
+		if err != nil {
+			sc.log.Warn("skipping reminders of series with broken recurrence",
+				"event_id", e.ID,
+				"recurrence", e.Recurrence,
+				"error", err,
+			)
+			continue
+		}
+		for _, occurrence := range occurrences {
+			result = appendDue(result, occurrence, e.ID, from, to)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FireAt.Before(result[j].FireAt)
+	})
+
+	return result, nil
+}
+
+func (sc *ServiceCalendar) ClaimReminder(ctx context.Context, key repository.ReminderKey) (bool, error) {
+	ctx, span := tracing.Start(ctx, "calendar.ClaimReminder")
+	defer span.End()
+
+	return sc.repo.ClaimReminder(ctx, key)
+}
+
+func (sc *ServiceCalendar) ReleaseReminder(ctx context.Context, key repository.ReminderKey) error {
+	ctx, span := tracing.Start(ctx, "calendar.ReleaseReminder")
+	defer span.End()
+
+	return sc.repo.ReleaseReminder(ctx, key)
+}
+
+func appendDue(result []repository.DueReminder, e repository.Event, eventID int, from, to time.Time) []repository.DueReminder {
+	start := reminderStart(e)
+	for _, minutes := range e.Reminders {
+		fireAt := start.Add(-time.Duration(minutes) * time.Minute)
+		if !fireAt.After(from) || fireAt.After(to) {
+			continue
+		}
+
+		result = append(result, repository.DueReminder{
+			ReminderKey: repository.ReminderKey{
+				EventID:       eventID,
+				Occurrence:    e.Date,
+				MinutesBefore: minutes,
+			},
+			Event:  e,
+			FireAt: fireAt,
+		})
+	}
+	return result
+}
+
+// reminderStart возвращает момент начала события; событие на весь день
+// начинается в полночь часового пояса события.
+func reminderStart(e repository.Event) time.Time {
+	if !e.AllDay {
+		return e.Date
+	}
+
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return e.Date
+	}
+
+	year, month, day := e.Date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// normalizeReminders проверяет напоминания, убирает повторы и сортирует их.
+func normalizeReminders(e *repository.Event) error {
+	if len(e.Reminders) == 0 {
+		e.Reminders = nil
+		return nil
+	}
+
+	seen := make(map[int]bool, len(e.Reminders))
+	reminders := make([]int, 0, len(e.Reminders))
+	for _, minutes := range e.Reminders {
+		if minutes < 0 || minutes > event.MaxReminderMinutes {
+			return fmt.Errorf("%w: reminder must be between 0 and %d minutes before start",
+				repository.ErrInvalidDataInput, event.MaxReminderMinutes)
+		}
+		if !seen[minutes] {
+			seen[minutes] = true
+			reminders = append(reminders, minutes)
+		}
+	}
+	sort.Ints(reminders)
+	e.Reminders = reminders
+
+	return nil
+}
+
+func maxReminder(reminders []int) int {
+	result := 0
+	for _, minutes := range reminders {
+		result = max(result, minutes)
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_DueReminders(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	call, err := service.CreateEvent(t.Context(), repository.Event{
+		UserID:    1,
+		Date:      time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC),
+		Title:     "Call",
+		Reminders: []int{60, 15, 15},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{15, 60}, call.Reminders)
+
+	standup, err := service.CreateEvent(t.Context(), repository.Event{
+		UserID:     2,
+		Date:       time.Date(2025, 8, 25, 9, 50, 0, 0, time.UTC),
+		Title:      "Standup",
+		Recurrence: "FREQ=WEEKLY",
+		Reminders:  []int{5},
+	})
+	require.NoError(t, err)
+
+	_, err = service.CreateEvent(t.Context(), repository.Event{
+		UserID:    3,
+		Date:      time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC),
+		AllDay:    true,
+		TimeZone:  "Europe/Moscow",
+		Title:     "Holiday",
+		Reminders: []int{14 * 60},
+	})
+	require.NoError(t, err)
+
+	t.Run("reminders in window", func(t *testing.T) {
+		due, err := service.DueReminders(t.Context(), time.Date(2025, 9, 1, 8, 59, 0, 0, time.UTC), time.Date(2025, 9, 1, 9, 50, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, due, 3)
+
+		assert.Equal(t, call.ID, due[0].EventID)
+		assert.Equal(t, 60, due[0].MinutesBefore)
+		assert.Equal(t, time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC), due[0].FireAt)
+
+		for _, reminder := range due[1:] {
+			assert.Equal(t, time.Date(2025, 9, 1, 9, 45, 0, 0, time.UTC), reminder.FireAt)
+		}
+		assert.ElementsMatch(t, []int{call.ID, standup.ID}, []int{due[1].EventID, due[2].EventID})
+	})
+
+	t.Run("window start is exclusive", func(t *testing.T) {
+		due, err := service.DueReminders(t.Context(), time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC), time.Date(2025, 9, 1, 9, 44, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("all-day event starts at midnight of its time zone", func(t *testing.T) {
+		fireAt := time.Date(2025, 9, 1, 10, 0, 0, 0, moscow)
+		due, err := service.DueReminders(t.Context(), fireAt.Add(-time.Minute), fireAt)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, "Holiday", due[0].Event.Title)
+	})
+
+	t.Run("occurrence override keeps series reminders", func(t *testing.T) {
+		moved := time.Date(2025, 9, 8, 11, 0, 0, 0, time.UTC)
+		override, err := service.UpdateOccurrence(t.Context(), standup.ID, 2, moved, repository.Event{Date: moved, Title: "Late standup"}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []int{5}, override.Reminders)
+
+		due, err := service.DueReminders(t.Context(), time.Date(2025, 9, 8, 9, 0, 0, 0, time.UTC), time.Date(2025, 9, 8, 11, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, override.ID, due[0].EventID)
+	})
+
+	t.Run("update without reminders keeps them", func(t *testing.T) {
+		updated, err := service.UpdateEvent(t.Context(), repository.Event{ID: call.ID, UserID: 1, Date: call.Date, Title: "Renamed call"})
+		require.NoError(t, err)
+		assert.Equal(t, []int{15, 60}, updated.Reminders)
+
+		updated, err = service.UpdateEvent(t.Context(), repository.Event{ID: call.ID, UserID: 1, Date: call.Date, Title: "Renamed call", Reminders: []int{}})
+		require.NoError(t, err)
+		assert.Empty(t, updated.Reminders)
+
+		_, err = service.UpdateEvent(t.Context(), repository.Event{ID: call.ID, UserID: 1, Date: call.Date, Title: "Renamed call", Reminders: []int{-5}})
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+	})
+}
@@ -0,0 +1,188 @@
+package calendar
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SortByDate      = "date"
+	SortByCreatedAt = "created_at"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EventsQuery — выборка событий пользователя, пересекающихся с [From, To).
+// Title отбирает события, в названии которых есть эта подстрока без учёта регистра.
+// Cursor — NextCursor предыдущей страницы той же выборки.
+type EventsQuery struct {
+	UserID     int
+	From       time.Time
+	To         time.Time
+	Title      string
+	SortBy     string
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// EventsPage — страница выборки. Total — число всех подходящих событий,
+// NextCursor пуст на последней странице.
+type EventsPage struct {
+	Events     []repository.Event
+	Total      int
+	NextCursor string
+}
+
+// pageKey — позиция события в выборке: значение поля сортировки, затем ID и
+// начало, которые различают вхождения одной серии.
+type pageKey struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	At         int64  `json:"a"`
+	ID         int    `json:"i"`
+	Date       int64  `json:"t"`
+}
+
+// SearchEvents возвращает страницу событий и вхождений серий за интервал.
+func (sc *ServiceCalendar) SearchEvents(ctx context.Context, query EventsQuery) (EventsPage, error) {
+	ctx, span := tracing.Start(ctx, "calendar.SearchEvents")
+	defer span.End()
+	span.SetAttr("user_id", query.UserID)
+
+	if !query.To.After(query.From) || query.To.After(query.From.AddDate(0, 0, event.MaxRangeDays)) {
+		return EventsPage{}, fmt.Errorf("%w: invalid range", repository.ErrInvalidDataInput)
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = SortByDate
+	}
+	if query.SortBy != SortByDate && query.SortBy != SortByCreatedAt {
+		return EventsPage{}, fmt.Errorf("%w: unknown sort field %q", repository.ErrInvalidDataInput, query.SortBy)
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = event.DefaultPageLimit
+	}
+	query.Limit = min(query.Limit, event.MaxPageLimit)
+
+	var after *pageKey
+	if query.Cursor != "" {
+		key, err := decodeCursor(query.Cursor)
+		if err != nil || key.SortBy != query.SortBy || key.Descending != query.Descending {
+			return EventsPage{}, ErrInvalidCursor
+		}
+		after = &key
+	}
+
+	events, err := sc.repo.GetEventsBetween(ctx, query.UserID, query.From, query.To)
+	if err != nil {
+		return EventsPage{}, err
+	}
+
+	events, err = sc.withOccurrences(ctx, query.UserID, events, query.From, query.To)
+	if err != nil {
+		return EventsPage{}, err
+	}
+
+	if title := strings.ToLower(strings.TrimSpace(query.Title)); title != "" {
+		filtered := events[:0]
+		for _, e := range events {
+			if strings.Contains(strings.ToLower(e.Title), title) {
+				filtered = append(filtered, e)
+			}
+		}
+		events = filtered
+	}
+
+	keys := make([]pageKey, len(events))
+	for i, e := range events {
+		keys[i] = newPageKey(e, query.SortBy, query.Descending)
+	}
+	sort.Sort(byPageKey{events: events, keys: keys})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(keys), func(i int) bool {
+			return comparePageKeys(keys[i], *after) > 0
+		})
+	}
+	end := min(start+query.Limit, len(events))
+
+	page := EventsPage{
+		Events: events[start:end],
+		Total:  len(events),
+	}
+	if end < len(events) {
+		page.NextCursor = encodeCursor(keys[end-1])
+	}
+
+	return page, nil
+}
+
+func newPageKey(e repository.Event, sortBy string, descending bool) pageKey {
+	at := e.Date
+	if sortBy == SortByCreatedAt {
+		at = e.CreatedAt
+	}
+
+	return pageKey{
+		SortBy:     sortBy,
+		Descending: descending,
+		At:         at.UnixNano(),
+		ID:         e.ID,
+		Date:       e.Date.UnixNano(),
+	}
+}
+
+func comparePageKeys(a, b pageKey) int {
+	result := cmp.Or(
+		cmp.Compare(a.At, b.At),
+		cmp.Compare(a.ID, b.ID),
+		cmp.Compare(a.Date, b.Date),
+	)
+	if a.Descending {
+		return -result
+	}
+	return result
+}
+
+type byPageKey struct {
+	events []repository.Event
+	keys   []pageKey
+}
+
+func (b byPageKey) Len() int           { return len(b.events) }
+func (b byPageKey) Less(i, j int) bool { return comparePageKeys(b.keys[i], b.keys[j]) < 0 }
+func (b byPageKey) Swap(i, j int) {
+	b.events[i], b.events[j] = b.events[j], b.events[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+func encodeCursor(key pageKey) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (pageKey, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageKey{}, err
+	}
+
+	var key pageKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return pageKey{}, err
+	}
+	return key, nil
+}
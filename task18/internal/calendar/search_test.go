@@ -0,0 +1,103 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_SearchEvents(t *testing.T) {
+	repo := repository.NewEventRepository(testLogger())
+	service := NewServiceCalendar(repo, testLogger())
+
+	day := func(d, hour int) time.Time { return time.Date(2025, 9, d, hour, 0, 0, 0, time.UTC) }
+
+	_, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: day(2, 9), Title: "Daily standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: day(3, 12), Title: "Lunch"})
+	require.NoError(t, err)
+	_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: day(20, 12), Title: "Outside"})
+	require.NoError(t, err)
+	_, err = service.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: day(3, 12), Title: "Foreign standup"})
+	require.NoError(t, err)
+
+	query := EventsQuery{UserID: 1, From: day(1, 0), To: day(8, 0), Limit: 2}
+
+	t.Run("pages cover the whole range without repeats", func(t *testing.T) {
+		var titles []string
+		var dates []time.Time
+		q := query
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 10)
+
+			page, err := service.SearchEvents(t.Context(), q)
+			require.NoError(t, err)
+			assert.Equal(t, 6, page.Total)
+			assert.LessOrEqual(t, len(page.Events), 2)
+
+			for _, e := range page.Events {
+				titles = append(titles, e.Title)
+				dates = append(dates, e.Date)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+
+		assert.Equal(t, []string{"Daily standup", "Daily standup", "Lunch", "Daily standup", "Daily standup", "Daily standup"}, titles)
+		for i := 1; i < len(dates); i++ {
+			assert.False(t, dates[i].Before(dates[i-1]))
+		}
+	})
+
+	t.Run("title filter", func(t *testing.T) {
+		q := query
+		q.Title = "STAND"
+		q.Limit = 10
+
+		page, err := service.SearchEvents(t.Context(), q)
+		require.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("descending by creation time", func(t *testing.T) {
+		q := query
+		q.SortBy = SortByCreatedAt
+		q.Descending = true
+
+		page, err := service.SearchEvents(t.Context(), q)
+		require.NoError(t, err)
+		require.Len(t, page.Events, 2)
+		assert.Equal(t, "Lunch", page.Events[0].Title)
+		assert.Equal(t, day(6, 9), page.Events[1].Date)
+	})
+
+	t.Run("cursor of another ordering is rejected", func(t *testing.T) {
+		page, err := service.SearchEvents(t.Context(), query)
+		require.NoError(t, err)
+
+		q := query
+		q.Cursor = page.NextCursor
+		q.Descending = true
+		_, err = service.SearchEvents(t.Context(), q)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		q = query
+		q.Cursor = "garbage"
+		_, err = service.SearchEvents(t.Context(), q)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := service.SearchEvents(t.Context(), EventsQuery{UserID: 1, From: day(8, 0), To: day(1, 0)})
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+
+		_, err = service.SearchEvents(t.Context(), EventsQuery{UserID: 1, From: day(1, 0), To: day(1, 0).AddDate(2, 0, 0)})
+		assert.ErrorIs(t, err, repository.ErrInvalidDataInput)
+	})
+}
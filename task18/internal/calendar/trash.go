@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"time"
+)
+
+// Trash возвращает корзину пользователя: его удалённые события, ещё не
+// удалённые окончательно, от недавно удалённых к давним.
+func (sc *ServiceCalendar) Trash(ctx context.Context, userID int) ([]repository.TrashedEvent, error) {
+	ctx, span := tracing.Start(ctx, "calendar.Trash")
+	defer span.End()
+	span.SetAttr("user_id", userID)
+
+	return sc.repo.ListTrash(ctx, userID)
+}
+
+// RestoreTrashedEvent возвращает событие из корзины пользователя под прежним
+// ID со следующей версией. Серия возвращается вместе с заменами вхождений из
+// корзины; замену, которую вернуть не удалось, можно восстановить отдельно.
+// Событие удалённого календаря восстанавливается вне календарей.
+func (sc *ServiceCalendar) RestoreTrashedEvent(ctx context.Context, eventID, userID int) (repository.Event, error) {
+	ctx, span := tracing.Start(ctx, "calendar.RestoreTrashedEvent")
+	defer span.End()
+	span.SetAttr("event_id", eventID)
+	span.SetAttr("user_id", userID)
+
+	trashed, err := sc.repo.GetTrashedEvent(ctx, eventID, userID)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	restored, err := sc.restoreTrashed(ctx, trashed.Event)
+	if err != nil || !restored.IsRecurring() {
+		return restored, err
+	}
+
+	trash, err := sc.repo.ListTrash(ctx, userID)
+	if err != nil {
+		return repository.Event{}, err
+	}
+	for _, override := range trash {
+		if override.RecurringEventID != eventID {
+			continue
+		}
+		if _, err := sc.restoreTrashed(ctx, override.Event); err != nil {
+			sc.log.Warn("failed to restore occurrence override from trash",
+				"event_id", override.ID,
+				"series_id", eventID,
+				"error", err,
+			)
+		}
+	}
+
+	return restored, nil
+}
+
+// PurgeTrash окончательно удаляет события, удалённые раньше before.
+func (sc *ServiceCalendar) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "calendar.PurgeTrash")
+	defer span.End()
+
+	return sc.repo.PurgeTrash(ctx, before)
+}
+
+func (sc *ServiceCalendar) restoreTrashed(ctx context.Context, event repository.Event) (repository.Event, error) {
+	event, err := sc.withoutDeletedCalendar(ctx, event)
+	if err != nil {
+		return repository.Event{}, err
+	}
+	return sc.repo.RestoreEvent(ctx, event)
+}
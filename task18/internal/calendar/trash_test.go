@@ -0,0 +1,65 @@
+package calendar
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarService_Trash(t *testing.T) {
+	service := NewServiceCalendar(repository.NewEventRepository(testLogger()), testLogger())
+	monday := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	standup, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: monday, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	moved, err := service.UpdateOccurrence(t.Context(), standup.ID, 1, monday.AddDate(0, 0, 1), repository.Event{Date: monday.AddDate(0, 0, 1).Add(time.Hour), Title: "Standup"}, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, service.DeleteEvent(t.Context(), standup.ID, 1, 0))
+
+	t.Run("trashed events are hidden from period queries", func(t *testing.T) {
+		events, err := service.GetEventsForWeek(t.Context(), 1, 0, monday)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		trash, err := service.Trash(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Len(t, trash, 2)
+	})
+
+	t.Run("only owner restores from trash", func(t *testing.T) {
+		_, err := service.RestoreTrashedEvent(t.Context(), standup.ID, 2)
+		assert.ErrorIs(t, err, repository.ErrEventNotFound)
+	})
+
+	t.Run("series is restored with its overrides", func(t *testing.T) {
+		restored, err := service.RestoreTrashedEvent(t.Context(), standup.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, standup.ID, restored.ID)
+		assert.Greater(t, restored.Version, standup.Version)
+
+		events, err := service.GetEventsForWeek(t.Context(), 1, 0, monday)
+		require.NoError(t, err)
+		require.Len(t, events, 5)
+		assert.Equal(t, moved.ID, events[1].ID)
+
+		trash, err := service.Trash(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+	})
+
+	t.Run("event of deleted calendar is restored outside calendars", func(t *testing.T) {
+		team, err := service.CreateCalendar(t.Context(), repository.Calendar{UserID: 1, Name: "team"})
+		require.NoError(t, err)
+		sync, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, CalendarID: team.ID, Date: monday, Title: "Sync"})
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteCalendar(t.Context(), team.ID, 1))
+
+		restored, err := service.RestoreTrashedEvent(t.Context(), sync.ID, 1)
+		require.NoError(t, err)
+		assert.Zero(t, restored.CalendarID)
+	})
+}
@@ -0,0 +1,119 @@
+package changes
+
+import (
+	"calendar/internal/event/repository"
+	"sync"
+)
+
+// subscriberBuffer — сколько изменений может ждать отправки подписчику.
+// Подписчик, который не успевает их забирать, отключается и при
+// переподключении получает пропущенное из буфера повтора.
+const subscriberBuffer = 64
+
+// Bus раздаёт изменения событий подписчикам, которым события видны, и хранит последние
+// изменения, чтобы переподключившийся клиент получил пропущенные.
+type Bus struct {
+	mu     sync.Mutex
+	lastID uint64
+	replay []repository.Change
+	size   int
+	subs   map[*Subscription]struct{}
+}
+
+// Subscription — подписка на изменения событий одного пользователя.
+// Канал Changes закрывается, когда подписка отменена или отстала.
+type Subscription struct {
+	bus    *Bus
+	userID int
+	ch     chan repository.Change
+	closed bool
+}
+
+// NewBus создаёт шину, хранящую для повтора последние replaySize изменений.
+func NewBus(replaySize int) *Bus {
+	return &Bus{
+		size: replaySize,
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish присваивает изменению следующий ID, запоминает его для повтора и
+// отправляет подписчикам. Publish не ждёт медленных подписчиков.
+func (b *Bus) Publish(change repository.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	change.ID = b.lastID
+
+	if b.size > 0 {
+		if len(b.replay) == b.size {
+			b.replay = b.replay[1:]
+		}
+		b.replay = append(b.replay, change)
+	}
+
+	for sub := range b.subs {
+		if !change.VisibleTo(sub.userID) {
+			continue
+		}
+		select {
+		case sub.ch <- change:
+		default:
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe подписывает на изменения событий userID. Если lastID не 0,
+// возвращает изменения пользователя после lastID из буфера повтора; complete
+// равен false, если часть изменений после lastID уже вытеснена из буфера или
+// lastID выдан не этой шиной.
+func (b *Bus) Subscribe(userID int, lastID uint64) (sub *Subscription, missed []repository.Change, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{
+		bus:    b,
+		userID: userID,
+		ch:     make(chan repository.Change, subscriberBuffer),
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastID == 0 {
+		return sub, nil, true
+	}
+	if lastID > b.lastID {
+		return sub, nil, false
+	}
+
+	oldest := b.lastID + 1 - uint64(len(b.replay))
+	for _, change := range b.replay {
+		if change.ID > lastID && change.VisibleTo(userID) {
+			missed = append(missed, change)
+		}
+	}
+	return sub, missed, lastID+1 >= oldest
+}
+
+// Changes возвращает канал новых изменений.
+func (s *Subscription) Changes() <-chan repository.Change {
+	return s.ch
+}
+
+// Close отменяет подписку.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.unsubscribe(s)
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subs, sub)
+	close(sub.ch)
+}
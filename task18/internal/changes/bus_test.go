@@ -0,0 +1,84 @@
+package changes
+
+import (
+	"calendar/internal/event/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changeIDs(changes []repository.Change) []uint64 {
+	ids := make([]uint64, len(changes))
+	for i, change := range changes {
+		ids[i] = change.ID
+	}
+	return ids
+}
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus(10)
+
+	sub, missed, complete := bus.Subscribe(1, 0)
+	defer sub.Close()
+	assert.Empty(t, missed)
+	assert.True(t, complete)
+
+	bus.Publish(repository.Change{Type: repository.ChangeCreated, UserID: 2, EventID: 1})
+	bus.Publish(repository.Change{Type: repository.ChangeCreated, UserID: 1, EventID: 2})
+	bus.Publish(repository.Change{Type: repository.ChangeCreated, UserID: 3, EventID: 3, Viewers: []int{1}})
+
+	change := <-sub.Changes()
+	assert.Equal(t, uint64(2), change.ID)
+	assert.Equal(t, 2, change.EventID)
+
+	change = <-sub.Changes()
+	assert.Equal(t, 3, change.EventID, "events shared with the subscriber are delivered too")
+	assert.Empty(t, sub.Changes())
+}
+
+func TestBus_Replay(t *testing.T) {
+	bus := NewBus(3)
+	for i := range 5 {
+		bus.Publish(repository.Change{Type: repository.ChangeCreated, UserID: 1 + i%2, EventID: i + 1})
+	}
+	// В буфере остались изменения 3, 4 и 5; изменения 3 и 5 принадлежат пользователю 1.
+
+	t.Run("changes after last id", func(t *testing.T) {
+		sub, missed, complete := bus.Subscribe(1, 3)
+		defer sub.Close()
+		assert.True(t, complete)
+		assert.Equal(t, []uint64{5}, changeIDs(missed))
+	})
+
+	t.Run("evicted changes", func(t *testing.T) {
+		sub, missed, complete := bus.Subscribe(1, 1)
+		defer sub.Close()
+		assert.False(t, complete)
+		assert.Equal(t, []uint64{3, 5}, changeIDs(missed))
+	})
+
+	t.Run("unknown last id", func(t *testing.T) {
+		sub, missed, complete := bus.Subscribe(1, 42)
+		defer sub.Close()
+		assert.False(t, complete)
+		assert.Empty(t, missed)
+	})
+}
+
+func TestBus_SlowSubscriber(t *testing.T) {
+	bus := NewBus(0)
+	sub, _, _ := bus.Subscribe(1, 0)
+
+	for range subscriberBuffer + 1 {
+		bus.Publish(repository.Change{Type: repository.ChangeUpdated, UserID: 1, EventID: 1})
+	}
+
+	received := 0
+	for range sub.Changes() {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	require.NotPanics(t, sub.Close)
+}
@@ -1,48 +1,516 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	StorageMemory = "memory"
+	StorageSQLite = "sqlite"
+
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+
+	RateLimitByUser = "user"
+	RateLimitByIP   = "ip"
+
+	LevelLocal = "local"
+	LevelDev   = "dev"
+	LevelProd  = "prod"
+)
+
+// minJWTSecretLength — 256 бит, размер подписи HS256: более короткий ключ
+// проще подобрать по перехваченному токену.
+const minJWTSecretLength = 32
+
+// placeholderSecrets — заглушки из примеров конфигурации. Ключ с ними известен
+// всем, кто видел пример, даже если он достаточно длинный.
+var placeholderSecrets = []string{"change-me", "changeme", "change_me", "replace-me", "your-secret"}
+
+// ErrHelp возвращает Load, когда запрошена справка по флагам.
+var ErrHelp = flag.ErrHelp
+
+// Config — настройки сервиса. Каждое поле с тегом cfg задаётся, по возрастанию
+// приоритета, значением по умолчанию, ключом в файле конфигурации, переменной
+// окружения с именем ключа в верхнем регистре и флагом с тем же именем через
+// дефисы: read_timeout, READ_TIMEOUT, --read-timeout. Поля с тегом reload
+// перечитываются по SIGHUP, secret скрывается при печати, zero может быть нулём.
 type Config struct {
-	Level        string
-	Port         string
-	LogFilePath  string
-	LogToFile    string
-	ReadTimeOut  time.Duration
-	WriteTimeOut time.Duration
-	IdleTimeOut  time.Duration
+	Level        string        `cfg:"level" reload:"true"`
+	Port         string        `cfg:"port"`
+	LogFilePath  string        `cfg:"log_file_path"`
+	LogToFile    bool          `cfg:"log_to_file"`
+	ReadTimeOut  time.Duration `cfg:"read_timeout"`
+	WriteTimeOut time.Duration `cfg:"write_timeout"`
+	IdleTimeOut  time.Duration `cfg:"idle_timeout"`
+	StorageType  string        `cfg:"storage_type"`
+	SQLitePath   string        `cfg:"sqlite_path"`
+	JWTSecret    string        `cfg:"jwt_secret" secret:"true"`
+	LegacyRoutes bool          `cfg:"legacy_routes"`
+
+	TraceExporter string `cfg:"trace_exporter"`
+
+	// Остановка: сколько /readyz отвечает not_ready до закрытия порта, чтобы
+	// балансировщик успел убрать сервис, и сколько затем ждать завершения
+	// запросов и фоновой работы.
+	ShutdownDrainDelay time.Duration `cfg:"shutdown_drain_delay" zero:"true"`
+	ShutdownTimeout    time.Duration `cfg:"shutdown_timeout"`
+
+	// Лимиты запросов в секунду на клиента; нулевой RPS снимает ограничение.
+	RateLimitReadRPS    float64       `cfg:"rate_limit_read_rps" reload:"true"`
+	RateLimitReadBurst  int           `cfg:"rate_limit_read_burst" reload:"true"`
+	RateLimitWriteRPS   float64       `cfg:"rate_limit_write_rps" reload:"true"`
+	RateLimitWriteBurst int           `cfg:"rate_limit_write_burst" reload:"true"`
+	RateLimitBy         string        `cfg:"rate_limit_by"`
+	RateLimitIdleTTL    time.Duration `cfg:"rate_limit_idle_ttl"`
+	// Лимит на IP-адрес проверяется до аутентификации и не даёт перебирать
+	// токены или нагружать проверку подписи.
+	RateLimitIPRPS   float64 `cfg:"rate_limit_ip_rps" reload:"true"`
+	RateLimitIPBurst int     `cfg:"rate_limit_ip_burst" reload:"true"`
+
+	IdempotencyTTL time.Duration `cfg:"idempotency_ttl"`
+
+	// Поток изменений: период комментариев-heartbeat и сколько последних
+	// изменений хранится для повтора по Last-Event-ID.
+	StreamHeartbeat  time.Duration `cfg:"stream_heartbeat"`
+	StreamReplaySize int           `cfg:"stream_replay_size"`
+
+	// Вебхуки: число параллельных отправок, попыток доставки, задержка перед
+	// первым повтором (дальше удваивается) и таймаут одного запроса.
+	WebhookWorkers     int           `cfg:"webhook_workers"`
+	WebhookMaxAttempts int           `cfg:"webhook_max_attempts"`
+	WebhookRetryBase   time.Duration `cfg:"webhook_retry_base"`
+	WebhookTimeout     time.Duration `cfg:"webhook_timeout"`
+
+	// WebhookAllowedNetworks — сети через запятую (CIDR), в которые вебхукам
+	// можно обращаться, хотя они не публичные: 10.0.0.0/8,127.0.0.1/32.
+	WebhookAllowedNetworks string `cfg:"webhook_allowed_networks"`
+
+	ReminderInterval   time.Duration `cfg:"reminder_interval"`
+	ReminderLookback   time.Duration `cfg:"reminder_lookback"`
+	ReminderNotifier   string        `cfg:"reminder_notifier"`
+	ReminderWebhookURL string        `cfg:"reminder_webhook_url"`
+
+	// Корзина: сколько удалённые события хранятся до окончательного удаления
+	// и как часто они удаляются.
+	TrashRetention     time.Duration `cfg:"trash_retention"`
+	TrashPurgeInterval time.Duration `cfg:"trash_purge_interval"`
+
+	// File — файл конфигурации (--config или CONFIG_FILE), PrintConfig — флаг
+	// --print-config. Они задаются только при запуске.
+	File        string
+	PrintConfig bool
+}
+
+func Default() *Config {
+	return &Config{
+		Level:        LevelProd,
+		Port:         "8080",
+		LogFilePath:  "http_requests.log",
+		ReadTimeOut:  10 * time.Second,
+		WriteTimeOut: 10 * time.Second,
+		IdleTimeOut:  60 * time.Second,
+		StorageType:  StorageMemory,
+		SQLitePath:   "calendar.db",
+		LegacyRoutes: true,
+
+		TraceExporter: TraceExporterNone,
+
+		ShutdownTimeout: 30 * time.Second,
+
+		RateLimitReadRPS:    20,
+		RateLimitReadBurst:  40,
+		RateLimitWriteRPS:   5,
+		RateLimitWriteBurst: 10,
+		RateLimitBy:         RateLimitByUser,
+		RateLimitIPRPS:      50,
+		RateLimitIPBurst:    100,
+		RateLimitIdleTTL:    10 * time.Minute,
+
+		IdempotencyTTL: 24 * time.Hour,
+
+		StreamHeartbeat:  15 * time.Second,
+		StreamReplaySize: 1000,
+
+		WebhookWorkers:     4,
+		WebhookMaxAttempts: 8,
+		WebhookRetryBase:   5 * time.Second,
+		WebhookTimeout:     10 * time.Second,
+
+		ReminderInterval: 30 * time.Second,
+		ReminderLookback: time.Hour,
+		ReminderNotifier: NotifierLog,
+
+		TrashRetention:     30 * 24 * time.Hour,
+		TrashPurgeInterval: time.Hour,
+	}
 }
 
-func LoadCfg() *Config {
-	if err := godotenv.Load(); err != nil {
-		fmt.Println("Error loading .env file", err)
-		panic(err)
+// Load собирает настройки из значений по умолчанию, файла конфигурации,
+// окружения (включая необязательный .env) и флагов args и проверяет их.
+func Load(args []string) (*Config, error) {
+	// .env читается при каждой загрузке, а не попадает в окружение процесса,
+	// чтобы его правки применялись по SIGHUP. Окружение важнее .env.
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+	getenv := func(name string) string {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return dotenv[name]
+	}
+
+	cfg := Default()
+
+	flags := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	flags.StringVar(&cfg.File, "config", getenv("CONFIG_FILE"), "файл конфигурации YAML или JSON")
+	flags.BoolVar(&cfg.PrintConfig, "print-config", false, "напечатать итоговые настройки и выйти")
+	for _, f := range fields(cfg) {
+		flags.String(f.flag(), "", fmt.Sprintf("%s (переменная %s)", f.key, strings.ToUpper(f.key)))
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if cfg.File != "" {
+		values, err := readFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, cfg.apply("config file", func(key string) (string, bool) {
+			value, ok := values[key]
+			return value, ok
+		})...)
+		for key := range values {
+			if !slices.ContainsFunc(fields(cfg), func(f field) bool { return f.key == key }) {
+				errs = append(errs, fmt.Errorf("config file: unknown key %q", key))
+			}
+		}
+	}
+
+	errs = append(errs, cfg.apply("environment", func(key string) (string, bool) {
+		value := getenv(strings.ToUpper(key))
+		return value, value != ""
+	})...)
+
+	set := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+	errs = append(errs, cfg.apply("flag", func(key string) (string, bool) {
+		value, ok := set[strings.ReplaceAll(key, "_", "-")]
+		return value, ok
+	})...)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
 
-	cfg := &Config{
-		Level:        os.Getenv("LEVEL"),
-		Port:         os.Getenv("PORT"),
-		LogFilePath:  os.Getenv("LOG_FILE_PATH"),
-		LogToFile:    os.Getenv("LOG_TO_FILE"),
-		ReadTimeOut:  parseDuration(os.Getenv("READ_TIMEOUT")),
-		WriteTimeOut: parseDuration(os.Getenv("WRITE_TIMEOUT")),
-		IdleTimeOut:  parseDuration(os.Getenv("IDLE_TIMEOUT")),
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	return cfg
+	return cfg, nil
 }
 
-func parseDuration(durationStr string) time.Duration {
+// Validate проверяет согласованность настроек и возвращает все найденные ошибки.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+	}
+
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			invalid(key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+		}
+	}
+	oneOf("level", c.Level, LevelLocal, LevelDev, LevelProd)
+	oneOf("storage_type", c.StorageType, StorageMemory, StorageSQLite)
+	oneOf("trace_exporter", c.TraceExporter, TraceExporterNone, TraceExporterStdout)
+	oneOf("rate_limit_by", c.RateLimitBy, RateLimitByUser, RateLimitByIP)
+	oneOf("reminder_notifier", c.ReminderNotifier, NotifierLog, NotifierWebhook)
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		invalid("port", "must be between 1 and 65535, got %q", c.Port)
+	}
+	switch {
+	case c.JWTSecret == "":
+		invalid("jwt_secret", "is required")
+	case len(c.JWTSecret) < minJWTSecretLength:
+		invalid("jwt_secret", "must be at least %d bytes long", minJWTSecretLength)
+	case isPlaceholderSecret(c.JWTSecret):
+		invalid("jwt_secret", "must not be a placeholder value")
+	}
+	if c.StorageType == StorageSQLite && c.SQLitePath == "" {
+		invalid("sqlite_path", "is required for sqlite storage")
+	}
+	if c.LogToFile && c.LogFilePath == "" {
+		invalid("log_file_path", "is required when log_to_file is set")
+	}
+	if c.ReminderNotifier == NotifierWebhook {
+		if u, err := url.Parse(c.ReminderWebhookURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			invalid("reminder_webhook_url", "must be an absolute http or https URL for webhook notifier")
+		}
+	}
+
+	if _, err := c.WebhookNetworks(); err != nil {
+		invalid("webhook_allowed_networks", "%v", err)
+	}
+	// Корзины проверяются каждые полпериода; меньший период занимал бы процессор впустую.
+	if c.RateLimitIdleTTL > 0 && c.RateLimitIdleTTL < time.Second {
+		invalid("rate_limit_idle_ttl", "must be at least 1s, got %s", c.RateLimitIdleTTL)
+	}
+
+	for _, f := range fields(c) {
+		switch v := f.value.Interface().(type) {
+		case time.Duration:
+			if f.zero && v < 0 {
+				invalid(f.key, "must not be negative, got %s", v)
+			} else if !f.zero && v <= 0 {
+				invalid(f.key, "must be positive, got %s", v)
+			}
+		case int:
+			if v < 1 {
+				invalid(f.key, "must be positive, got %d", v)
+			}
+		case float64:
+			if v < 0 {
+				invalid(f.key, "must not be negative, got %v", v)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isPlaceholderSecret(secret string) bool {
+	secret = strings.ToLower(secret)
+	return slices.ContainsFunc(placeholderSecrets, func(placeholder string) bool {
+		return strings.Contains(secret, placeholder)
+	})
+}
+
+// WebhookNetworks разбирает WebhookAllowedNetworks.
+func (c *Config) WebhookNetworks() ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, value := range strings.Split(c.WebhookAllowedNetworks, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be a comma-separated list of CIDR networks, got %q", value)
+		}
+		networks = append(networks, prefix)
+	}
+
+	return networks, nil
+}
+
+// Reload возвращает копию c, в которую из next перенесены поля с тегом reload,
+// и ключи перенесённых изменений. ignored — ключи изменённых полей, которые
+// вступят в силу только после перезапуска.
+func (c *Config) Reload(next *Config) (reloaded *Config, changed, ignored []string) {
+	copied := *c
+	reloaded = &copied
+
+	nextFields := fields(next)
+	for i, f := range fields(reloaded) {
+		value := nextFields[i].value
+		if f.value.Equal(value) {
+			continue
+		}
+		if !f.reload {
+			ignored = append(ignored, f.key)
+			continue
+		}
+		f.value.Set(value)
+		changed = append(changed, f.key)
+	}
+
+	return reloaded, changed, ignored
+}
+
+// Print пишет настройки в формате файла конфигурации; секреты скрыты.
+func (c *Config) Print(w io.Writer) error {
+	for _, f := range fields(c) {
+		value := f.String()
+		if f.secret && value != "" {
+			value = "********"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.key, strconv.Quote(value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type field struct {
+	key    string
+	reload bool
+	secret bool
+	zero   bool
+	value  reflect.Value
+}
+
+func fields(c *Config) []field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	var result []field
+	for i := range t.NumField() {
+		key := t.Field(i).Tag.Get("cfg")
+		if key == "" {
+			continue
+		}
+		result = append(result, field{
+			key:    key,
+			reload: t.Field(i).Tag.Get("reload") == "true",
+			secret: t.Field(i).Tag.Get("secret") == "true",
+			zero:   t.Field(i).Tag.Get("zero") == "true",
+			value:  v.Field(i),
+		})
+	}
+
+	return result
+}
+
+func (f field) flag() string {
+	return strings.ReplaceAll(f.key, "_", "-")
+}
+
+func (f field) String() string {
+	switch v := f.value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// set разбирает значение поля из строки. Длительность без единицы измерения
+// считается числом секунд, как в прежних .env: READ_TIMEOUT=10.
+func (f field) set(value string) error {
+	value = strings.TrimSpace(value)
+
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", value)
+		}
+		f.value.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", value)
+		}
+		f.value.SetInt(int64(n))
+	case float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number, got %q", value)
+		}
+		f.value.SetFloat(n)
+	case time.Duration:
+		d, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+	}
+
+	return nil
+}
+
+// apply задаёт поля значениями из источника source; lookup возвращает значение
+// по ключу поля и признак того, что оно задано.
+func (c *Config) apply(source string, lookup func(key string) (string, bool)) []error {
+	var errs []error
+	for _, f := range fields(c) {
+		value, ok := lookup(f.key)
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", source, f.key, err))
+		}
+	}
+
+	return errs
+}
+
+// readFile читает плоский файл конфигурации: JSON для расширения .json,
+// иначе YAML. Значения приводятся к строкам и разбираются как переменные окружения.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var raw map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case map[string]any, []any:
+			return nil, fmt.Errorf("config file %s: %s: must be a scalar value", path, key)
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return values, nil
+}
+
+func parseDuration(durationStr string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(durationStr); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
 	duration, err := time.ParseDuration(durationStr)
 	if err != nil {
-		times, _ := strconv.Atoi(durationStr)
-		return time.Duration(times) * time.Second
+		return 0, fmt.Errorf("must be a duration such as 30s or 1h, got %q", durationStr)
 	}
 
-	return duration
+	return duration, nil
 }
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "top-secret-0123456789abcdef0123456789"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	t.Chdir(t.TempDir())
+	file := writeFile(t, "calendar.yaml", `
+port: 9000
+read_timeout: 5s
+write_timeout: 20
+jwt_secret: from-file-0123456789abcdef0123456789
+rate_limit_read_rps: 2.5
+storage_type: sqlite
+`)
+	t.Setenv("PORT", "9100")
+	t.Setenv("STORAGE_TYPE", "memory")
+
+	cfg, err := Load([]string{"--config", file, "--port", "9200"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "9200", cfg.Port, "flag beats environment and file")
+	assert.Equal(t, StorageMemory, cfg.StorageType, "environment beats file")
+	assert.Equal(t, 5*time.Second, cfg.ReadTimeOut)
+	assert.Equal(t, 20*time.Second, cfg.WriteTimeOut, "bare number is seconds")
+	assert.Equal(t, 2.5, cfg.RateLimitReadRPS)
+	assert.Equal(t, "from-file-0123456789abcdef0123456789", cfg.JWTSecret)
+	assert.Equal(t, Default().IdleTimeOut, cfg.IdleTimeOut, "unset keys keep defaults")
+}
+
+func TestLoad_DotEnvAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	file := writeFile(t, "calendar.json", `{"port": 9300, "legacy_routes": false, "webhook_workers": 2}`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("JWT_SECRET=dotenv-0123456789abcdef0123456789abcdef\nCONFIG_FILE="+file+"\n"), 0o600))
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "dotenv-0123456789abcdef0123456789abcdef", cfg.JWTSecret)
+	assert.Equal(t, "9300", cfg.Port)
+	assert.False(t, cfg.LegacyRoutes)
+	assert.Equal(t, 2, cfg.WebhookWorkers)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	t.Run("bad values are reported with their source", func(t *testing.T) {
+		file := writeFile(t, "calendar.yaml", "read_timeout: soon\nunknown: 1\n")
+		t.Setenv("WEBHOOK_WORKERS", "many")
+
+		_, err := Load([]string{"--config", file, "--jwt-secret", testSecret})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `config file: read_timeout: must be a duration such as 30s or 1h, got "soon"`)
+		assert.Contains(t, err.Error(), `config file: unknown key "unknown"`)
+		assert.Contains(t, err.Error(), `environment: webhook_workers: must be an integer, got "many"`)
+	})
+
+	t.Run("validation lists every problem", func(t *testing.T) {
+		_, err := Load([]string{"--port", "0", "--storage-type", "redis", "--reminder-notifier", "webhook",
+			"--webhook-allowed-networks", "127.0.0.0/8, 10.0.0.1"})
+		require.Error(t, err)
+		for _, want := range []string{
+			`port: must be between 1 and 65535, got "0"`,
+			"storage_type: must be one of memory, sqlite",
+			"jwt_secret: is required",
+			"reminder_webhook_url: must be an absolute http or https URL",
+			`webhook_allowed_networks: must be a comma-separated list of CIDR networks, got "10.0.0.1"`,
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+
+	t.Run("zero is allowed only where it makes sense", func(t *testing.T) {
+		cfg, err := Load([]string{"--jwt-secret", testSecret, "--shutdown-drain-delay", "0"})
+		require.NoError(t, err)
+		assert.Zero(t, cfg.ShutdownDrainDelay)
+
+		_, err = Load([]string{"--jwt-secret", testSecret, "--shutdown-drain-delay", "-1s", "--shutdown-timeout", "0"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "shutdown_drain_delay: must not be negative, got -1s")
+		assert.Contains(t, err.Error(), "shutdown_timeout: must be positive, got 0s")
+
+		_, err = Load([]string{"--jwt-secret", testSecret, "--rate-limit-idle-ttl", "1ns"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rate_limit_idle_ttl: must be at least 1s, got 1ns")
+	})
+
+	t.Run("webhook networks", func(t *testing.T) {
+		cfg, err := Load([]string{"--jwt-secret", testSecret, "--webhook-allowed-networks", "10.0.0.0/8, fd00::/8"})
+		require.NoError(t, err)
+		networks, err := cfg.WebhookNetworks()
+		require.NoError(t, err)
+		assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}, networks)
+	})
+
+	t.Run("weak jwt secret", func(t *testing.T) {
+		_, err := Load([]string{"--jwt-secret", "short"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "jwt_secret: must be at least 32 bytes long")
+
+		_, err = Load([]string{"--jwt-secret", "change-me-change-me-change-me-change-me"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "jwt_secret: must not be a placeholder value")
+	})
+
+	t.Run("missing config file", func(t *testing.T) {
+		_, err := Load([]string{"--config", filepath.Join(t.TempDir(), "missing.yaml")})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestConfig_Reload(t *testing.T) {
+	current := Default()
+	next := Default()
+	next.Level = LevelDev
+	next.RateLimitWriteRPS = 50
+	next.Port = "9000"
+
+	reloaded, changed, ignored := current.Reload(next)
+	assert.Equal(t, []string{"level", "rate_limit_write_rps"}, changed)
+	assert.Equal(t, []string{"port"}, ignored)
+	assert.Equal(t, LevelDev, reloaded.Level)
+	assert.Equal(t, 50.0, reloaded.RateLimitWriteRPS)
+	assert.Equal(t, "8080", reloaded.Port)
+	assert.Equal(t, LevelProd, current.Level, "current config is not modified")
+}
+
+func TestConfig_Print(t *testing.T) {
+	cfg := Default()
+	cfg.JWTSecret = testSecret
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+	assert.Contains(t, out.String(), "read_timeout: \"10s\"\n")
+	assert.Contains(t, out.String(), "jwt_secret: \"********\"\n")
+	assert.NotContains(t, out.String(), testSecret)
+
+	file := writeFile(t, "printed.yaml", out.String())
+	t.Chdir(t.TempDir())
+	printed, err := Load([]string{"--config", file, "--jwt-secret", testSecret})
+	require.NoError(t, err)
+	printed.File = ""
+	assert.Equal(t, cfg, printed, "printed settings load back")
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// linearRepository — прежнее устройство EventRepository: один срез событий,
+// который каждая операция просматривает целиком. Нужен только для сравнения.
+type linearRepository struct {
+	mu     sync.RWMutex
+	events []Event
+	nextID int
+}
+
+func (lr *linearRepository) CreateEvent(_ context.Context, event Event) (Event, error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	lr.nextID++
+	event.ID = lr.nextID
+	lr.events = append(lr.events, event)
+	return event, nil
+}
+
+func (lr *linearRepository) UpdateEvent(_ context.Context, event Event) (Event, error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	for i := range lr.events {
+		if lr.events[i].ID == event.ID && lr.events[i].UserID == event.UserID {
+			lr.events[i].Date = event.Date
+			lr.events[i].End = event.End
+			lr.events[i].Title = event.Title
+			return lr.events[i], nil
+		}
+	}
+	return Event{}, ErrEventNotFound
+}
+
+func (lr *linearRepository) GetEventsForDay(_ context.Context, userID int, date time.Time) ([]Event, error) {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	from, to := DayRange(date)
+	var result []Event
+	for _, event := range lr.events {
+		if event.UserID == userID && !event.IsRecurring() && event.Overlaps(from, to) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (lr *linearRepository) GetEventsForMonth(_ context.Context, userID int, date time.Time) ([]Event, error) {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+
+	from, to := MonthRange(date)
+	var result []Event
+	for _, event := range lr.events {
+		if event.UserID == userID && !event.IsRecurring() && event.Overlaps(from, to) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+type benchStorage interface {
+	CreateEvent(ctx context.Context, event Event) (Event, error)
+	UpdateEvent(ctx context.Context, event Event) (Event, error)
+	GetEventsForDay(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	GetEventsForMonth(ctx context.Context, userID int, date time.Time) ([]Event, error)
+}
+
+const (
+	benchUsers         = 1000
+	benchEventsPerUser = 100
+)
+
+var benchStart = time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// fillBenchStorage создаёт по benchEventsPerUser часовых событий на каждого
+// пользователя, по одному в день начиная с benchStart.
+func fillBenchStorage(b *testing.B, repo benchStorage) {
+	b.Helper()
+	for day := 0; day < benchEventsPerUser; day++ {
+		for user := 1; user <= benchUsers; user++ {
+			date := benchStart.AddDate(0, 0, day)
+			if _, err := repo.CreateEvent(b.Context(), Event{UserID: user, Date: date, End: date.Add(time.Hour), Title: "Event"}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func benchStorages() map[string]func() benchStorage {
+	return map[string]func() benchStorage{
+		"indexed": func() benchStorage { return NewEventRepository(slog.New(slog.NewTextHandler(io.Discard, nil))) },
+		"linear":  func() benchStorage { return &linearRepository{} },
+	}
+}
+
+func BenchmarkGetEventsForDay(b *testing.B) {
+	for name, newRepo := range benchStorages() {
+		b.Run(name, func(b *testing.B) {
+			repo := newRepo()
+			fillBenchStorage(b, repo)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				events, _ := repo.GetEventsForDay(b.Context(), i%benchUsers+1, benchStart.AddDate(0, 0, i%benchEventsPerUser))
+				if len(events) != 1 {
+					b.Fatalf("expected 1 event, got %d", len(events))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetEventsForMonth(b *testing.B) {
+	for name, newRepo := range benchStorages() {
+		b.Run(name, func(b *testing.B) {
+			repo := newRepo()
+			fillBenchStorage(b, repo)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				events, _ := repo.GetEventsForMonth(b.Context(), i%benchUsers+1, benchStart.AddDate(0, 1, 0))
+				if len(events) != 28 {
+					b.Fatalf("expected 28 events, got %d", len(events))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUpdateEvent(b *testing.B) {
+	for name, newRepo := range benchStorages() {
+		b.Run(name, func(b *testing.B) {
+			repo := newRepo()
+			fillBenchStorage(b, repo)
+			total := benchUsers * benchEventsPerUser
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				id := (i*7919)%total + 1
+				userID := (id-1)%benchUsers + 1
+				date := benchStart.AddDate(0, 0, (id-1)/benchUsers)
+				if _, err := repo.UpdateEvent(b.Context(), Event{ID: id, UserID: userID, Date: date, End: date.Add(time.Hour), Title: fmt.Sprint("Event ", i)}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
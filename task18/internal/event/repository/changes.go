@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// ChangeSink принимает изменения событий, например шина изменений или
+// рассылка вебхуков. Publish не должен блокировать вызывающего.
+type ChangeSink interface {
+	Publish(change Change)
+}
+
+// publishingStorage сообщает в каждый ChangeSink о каждом успешном создании, изменении
+// и удалении события. Изменение вхождения серии публикуется как новая замена
+// вхождения и изменение самой серии, удаление серии — как удаление её и каждой
+// замены. Viewers изменения — пользователи, которым событие видно через доступ
+// к календарю или приглашение; их определяют до удаления, пока доступы на месте.
+type publishingStorage struct {
+	Storage
+	sinks []ChangeSink
+}
+
+// Publish оборачивает storage так, что изменения событий попадают во все sinks.
+func Publish(storage Storage, sinks ...ChangeSink) Storage {
+	return &publishingStorage{Storage: storage, sinks: sinks}
+}
+
+func (s *publishingStorage) CreateEvent(ctx context.Context, event Event) (Event, error) {
+	created, err := s.Storage.CreateEvent(ctx, event)
+	if err == nil {
+		s.send(s.change(ctx, ChangeCreated, created))
+	}
+	return created, err
+}
+
+func (s *publishingStorage) UpdateEvent(ctx context.Context, event Event) (Event, error) {
+	updated, err := s.Storage.UpdateEvent(ctx, event)
+	if err == nil {
+		s.send(s.change(ctx, ChangeUpdated, updated))
+	}
+	return updated, err
+}
+
+func (s *publishingStorage) DeleteEvent(ctx context.Context, eventID, userID, version int) error {
+	deletions := s.deletions(ctx, eventID, userID)
+	err := s.Storage.DeleteEvent(ctx, eventID, userID, version)
+	if err == nil {
+		s.send(deletions...)
+	}
+	return err
+}
+
+func (s *publishingStorage) DeleteOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, version int) error {
+	err := s.Storage.DeleteOccurrence(ctx, eventID, userID, occurrence, version)
+	if err == nil {
+		s.publishSeries(ctx, eventID, userID)
+	}
+	return err
+}
+
+func (s *publishingStorage) ReplaceOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, override Event, version int) (Event, error) {
+	created, err := s.Storage.ReplaceOccurrence(ctx, eventID, userID, occurrence, override, version)
+	if err == nil {
+		s.publishSeries(ctx, eventID, userID)
+		s.send(s.change(ctx, ChangeCreated, created))
+	}
+	return created, err
+}
+
+func (s *publishingStorage) ApplyBatch(ctx context.Context, ops []BatchOp) ([]Event, error) {
+	deletions := make(map[int][]Change)
+	for i, op := range ops {
+		if op.Kind == BatchDelete {
+			deletions[i] = s.deletions(ctx, op.Event.ID, op.Event.UserID)
+		}
+	}
+
+	results, err := s.Storage.ApplyBatch(ctx, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range results {
+		switch ops[i].Kind {
+		case BatchCreate:
+			s.send(s.change(ctx, ChangeCreated, result))
+		case BatchUpdate:
+			s.send(s.change(ctx, ChangeUpdated, result))
+		case BatchDelete:
+			s.send(deletions[i]...)
+		}
+	}
+	return results, nil
+}
+
+// RestoreEvent публикует восстановление удалённого события как создание.
+func (s *publishingStorage) RestoreEvent(ctx context.Context, event Event) (Event, error) {
+	_, getErr := s.Storage.GetEvent(ctx, event.ID, event.UserID)
+
+	restored, err := s.Storage.RestoreEvent(ctx, event)
+	if err == nil {
+		changeType := ChangeUpdated
+		if getErr != nil {
+			changeType = ChangeCreated
+		}
+		s.send(s.change(ctx, changeType, restored))
+	}
+	return restored, err
+}
+
+// DeleteCalendar публикует удаление каждого события календаря, включая замены
+// вхождений, как если бы события удалялись по одному.
+func (s *publishingStorage) DeleteCalendar(ctx context.Context, calendarID, userID int) error {
+	events, err := s.Storage.GetUserEvents(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var deletions []Change
+	for _, event := range events {
+		if event.CalendarID == calendarID {
+			deletions = append(deletions, s.change(ctx, ChangeDeleted, event))
+		}
+	}
+
+	if err := s.Storage.DeleteCalendar(ctx, calendarID, userID); err != nil {
+		return err
+	}
+
+	s.send(deletions...)
+	return nil
+}
+
+// publishSeries публикует изменение серии после изменения её вхождения.
+// Если серию не удалось перечитать, изменение публикуется без события.
+func (s *publishingStorage) publishSeries(ctx context.Context, eventID, userID int) {
+	series, err := s.Storage.GetEvent(ctx, eventID, userID)
+	if err != nil {
+		series = Event{ID: eventID, UserID: userID}
+	}
+	s.send(s.change(ctx, ChangeUpdated, series))
+}
+
+// deletions готовит изменения об удалении события и, если это серия, её замен
+// вхождений. Вызывается до удаления: после него не найти ни замен, ни зрителей.
+func (s *publishingStorage) deletions(ctx context.Context, eventID, userID int) []Change {
+	event, err := s.Storage.GetEvent(ctx, eventID, userID)
+	if err != nil {
+		return []Change{{Type: ChangeDeleted, UserID: userID, EventID: eventID}}
+	}
+
+	removed := []Event{event}
+	if event.IsRecurring() {
+		events, err := s.Storage.GetUserEvents(ctx, userID)
+		if err == nil {
+			for _, e := range events {
+				if e.RecurringEventID == eventID {
+					removed = append(removed, e)
+				}
+			}
+		}
+	}
+
+	changes := make([]Change, len(removed))
+	for i, e := range removed {
+		changes[i] = s.change(ctx, ChangeDeleted, e)
+	}
+	return changes
+}
+
+func (s *publishingStorage) change(ctx context.Context, changeType string, event Event) Change {
+	change := Change{
+		Type:    changeType,
+		UserID:  event.UserID,
+		EventID: event.ID,
+		Viewers: s.viewers(ctx, event),
+	}
+	if changeType != ChangeDeleted && event.Version != 0 {
+		change.Event = &event
+	}
+	return change
+}
+
+// viewers возвращает пользователей, кроме владельца, которым event виден через
+// доступ к его календарю или приглашение на событие или его серию.
+func (s *publishingStorage) viewers(ctx context.Context, event Event) []int {
+	var viewers []int
+	if event.CalendarID != 0 {
+		if shares, err := s.Storage.ListCalendarShares(ctx, event.CalendarID); err == nil {
+			for _, share := range shares {
+				viewers = append(viewers, share.UserID)
+			}
+		}
+	}
+
+	seriesID := event.ID
+	if event.RecurringEventID != 0 {
+		seriesID = event.RecurringEventID
+	}
+	if attendees, err := s.Storage.ListAttendees(ctx, seriesID); err == nil {
+		for _, attendee := range attendees {
+			viewers = append(viewers, attendee.UserID)
+		}
+	}
+
+	slices.Sort(viewers)
+	viewers = slices.Compact(viewers)
+	return slices.DeleteFunc(viewers, func(userID int) bool { return userID == event.UserID })
+}
+
+func (s *publishingStorage) send(changes ...Change) {
+	now := time.Now()
+	for _, change := range changes {
+		change.Time = now
+		for _, sink := range s.sinks {
+			sink.Publish(change)
+		}
+	}
+}
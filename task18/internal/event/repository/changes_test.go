@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type changeLog []Change
+
+func (l *changeLog) Publish(change Change) {
+	*l = append(*l, change)
+}
+
+func (l *changeLog) types() []string {
+	types := make([]string, len(*l))
+	for i, change := range *l {
+		types[i] = change.Type
+	}
+	return types
+}
+
+func TestPublish(t *testing.T) {
+	var log changeLog
+	repo := Publish(NewEventRepository(testLogger()), &log)
+	date := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	series, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	_, err = repo.UpdateEvent(t.Context(), Event{ID: series.ID, UserID: 1, Date: date, Title: "Daily", Recurrence: series.Recurrence})
+	require.NoError(t, err)
+	override, err := repo.ReplaceOccurrence(t.Context(), series.ID, 1, date.AddDate(0, 0, 1), Event{Date: date, Title: "Moved"}, 0)
+	require.NoError(t, err)
+
+	_, err = repo.UpdateEvent(t.Context(), Event{ID: 999, UserID: 1, Date: date, Title: "Missing"})
+	require.ErrorIs(t, err, ErrEventNotFound)
+
+	_, err = repo.ApplyBatch(t.Context(), []BatchOp{
+		{Kind: BatchCreate, Event: Event{UserID: 1, Date: date, Title: "Review"}},
+		{Kind: BatchDelete, Event: Event{ID: series.ID, UserID: 1}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		ChangeCreated, ChangeUpdated,
+		ChangeUpdated, ChangeCreated,
+		ChangeCreated, ChangeDeleted, ChangeDeleted,
+	}, log.types())
+
+	assert.Equal(t, "Daily", log[1].Event.Title)
+	assert.Equal(t, 3, log[2].Event.Version, "series version after the occurrence change")
+	assert.Equal(t, override.ID, log[3].EventID)
+	assert.Equal(t, series.ID, log[5].EventID)
+	assert.Nil(t, log[5].Event)
+	assert.Equal(t, override.ID, log[6].EventID, "deleting a series deletes its overrides")
+	for _, change := range log {
+		assert.Equal(t, 1, change.UserID)
+	}
+}
+
+func TestPublish_DeleteCalendar(t *testing.T) {
+	var log changeLog
+	repo := Publish(NewEventRepository(testLogger()), &log)
+	date := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	work, err := repo.CreateCalendar(t.Context(), Calendar{UserID: 1, Name: "work"})
+	require.NoError(t, err)
+	inCalendar, err := repo.CreateEvent(t.Context(), Event{UserID: 1, CalendarID: work.ID, Date: date, Title: "Standup"})
+	require.NoError(t, err)
+	_, err = repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Lunch"})
+	require.NoError(t, err)
+	log = nil
+
+	require.NoError(t, repo.DeleteCalendar(t.Context(), work.ID, 1))
+
+	require.Len(t, log, 1)
+	assert.Equal(t, ChangeDeleted, log[0].Type)
+	assert.Equal(t, inCalendar.ID, log[0].EventID)
+}
+
+func TestPublish_Viewers(t *testing.T) {
+	var log changeLog
+	repo := Publish(NewEventRepository(testLogger()), &log)
+	date := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	work, err := repo.CreateCalendar(t.Context(), Calendar{UserID: 1, Name: "work"})
+	require.NoError(t, err)
+	_, err = repo.ShareCalendar(t.Context(), CalendarShare{CalendarID: work.ID, UserID: 2, Role: RoleViewer})
+	require.NoError(t, err)
+	series, err := repo.CreateEvent(t.Context(), Event{UserID: 1, CalendarID: work.ID, Date: date, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	_, err = repo.AddAttendee(t.Context(), Attendee{EventID: series.ID, OrganizerID: 1, UserID: 3})
+	require.NoError(t, err)
+	override, err := repo.ReplaceOccurrence(t.Context(), series.ID, 1, date.AddDate(0, 0, 1), Event{Date: date, Title: "Moved"}, 0)
+	require.NoError(t, err)
+	log = nil
+
+	require.NoError(t, repo.DeleteCalendar(t.Context(), work.ID, 1))
+
+	require.Len(t, log, 2)
+	assert.ElementsMatch(t, []int{series.ID, override.ID}, []int{log[0].EventID, log[1].EventID})
+	for _, change := range log {
+		assert.Equal(t, []int{2, 3}, change.Viewers)
+		assert.True(t, change.VisibleTo(2))
+		assert.False(t, change.VisibleTo(4))
+	}
+}
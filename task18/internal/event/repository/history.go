@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"calendar/internal/requestctx"
+	"context"
+	"time"
+)
+
+// Хранилища дописывают в историю каждое создание, изменение, удаление и
+// восстановление события под той же блокировкой или в той же транзакции,
+// что и само изменение: состояние «до» читается там же, и изменение без
+// записи в истории (или запись без изменения) невозможно. Удаление серии
+// записывается и для каждой замены её вхождений.
+
+// newHistoryEntry описывает изменение события: состояние до и после,
+// пользователя и ID запроса из контекста.
+func newHistoryEntry(ctx context.Context, action string, before, after *Event) HistoryEntry {
+	event := after
+	if event == nil {
+		event = before
+	}
+
+	actorID, _ := requestctx.UserID(ctx)
+	return HistoryEntry{
+		UserID:    event.UserID,
+		EventID:   event.ID,
+		Action:    action,
+		ActorID:   actorID,
+		RequestID: requestctx.RequestID(ctx),
+		Old:       before,
+		New:       after,
+		Time:      time.Now(),
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"calendar/internal/requestctx"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyActions(entries []HistoryEntry) []string {
+	actions := make([]string, len(entries))
+	for i, entry := range entries {
+		actions[i] = entry.Action
+	}
+	return actions
+}
+
+func testStorageHistoryRecording(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+	ctx := requestctx.WithUserID(requestctx.WithRequestID(t.Context(), "req-1"), 2)
+
+	series, err := repo.CreateEvent(ctx, Event{UserID: 1, Date: date, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	updated, err := repo.UpdateEvent(ctx, Event{ID: series.ID, UserID: 1, Date: date, Title: "Daily", Recurrence: series.Recurrence})
+	require.NoError(t, err)
+	override, err := repo.ReplaceOccurrence(ctx, series.ID, 1, date.AddDate(0, 0, 1), Event{Date: date.AddDate(0, 0, 1), Title: "Moved"}, 0)
+	require.NoError(t, err)
+
+	_, err = repo.UpdateEvent(ctx, Event{ID: 999, UserID: 1, Date: date, Title: "Missing"})
+	require.ErrorIs(t, err, ErrEventNotFound)
+
+	t.Run("changes are recorded with actor and request", func(t *testing.T) {
+		entries, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 1, EventID: series.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{HistoryUpdated, HistoryUpdated, HistoryCreated}, historyActions(entries))
+
+		update := entries[1]
+		assert.Equal(t, 2, update.ActorID)
+		assert.Equal(t, "req-1", update.RequestID)
+		require.NotNil(t, update.Old)
+		require.NotNil(t, update.New)
+		assert.Equal(t, "Standup", update.Old.Title)
+		assert.Equal(t, updated.Version, update.New.Version)
+		assert.False(t, update.Time.IsZero())
+
+		assert.Len(t, entries[0].New.ExDates, 1, "replacing an occurrence updates the series")
+	})
+
+	t.Run("series deletion records its overrides", func(t *testing.T) {
+		require.NoError(t, repo.DeleteEvent(ctx, series.ID, 1, 0))
+
+		entries, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 1, EventID: override.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{HistoryDeleted, HistoryCreated}, historyActions(entries))
+		assert.Nil(t, entries[0].New)
+		assert.Equal(t, "Moved", entries[0].Old.Title)
+	})
+
+	t.Run("restore is recorded", func(t *testing.T) {
+		entries, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 1, EventID: series.ID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		restored, err := repo.RestoreEvent(t.Context(), *entries[0].Old)
+		require.NoError(t, err)
+
+		entries, err = repo.ListHistory(t.Context(), HistoryQuery{UserID: 1, EventID: series.ID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, HistoryRestored, entries[0].Action)
+		assert.Nil(t, entries[0].Old)
+		assert.Equal(t, restored.Version, entries[0].New.Version)
+		assert.Zero(t, entries[0].ActorID)
+	})
+
+	t.Run("calendar deletion records its events", func(t *testing.T) {
+		cal, err := repo.CreateCalendar(t.Context(), Calendar{UserID: 3, Name: "team"})
+		require.NoError(t, err)
+		meeting, err := repo.CreateEvent(t.Context(), Event{UserID: 3, CalendarID: cal.ID, Date: date, Title: "Sync"})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteCalendar(t.Context(), cal.ID, 3))
+
+		entries, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 3, EventID: meeting.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{HistoryDeleted, HistoryCreated}, historyActions(entries))
+	})
+
+	t.Run("batch is recorded only when applied", func(t *testing.T) {
+		results, err := repo.ApplyBatch(ctx, []BatchOp{
+			{Kind: BatchCreate, Event: Event{UserID: 4, Date: date, Title: "Lunch"}},
+		})
+		require.NoError(t, err)
+		lunch := results[0]
+
+		_, err = repo.ApplyBatch(ctx, []BatchOp{
+			{Kind: BatchUpdate, Event: Event{ID: lunch.ID, UserID: 4, Date: date, Title: "Dinner"}},
+			{Kind: BatchDelete, Event: Event{ID: 999, UserID: 4}},
+		})
+		require.Error(t, err)
+
+		_, err = repo.ApplyBatch(ctx, []BatchOp{
+			{Kind: BatchUpdate, Event: Event{ID: lunch.ID, UserID: 4, Date: date, Title: "Brunch"}},
+			{Kind: BatchDelete, Event: Event{ID: lunch.ID, UserID: 4}},
+		})
+		require.NoError(t, err)
+
+		entries, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 4, EventID: lunch.ID})
+		require.NoError(t, err)
+		assert.Equal(t, []string{HistoryDeleted, HistoryUpdated, HistoryCreated}, historyActions(entries))
+		assert.Equal(t, "Lunch", entries[1].Old.Title)
+		assert.Equal(t, "Brunch", entries[0].Old.Title)
+	})
+}
@@ -0,0 +1,364 @@
+package repository
+
+import (
+	"calendar/internal/metrics"
+	"calendar/internal/tracing"
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// instrumentedStorage замеряет длительность каждой операции хранилища, считает
+// её сбои и ведёт для неё span трассировки. Ожидаемые ответы вроде
+// ErrEventNotFound сбоями не считаются.
+type instrumentedStorage struct {
+	storage  Storage
+	duration *metrics.HistogramVec
+	failures *metrics.CounterVec
+}
+
+// Instrument оборачивает storage так, что каждая операция попадает в метрики reg
+// и в трассу из контекста вызова, и регистрирует gauge с числом хранимых событий.
+func Instrument(storage Storage, reg *metrics.Registry) Storage {
+	s := &instrumentedStorage{
+		storage: storage,
+		duration: reg.NewHistogramVec("calendar_storage_operation_duration_seconds",
+			"Storage operation latency in seconds.", metrics.DefaultBuckets, "operation"),
+		failures: reg.NewCounterVec("calendar_storage_operation_errors_total",
+			"Total number of failed storage operations.", "operation"),
+	}
+
+	reg.NewGaugeFunc("calendar_events", "Number of stored events.", func() float64 {
+		count, err := storage.CountEvents(context.Background())
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(count)
+	})
+
+	return s
+}
+
+// start начинает span операции operation; возвращённая функция завершает его
+// и записывает длительность и исход операции в метрики.
+func (s *instrumentedStorage) start(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "storage."+operation)
+
+	return ctx, func(err error) {
+		s.duration.Observe(time.Since(start).Seconds(), operation)
+		if err != nil && !isExpected(err) {
+			s.failures.Inc(operation)
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}
+
+func isExpected(err error) bool {
+	return errors.Is(err, ErrEventNotFound) ||
+		errors.Is(err, ErrEventExists) ||
+		errors.Is(err, ErrVersionMismatch) ||
+		errors.Is(err, ErrWebhookNotFound) ||
+		errors.Is(err, ErrCalendarNotFound) ||
+		errors.Is(err, ErrShareNotFound) ||
+		errors.Is(err, ErrAttendeeNotFound) ||
+		errors.Is(err, ErrInvalidDataInput)
+}
+
+func (s *instrumentedStorage) CreateEvent(ctx context.Context, event Event) (Event, error) {
+	ctx, done := s.start(ctx, "create_event")
+	result, err := s.storage.CreateEvent(ctx, event)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) UpdateEvent(ctx context.Context, event Event) (Event, error) {
+	ctx, done := s.start(ctx, "update_event")
+	result, err := s.storage.UpdateEvent(ctx, event)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) DeleteEvent(ctx context.Context, eventID, userID, version int) error {
+	ctx, done := s.start(ctx, "delete_event")
+	err := s.storage.DeleteEvent(ctx, eventID, userID, version)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) GetEvent(ctx context.Context, eventID, userID int) (Event, error) {
+	ctx, done := s.start(ctx, "get_event")
+	result, err := s.storage.GetEvent(ctx, eventID, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetUserEvents(ctx context.Context, userID int) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_user_events")
+	result, err := s.storage.GetUserEvents(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetEventsForDay(ctx context.Context, userID int, date time.Time) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_events_for_day")
+	result, err := s.storage.GetEventsForDay(ctx, userID, date)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetEventsForWeek(ctx context.Context, userID int, date time.Time) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_events_for_week")
+	result, err := s.storage.GetEventsForWeek(ctx, userID, date)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetEventsForMonth(ctx context.Context, userID int, date time.Time) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_events_for_month")
+	result, err := s.storage.GetEventsForMonth(ctx, userID, date)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetEventsBetween(ctx context.Context, userID int, from, to time.Time) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_events_between")
+	result, err := s.storage.GetEventsBetween(ctx, userID, from, to)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetRecurringEvents(ctx context.Context, userID int, before time.Time) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_recurring_events")
+	result, err := s.storage.GetRecurringEvents(ctx, userID, before)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) DeleteOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, version int) error {
+	ctx, done := s.start(ctx, "delete_occurrence")
+	err := s.storage.DeleteOccurrence(ctx, eventID, userID, occurrence, version)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) ReplaceOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, override Event, version int) (Event, error) {
+	ctx, done := s.start(ctx, "replace_occurrence")
+	result, err := s.storage.ReplaceOccurrence(ctx, eventID, userID, occurrence, override, version)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetEventsWithReminders(ctx context.Context, startsAfter time.Time) ([]Event, error) {
+	ctx, done := s.start(ctx, "get_events_with_reminders")
+	result, err := s.storage.GetEventsWithReminders(ctx, startsAfter)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ClaimReminder(ctx context.Context, key ReminderKey) (bool, error) {
+	ctx, done := s.start(ctx, "claim_reminder")
+	result, err := s.storage.ClaimReminder(ctx, key)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ReleaseReminder(ctx context.Context, key ReminderKey) error {
+	ctx, done := s.start(ctx, "release_reminder")
+	err := s.storage.ReleaseReminder(ctx, key)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) ApplyBatch(ctx context.Context, ops []BatchOp) ([]Event, error) {
+	ctx, done := s.start(ctx, "apply_batch")
+	result, err := s.storage.ApplyBatch(ctx, ops)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) CreateWebhook(ctx context.Context, hook Webhook) (Webhook, error) {
+	ctx, done := s.start(ctx, "create_webhook")
+	result, err := s.storage.CreateWebhook(ctx, hook)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetWebhook(ctx context.Context, webhookID, userID int) (Webhook, error) {
+	ctx, done := s.start(ctx, "get_webhook")
+	result, err := s.storage.GetWebhook(ctx, webhookID, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ListWebhooks(ctx context.Context, userID int) ([]Webhook, error) {
+	ctx, done := s.start(ctx, "list_webhooks")
+	result, err := s.storage.ListWebhooks(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) DeleteWebhook(ctx context.Context, webhookID, userID int) error {
+	ctx, done := s.start(ctx, "delete_webhook")
+	err := s.storage.DeleteWebhook(ctx, webhookID, userID)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) CreateCalendar(ctx context.Context, cal Calendar) (Calendar, error) {
+	ctx, done := s.start(ctx, "create_calendar")
+	result, err := s.storage.CreateCalendar(ctx, cal)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetCalendar(ctx context.Context, calendarID, userID int) (Calendar, error) {
+	ctx, done := s.start(ctx, "get_calendar")
+	result, err := s.storage.GetCalendar(ctx, calendarID, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ListCalendars(ctx context.Context, userID int) ([]Calendar, error) {
+	ctx, done := s.start(ctx, "list_calendars")
+	result, err := s.storage.ListCalendars(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) UpdateCalendar(ctx context.Context, cal Calendar) (Calendar, error) {
+	ctx, done := s.start(ctx, "update_calendar")
+	result, err := s.storage.UpdateCalendar(ctx, cal)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) DeleteCalendar(ctx context.Context, calendarID, userID int) error {
+	ctx, done := s.start(ctx, "delete_calendar")
+	err := s.storage.DeleteCalendar(ctx, calendarID, userID)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) ShareCalendar(ctx context.Context, share CalendarShare) (CalendarShare, error) {
+	ctx, done := s.start(ctx, "share_calendar")
+	result, err := s.storage.ShareCalendar(ctx, share)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) UnshareCalendar(ctx context.Context, calendarID, userID int) error {
+	ctx, done := s.start(ctx, "unshare_calendar")
+	err := s.storage.UnshareCalendar(ctx, calendarID, userID)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) ListCalendarShares(ctx context.Context, calendarID int) ([]CalendarShare, error) {
+	ctx, done := s.start(ctx, "list_calendar_shares")
+	result, err := s.storage.ListCalendarShares(ctx, calendarID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) RestoreEvent(ctx context.Context, event Event) (Event, error) {
+	ctx, done := s.start(ctx, "restore_event")
+	result, err := s.storage.RestoreEvent(ctx, event)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) AddHistory(ctx context.Context, entry HistoryEntry) (HistoryEntry, error) {
+	ctx, done := s.start(ctx, "add_history")
+	result, err := s.storage.AddHistory(ctx, entry)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ListHistory(ctx context.Context, query HistoryQuery) ([]HistoryEntry, error) {
+	ctx, done := s.start(ctx, "list_history")
+	result, err := s.storage.ListHistory(ctx, query)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ListTrash(ctx context.Context, userID int) ([]TrashedEvent, error) {
+	ctx, done := s.start(ctx, "list_trash")
+	result, err := s.storage.ListTrash(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) GetTrashedEvent(ctx context.Context, eventID, userID int) (TrashedEvent, error) {
+	ctx, done := s.start(ctx, "get_trashed_event")
+	result, err := s.storage.GetTrashedEvent(ctx, eventID, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	ctx, done := s.start(ctx, "purge_trash")
+	result, err := s.storage.PurgeTrash(ctx, before)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) AddAttendee(ctx context.Context, attendee Attendee) (Attendee, error) {
+	ctx, done := s.start(ctx, "add_attendee")
+	result, err := s.storage.AddAttendee(ctx, attendee)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) RemoveAttendee(ctx context.Context, eventID, userID int) error {
+	ctx, done := s.start(ctx, "remove_attendee")
+	err := s.storage.RemoveAttendee(ctx, eventID, userID)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) ListAttendees(ctx context.Context, eventID int) ([]Attendee, error) {
+	ctx, done := s.start(ctx, "list_attendees")
+	result, err := s.storage.ListAttendees(ctx, eventID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) SetAttendeeStatus(ctx context.Context, eventID, userID int, status string) (Attendee, error) {
+	ctx, done := s.start(ctx, "set_attendee_status")
+	result, err := s.storage.SetAttendeeStatus(ctx, eventID, userID, status)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) ResetAttendees(ctx context.Context, eventID int) error {
+	ctx, done := s.start(ctx, "reset_attendees")
+	err := s.storage.ResetAttendees(ctx, eventID)
+	done(err)
+	return err
+}
+
+func (s *instrumentedStorage) ListInvitations(ctx context.Context, userID int) ([]Attendee, error) {
+	ctx, done := s.start(ctx, "list_invitations")
+	result, err := s.storage.ListInvitations(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *instrumentedStorage) CountEvents(ctx context.Context) (int, error) {
+	ctx, done := s.start(ctx, "count_events")
+	result, err := s.storage.CountEvents(ctx)
+	done(err)
+	return result, err
+}
+
+// Ping не попадает в метрики: его вызывают проверки готовности, а не запросы.
+func (s *instrumentedStorage) Ping(ctx context.Context) error {
+	return s.storage.Ping(ctx)
+}
+
+func (s *instrumentedStorage) Close() error {
+	return s.storage.Close()
+}
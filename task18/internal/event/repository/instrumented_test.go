@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"calendar/internal/metrics"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedStorage(t *testing.T) {
+	runStorageSuite(t, func(t *testing.T) Storage {
+		return Instrument(newMemoryStorage(t), metrics.NewRegistry())
+	})
+
+	reg := metrics.NewRegistry()
+	repo := Instrument(newMemoryStorage(t), reg)
+
+	_, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Title: "Counted"})
+	require.NoError(t, err)
+	_, err = repo.GetEvent(t.Context(), 999, 1)
+	require.ErrorIs(t, err, ErrEventNotFound)
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+
+	assert.Contains(t, out.String(), `calendar_storage_operation_duration_seconds_count{operation="create_event"} 1`)
+	assert.Contains(t, out.String(), `calendar_storage_operation_duration_seconds_count{operation="get_event"} 1`)
+	assert.NotContains(t, out.String(), `calendar_storage_operation_errors_total{`)
+	assert.Contains(t, out.String(), "\ncalendar_events 1\n")
+}
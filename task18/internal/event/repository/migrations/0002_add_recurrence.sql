@@ -0,0 +1,6 @@
+ALTER TABLE events ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN exdates TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE events ADD COLUMN recurring_event_id INTEGER REFERENCES events (id) ON DELETE CASCADE;
+ALTER TABLE events ADD COLUMN original_date TEXT;
+
+CREATE INDEX idx_events_user_recurrence ON events (user_id, recurrence);
@@ -0,0 +1,7 @@
+ALTER TABLE events ADD COLUMN uid TEXT NOT NULL DEFAULT '';
+
+UPDATE events SET uid = lower(hex(randomblob(16))) WHERE recurring_event_id IS NULL;
+UPDATE events SET uid = (SELECT s.uid FROM events s WHERE s.id = events.recurring_event_id)
+WHERE recurring_event_id IS NOT NULL;
+
+CREATE UNIQUE INDEX idx_events_user_uid ON events (user_id, uid) WHERE recurring_event_id IS NULL;
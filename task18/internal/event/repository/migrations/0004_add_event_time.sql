@@ -0,0 +1,14 @@
+ALTER TABLE events ADD COLUMN end_date TEXT NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN all_day INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- До появления времени начала события хранились как полночь UTC:
+-- такие события становятся событиями на весь день.
+UPDATE events
+SET all_day  = 1,
+    end_date = strftime('%Y-%m-%dT%H:%M:%S.000000000Z', date, '+1 day')
+WHERE time(date) = '00:00:00';
+
+UPDATE events SET end_date = date WHERE end_date = '';
+
+CREATE INDEX idx_events_user_end ON events (user_id, end_date);
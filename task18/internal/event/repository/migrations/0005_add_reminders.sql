@@ -0,0 +1,9 @@
+ALTER TABLE events ADD COLUMN reminders TEXT NOT NULL DEFAULT '[]';
+
+CREATE TABLE reminder_deliveries (
+    event_id       INTEGER NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    occurrence     TEXT    NOT NULL,
+    minutes_before INTEGER NOT NULL,
+    sent_at        TEXT    NOT NULL,
+    PRIMARY KEY (event_id, occurrence, minutes_before)
+);
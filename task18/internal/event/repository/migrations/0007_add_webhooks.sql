@@ -0,0 +1,10 @@
+CREATE TABLE webhooks (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL,
+    url        TEXT    NOT NULL,
+    secret     TEXT    NOT NULL,
+    events     TEXT    NOT NULL DEFAULT '[]',
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX idx_webhooks_user ON webhooks (user_id);
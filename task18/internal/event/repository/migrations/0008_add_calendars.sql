@@ -0,0 +1,22 @@
+CREATE TABLE calendars (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL,
+    name       TEXT    NOT NULL,
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX idx_calendars_user ON calendars (user_id);
+
+CREATE TABLE calendar_shares (
+    calendar_id INTEGER NOT NULL REFERENCES calendars (id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL,
+    role        TEXT    NOT NULL,
+    created_at  TEXT    NOT NULL,
+    PRIMARY KEY (calendar_id, user_id)
+);
+
+CREATE INDEX idx_calendar_shares_user ON calendar_shares (user_id);
+
+ALTER TABLE events ADD COLUMN calendar_id INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_events_calendar ON events (calendar_id);
@@ -0,0 +1,14 @@
+CREATE TABLE event_history (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL,
+    event_id   INTEGER NOT NULL,
+    action     TEXT    NOT NULL,
+    actor_id   INTEGER NOT NULL,
+    request_id TEXT    NOT NULL DEFAULT '',
+    old_event  TEXT,
+    new_event  TEXT,
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX idx_event_history_user ON event_history (user_id, id);
+CREATE INDEX idx_event_history_event ON event_history (user_id, event_id, id);
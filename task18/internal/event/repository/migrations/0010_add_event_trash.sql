@@ -0,0 +1,9 @@
+CREATE TABLE event_trash (
+    id         INTEGER PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    event      TEXT    NOT NULL,
+    deleted_at TEXT    NOT NULL
+);
+
+CREATE INDEX idx_event_trash_user ON event_trash (user_id, deleted_at);
+CREATE INDEX idx_event_trash_deleted ON event_trash (deleted_at);
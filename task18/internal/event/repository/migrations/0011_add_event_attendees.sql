@@ -0,0 +1,11 @@
+CREATE TABLE event_attendees (
+    event_id     INTEGER NOT NULL,
+    organizer_id INTEGER NOT NULL,
+    user_id      INTEGER NOT NULL,
+    status       TEXT    NOT NULL,
+    invited_at   TEXT    NOT NULL,
+    responded_at TEXT,
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX idx_event_attendees_user ON event_attendees (user_id);
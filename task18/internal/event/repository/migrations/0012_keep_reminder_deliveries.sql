@@ -0,0 +1,16 @@
+-- Отправленные напоминания удалённого события хранятся, пока оно в корзине,
+-- чтобы после восстановления они не ушли повторно; их удаляет очистка корзины.
+CREATE TABLE reminder_deliveries_new (
+    event_id       INTEGER NOT NULL,
+    occurrence     TEXT    NOT NULL,
+    minutes_before INTEGER NOT NULL,
+    sent_at        TEXT    NOT NULL,
+    PRIMARY KEY (event_id, occurrence, minutes_before)
+);
+
+INSERT INTO reminder_deliveries_new (event_id, occurrence, minutes_before, sent_at)
+SELECT event_id, occurrence, minutes_before, sent_at FROM reminder_deliveries;
+
+DROP TABLE reminder_deliveries;
+
+ALTER TABLE reminder_deliveries_new RENAME TO reminder_deliveries;
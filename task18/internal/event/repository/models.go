@@ -1,42 +1,497 @@
 package repository
 
 import (
+	"calendar/internal/problem"
+	"slices"
 	"time"
 )
 
 type Event struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Title     string    `json:"title"`
-	Date      time.Time `json:"date"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               int         `json:"id"`
+	UID              string      `json:"uid"`
+	UserID           int         `json:"user_id"`
+	CalendarID       int         `json:"calendar_id,omitempty"`
+	Title            string      `json:"title"`
+	Date             time.Time   `json:"date"`
+	End              time.Time   `json:"end"`
+	AllDay           bool        `json:"all_day"`
+	TimeZone         string      `json:"timezone"`
+	Recurrence       string      `json:"recurrence,omitempty"`
+	ExDates          []time.Time `json:"exdates,omitempty"`
+	RecurringEventID int         `json:"recurring_event_id,omitempty"`
+	OriginalDate     *time.Time  `json:"original_date,omitempty"`
+	Reminders        []int       `json:"reminders,omitempty"`
+	Version          int         `json:"version"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// EndTime возвращает конец события; у событий без длительности он совпадает с началом.
+func (e Event) EndTime() time.Time {
+	if e.End.Before(e.Date) {
+		return e.Date
+	}
+	return e.End
+}
+
+// Overlaps сообщает, пересекается ли событие с интервалом [from, to).
+// Событие нулевой длительности попадает в интервал, если в нём лежит его начало.
+// События на весь день не привязаны к часовому поясу: их даты сравниваются
+// с календарными датами границ интервала.
+func (e Event) Overlaps(from, to time.Time) bool {
+	if e.AllDay {
+		from, to = FloatingDate(from), FloatingDate(to)
+	}
+
+	if !e.Date.Before(to) {
+		return false
+	}
+	return e.EndTime().After(from) || !e.Date.Before(from)
+}
+
+// IsRecurring сообщает, является ли событие описанием серии, а не отдельным событием.
+func (e Event) IsRecurring() bool {
+	return e.Recurrence != ""
+}
+
+// IsExcluded сообщает, исключено ли вхождение серии, начинающееся в occurrence.
+func (e Event) IsExcluded(occurrence time.Time) bool {
+	for _, exDate := range e.ExDates {
+		if exDate.Equal(occurrence) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reschedules сообщает, отличается ли время события от времени other:
+// начало, конец, весь ли это день или правило повторения.
+func (e Event) Reschedules(other Event) bool {
+	return !e.Date.Equal(other.Date) || !e.EndTime().Equal(other.EndTime()) ||
+		e.AllDay != other.AllDay || e.Recurrence != other.Recurrence
+}
+
+// EventRequest — тело запросов на создание и замену события в REST API;
+// пользователь задаётся путём ресурса. calendar_id выбирает календарь при
+// создании; событие нельзя перенести в другой календарь.
+type EventRequest struct {
+	CalendarID int      `json:"calendar_id,omitempty" example:"1"`
+	Date       string   `json:"date" example:"YYYY-MM-DDTHH:MM" binding:"required"`
+	End        string   `json:"end,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	Duration   string   `json:"duration,omitempty" example:"1h30m"`
+	AllDay     bool     `json:"all_day,omitempty" example:"false"`
+	TimeZone   string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	Title      string   `json:"title" example:"example string" binding:"required"`
+	Recurrence string   `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"`
+	ExDates    []string `json:"exdates,omitempty" example:"YYYY-MM-DD"`
+	Reminders  []int    `json:"reminders,omitempty" example:"15"`
+}
+
+// PatchEventRequest — частичное изменение события: отсутствующие поля не меняются.
+type PatchEventRequest struct {
+	Date       *string   `json:"date,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	End        *string   `json:"end,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	Duration   *string   `json:"duration,omitempty" example:"1h30m"`
+	AllDay     *bool     `json:"all_day,omitempty" example:"false"`
+	TimeZone   *string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	Title      *string   `json:"title,omitempty" example:"example string"`
+	Recurrence *string   `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"`
+	ExDates    *[]string `json:"exdates,omitempty" example:"YYYY-MM-DD"`
+	Reminders  *[]int    `json:"reminders,omitempty" example:"15"`
 }
 
 type CreateEventRequest struct {
-	UserID int    `json:"user_id" example:"1" binding:"required"`
-	Date   string `json:"date" example:"YYYY-MM-DD" binding:"required" format:"date"`
-	Title  string `json:"title" example:"example string" binding:"required"`
+	UserID     int      `json:"user_id,omitempty" example:"1"`
+	Date       string   `json:"date" example:"YYYY-MM-DDTHH:MM" binding:"required"`
+	End        string   `json:"end,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	Duration   string   `json:"duration,omitempty" example:"1h30m"`
+	AllDay     bool     `json:"all_day,omitempty" example:"false"`
+	TimeZone   string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	Title      string   `json:"title" example:"example string" binding:"required"`
+	Recurrence string   `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"`
+	ExDates    []string `json:"exdates,omitempty" example:"YYYY-MM-DD"`
+	Reminders  []int    `json:"reminders,omitempty" example:"15"`
 }
 
 type UpdateEventRequest struct {
-	EventID int    `json:"event_id" example:"1" binding:"required"`
-	UserID  int    `json:"user_id" example:"1" binding:"required"`
-	Date    string `json:"date" example:"YYYY-MM-DD" binding:"required" format:"date"`
-	Title   string `json:"title" example:"example string" binding:"required"`
+	EventID        int      `json:"event_id" example:"1" binding:"required"`
+	UserID         int      `json:"user_id,omitempty" example:"1"`
+	Date           string   `json:"date" example:"YYYY-MM-DDTHH:MM" binding:"required"`
+	End            string   `json:"end,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	Duration       string   `json:"duration,omitempty" example:"1h30m"`
+	AllDay         bool     `json:"all_day,omitempty" example:"false"`
+	TimeZone       string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	Title          string   `json:"title" example:"example string" binding:"required"`
+	Recurrence     string   `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"`
+	ExDates        []string `json:"exdates,omitempty" example:"YYYY-MM-DD"`
+	Reminders      []int    `json:"reminders,omitempty" example:"15"`
+	OccurrenceDate string   `json:"occurrence_date,omitempty" example:"YYYY-MM-DD" format:"date"`
 }
 
 type DeleteEventRequest struct {
-	EventID int `json:"event_id" example:"1" binding:"required"`
-	UserID  int `json:"user_id" example:"1" binding:"required"`
+	EventID        int    `json:"event_id" example:"1" binding:"required"`
+	UserID         int    `json:"user_id,omitempty" example:"1"`
+	OccurrenceDate string `json:"occurrence_date,omitempty" example:"YYYY-MM-DD" format:"date"`
+}
+
+// ReminderKey однозначно определяет напоминание: событие (для серии — её ID),
+// начало вхождения и за сколько минут до него оно срабатывает.
+type ReminderKey struct {
+	EventID       int
+	Occurrence    time.Time
+	MinutesBefore int
+}
+
+// DueReminder — напоминание, время срабатывания которого наступило.
+type DueReminder struct {
+	ReminderKey
+	Event  Event
+	FireAt time.Time
 }
 
 type EventsResponse struct {
 	Events []Event `json:"events"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// PageMeta описывает страницу выборки: next_cursor передается в cursor
+// следующего запроса и отсутствует на последней странице.
+type PageMeta struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type EventsPageResponse struct {
+	Events []Event  `json:"events"`
+	Meta   PageMeta `json:"meta"`
+}
+
+// Interval — отрезок времени [Start, End).
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// FreeBusyResponse — общая занятость участников за интервал: busy объединяет
+// их события, free — общие свободные отрезки в рабочие часы, slots — самые
+// ранние окна запрошенной длины.
+type FreeBusyResponse struct {
+	From  time.Time  `json:"from"`
+	To    time.Time  `json:"to"`
+	Busy  []Interval `json:"busy"`
+	Free  []Interval `json:"free"`
+	Slots []Interval `json:"slots"`
+}
+
+type ImportResult struct {
+	Index   int    `json:"index"`
+	UID     string `json:"uid,omitempty"`
+	Status  string `json:"status" enums:"created,updated,failed"`
+	EventID int    `json:"event_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ImportResponse struct {
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Failed  int            `json:"failed"`
+	Items   []ImportResult `json:"items"`
+}
+
+// Режимы пакетного запроса: atomic применяет все операции или ни одной,
+// best_effort применяет каждую операцию независимо от остальных.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+// Операции пакетного запроса.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation — одна операция пакетного запроса. Событие для create и
+// update задаётся теми же полями, что и в EventRequest, update заменяет событие целиком.
+// Version — ожидаемая версия изменяемого или удаляемого события, 0 отключает проверку.
+type BatchOperation struct {
+	Op         string   `json:"op" enums:"create,update,delete" binding:"required"`
+	EventID    int      `json:"event_id,omitempty" example:"1"`
+	Version    int      `json:"version,omitempty" example:"1"`
+	CalendarID int      `json:"calendar_id,omitempty" example:"1"`
+	Date       string   `json:"date,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	End        string   `json:"end,omitempty" example:"YYYY-MM-DDTHH:MM"`
+	Duration   string   `json:"duration,omitempty" example:"1h30m"`
+	AllDay     bool     `json:"all_day,omitempty" example:"false"`
+	TimeZone   string   `json:"timezone,omitempty" example:"Europe/Moscow"`
+	Title      string   `json:"title,omitempty" example:"example string"`
+	Recurrence string   `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"`
+	ExDates    []string `json:"exdates,omitempty" example:"YYYY-MM-DD"`
+	Reminders  []int    `json:"reminders,omitempty" example:"15"`
+}
+
+// EventRequest возвращает событие операции в виде тела одиночного запроса.
+func (op BatchOperation) EventRequest() EventRequest {
+	return EventRequest{
+		CalendarID: op.CalendarID,
+		Date:       op.Date,
+		End:        op.End,
+		Duration:   op.Duration,
+		AllDay:     op.AllDay,
+		TimeZone:   op.TimeZone,
+		Title:      op.Title,
+		Recurrence: op.Recurrence,
+		ExDates:    op.ExDates,
+		Reminders:  op.Reminders,
+	}
+}
+
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty" enums:"atomic,best_effort" default:"atomic"`
+	Operations []BatchOperation `json:"operations" binding:"required"`
+}
+
+// BatchResult — результат операции пакета. У неудачной операции Code — код
+// ошибки, как в ответе одиночного запроса, а Errors — ошибки её полей.
+type BatchResult struct {
+	Index   int                  `json:"index"`
+	Op      string               `json:"op"`
+	Status  string               `json:"status" enums:"created,updated,deleted,failed,skipped"`
+	EventID int                  `json:"event_id,omitempty"`
+	Event   *Event               `json:"event,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	Code    string               `json:"code,omitempty" example:"version_mismatch"`
+	Errors  []problem.FieldError `json:"errors,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode    string        `json:"mode"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Deleted int           `json:"deleted"`
+	Failed  int           `json:"failed"`
+	Items   []BatchResult `json:"items"`
+}
+
+// BatchErrorResponse — ответ на отклонённый атомарный пакет: ни одна операция
+// не применена, у неудачных операций указана причина.
+type BatchErrorResponse struct {
+	problem.Problem
+	Result BatchResponse `json:"result"`
+}
+
+// BatchOp — операция пакета для хранилища: создание Event, замена события
+// Event.ID или его удаление. Event.Version — ожидаемая версия, 0 отключает проверку.
+type BatchOp struct {
+	Kind  string
+	Event Event
+}
+
+// Виды изменений в потоке изменений событий.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change — изменение события в потоке изменений. ID назначает шина изменений
+// по порядку публикации; у удалений Event не передаётся. UserID — владелец
+// события, Viewers — пользователи, которым оно видно через общий календарь
+// или приглашение.
+type Change struct {
+	ID      uint64    `json:"id"`
+	Type    string    `json:"type" enums:"created,updated,deleted"`
+	UserID  int       `json:"user_id"`
+	EventID int       `json:"event_id"`
+	Event   *Event    `json:"event,omitempty"`
+	Time    time.Time `json:"time"`
+	Viewers []int     `json:"-"`
+}
+
+// VisibleTo сообщает, видно ли изменённое событие пользователю userID.
+func (c Change) VisibleTo(userID int) bool {
+	return c.UserID == userID || slices.Contains(c.Viewers, userID)
+}
+
+// Webhook — подписка на изменения событий пользователя. Events — типы
+// изменений (created, updated, deleted), пустой список означает все.
+// Secret подписывает доставки и возвращается только при создании подписки.
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookRequest — тело запроса на создание подписки; если secret не задан,
+// он генерируется.
+type WebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/hooks/calendar" binding:"required"`
+	Secret string   `json:"secret,omitempty" example:"s3cr3t"`
+	Events []string `json:"events,omitempty" enums:"created,updated,deleted"`
+}
+
+// Роли пользователя в календаре: владелец управляет календарём и доступом
+// к нему, редактор меняет события, читатель только видит их.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Calendar — именованный календарь пользователя UserID, которому принадлежат
+// события. Role — роль пользователя, запросившего календарь.
+type Calendar struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role" enums:"owner,editor,viewer"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CanEdit сообщает, может ли пользователь с ролью Role менять события календаря.
+func (c Calendar) CanEdit() bool {
+	return c.Role == RoleOwner || c.Role == RoleEditor
+}
+
+// CalendarShare — доступ пользователя UserID к чужому календарю.
+type CalendarShare struct {
+	CalendarID int       `json:"calendar_id"`
+	UserID     int       `json:"user_id"`
+	Role       string    `json:"role" enums:"viewer,editor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CalendarRequest struct {
+	Name string `json:"name" example:"work" binding:"required"`
+}
+
+type ShareRequest struct {
+	Role string `json:"role" enums:"viewer,editor" binding:"required"`
+}
+
+// Действия в истории изменений: виды изменений и восстановление прежней версии.
+const (
+	HistoryCreated  = ChangeCreated
+	HistoryUpdated  = ChangeUpdated
+	HistoryDeleted  = ChangeDeleted
+	HistoryRestored = "restored"
+)
+
+// HistoryEntry — запись истории изменений события владельца UserID. Old и New —
+// событие до и после изменения: у создания нет Old, у удаления — New.
+// ActorID — пользователь, выполнивший изменение (0 — вне запроса пользователя).
+type HistoryEntry struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	EventID   int       `json:"event_id"`
+	Action    string    `json:"action" enums:"created,updated,deleted,restored"`
+	ActorID   int       `json:"actor_id"`
+	RequestID string    `json:"request_id,omitempty"`
+	Old       *Event    `json:"old,omitempty"`
+	New       *Event    `json:"new,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Snapshot возвращает состояние события после изменения, а для удаления — до него.
+func (h HistoryEntry) Snapshot() Event {
+	if h.New != nil {
+		return *h.New
+	}
+	if h.Old != nil {
+		return *h.Old
+	}
+	return Event{}
+}
+
+// HistoryQuery выбирает записи истории пользователя UserID от новых к старым:
+// только события EventID, если он не нулевой, и только с ID меньше BeforeID,
+// если он не нулевой. Нулевой Limit снимает ограничение.
+type HistoryQuery struct {
+	UserID   int
+	EventID  int
+	BeforeID int
+	Limit    int
+}
+
+// HistoryResponse — страница истории; next_cursor передается в cursor
+// следующего запроса и отсутствует на последней странице.
+type HistoryResponse struct {
+	Entries    []HistoryEntry `json:"entries"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// RestoreRequest — версия, к которой вернуть событие; без версии удалённое
+// событие восстанавливается в состоянии перед удалением.
+type RestoreRequest struct {
+	Version int `json:"version,omitempty" example:"2"`
+}
+
+// TrashedEvent — удалённое событие в корзине и время удаления.
+type TrashedEvent struct {
+	Event
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashResponse struct {
+	Events []TrashedEvent `json:"events"`
+}
+
+// Ответы участника на приглашение; needs_action — ответа ещё нет.
+const (
+	RSVPNeedsAction = "needs_action"
+	RSVPAccepted    = "accepted"
+	RSVPDeclined    = "declined"
+	RSVPTentative   = "tentative"
+)
+
+// Attendee — приглашённый на событие EventID организатора OrganizerID
+// пользователь UserID и его ответ. RespondedAt пуст, пока ответа нет.
+type Attendee struct {
+	EventID     int        `json:"event_id"`
+	OrganizerID int        `json:"organizer_id"`
+	UserID      int        `json:"user_id"`
+	Status      string     `json:"status" enums:"needs_action,accepted,declined,tentative"`
+	InvitedAt   time.Time  `json:"invited_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// RSVPSummary — число участников с каждым ответом.
+type RSVPSummary struct {
+	Accepted    int `json:"accepted"`
+	Declined    int `json:"declined"`
+	Tentative   int `json:"tentative"`
+	NeedsAction int `json:"needs_action"`
+}
+
+type AttendeesResponse struct {
+	Attendees []Attendee  `json:"attendees"`
+	Summary   RSVPSummary `json:"summary"`
+}
+
+type RSVPRequest struct {
+	Status string `json:"status" enums:"accepted,declined,tentative" binding:"required"`
+}
+
+// Invitation — приглашение пользователя на событие и его ответ.
+type Invitation struct {
+	Event       Event      `json:"event"`
+	Status      string     `json:"status" enums:"needs_action,accepted,declined,tentative"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+type InvitationsResponse struct {
+	Invitations []Invitation `json:"invitations"`
+}
+
+// HealthResponse — ответ проверок живости и готовности; Checks — результаты
+// проверок зависимостей, ok или текст ошибки.
+type HealthResponse struct {
+	Status string            `json:"status" enums:"ok,ready,not_ready"`
+	Checks map[string]string `json:"checks,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 )
@@ -10,141 +12,1119 @@ import (
 var (
 	ErrEventNotFound    = errors.New("event not found")
 	ErrInvalidDataInput = errors.New("invalid data input")
+	ErrEventExists      = errors.New("event with this uid already exists")
+	ErrVersionMismatch  = errors.New("event was modified by another request")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrCalendarNotFound = errors.New("calendar not found")
+	ErrShareNotFound    = errors.New("calendar is not shared with this user")
+	ErrAttendeeNotFound = errors.New("user is not invited to this event")
 )
 
+// EventRepository хранит события в памяти. События лежат в карте по ID,
+// а для каждого пользователя ведётся индекс, упорядоченный по началу события,
+// поэтому выборки за период просматривают только события рядом с периодом.
 type EventRepository struct {
-	mu     sync.RWMutex
-	events []Event
-	nextID int
-	log    *slog.Logger
+	mu            sync.RWMutex
+	events        map[int]Event
+	users         map[int]*userIndex
+	withReminders map[int]struct{}
+	reminders     map[reminderKey]struct{}
+	nextID        int
+	log           *slog.Logger
+
+	webhooks      map[int]Webhook
+	nextWebhookID int
+
+	calendars      map[int]Calendar
+	shares         map[int]map[int]CalendarShare
+	nextCalendarID int
+
+	history   []HistoryEntry
+	trash     map[int]TrashedEvent
+	attendees map[int]map[int]Attendee
+}
+
+// userIndex — события одного пользователя. byDate содержит все события,
+// series — только серии, оба упорядочены по (Date, ID). maxDuration — самая
+// большая длительность события пользователя, нужна, чтобы найти события,
+// начавшиеся до периода и ещё идущие в нём. durations считает события каждой
+// длительности, чтобы после удаления самого длинного события пересчитать
+// maxDuration по различным длительностям, а не по всем событиям.
+type userIndex struct {
+	byDate      []dateKey
+	series      []dateKey
+	byUID       map[string]int
+	durations   map[time.Duration]int
+	maxDuration time.Duration
+}
+
+type dateKey struct {
+	date int64
+	id   int
+}
+
+// reminderKey — ReminderKey, пригодный для ключа карты: time.Time с разными
+// часовыми поясами сравнивается через ==, поэтому время хранится в наносекундах.
+type reminderKey struct {
+	eventID       int
+	occurrence    int64
+	minutesBefore int
 }
 
 func NewEventRepository(logger *slog.Logger) *EventRepository {
 	return &EventRepository{
-		events: make([]Event, 20),
-		nextID: 1,
-		log:    logger,
+		events:        make(map[int]Event),
+		users:         make(map[int]*userIndex),
+		withReminders: make(map[int]struct{}),
+		reminders:     make(map[reminderKey]struct{}),
+		nextID:        1,
+		log:           logger,
+		webhooks:      make(map[int]Webhook),
+		nextWebhookID: 1,
+
+		calendars:      make(map[int]Calendar),
+		shares:         make(map[int]map[int]CalendarShare),
+		nextCalendarID: 1,
+
+		trash:     make(map[int]TrashedEvent),
+		attendees: make(map[int]map[int]Attendee),
 	}
 }
 
-func (er *EventRepository) CreateEvent(userID int, date time.Time, title string) (Event, error) {
+func (er *EventRepository) CreateEvent(ctx context.Context, event Event) (Event, error) {
 	er.mu.Lock()
 	defer er.mu.Unlock()
 
-	now := time.Now()
-	event := Event{
-		ID:        er.nextID,
-		UserID:    userID,
-		Date:      date,
-		Title:     title,
-		CreatedAt: now,
-		UpdatedAt: now,
+	event, err := er.create(event)
+	if err != nil {
+		return Event{}, err
 	}
-
-	er.events = append(er.events, event)
-	er.nextID++
+	er.record(ctx, HistoryCreated, nil, &event)
 
 	er.log.Info("Event created",
 		"event_id", event.ID,
 		"user_id", event.UserID,
 		"date", event.Date.Format("2006-01-02"),
-		"title", title,
+		"title", event.Title,
+		"recurrence", event.Recurrence,
 	)
 
 	return event, nil
 }
 
-func (er *EventRepository) GetEventsForDay(userID int, date time.Time) []Event {
+func (er *EventRepository) GetEvent(_ context.Context, eventID, userID int) (Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	if event, ok := er.find(eventID, userID); ok {
+		return event, nil
+	}
+	return Event{}, ErrEventNotFound
+}
+
+func (er *EventRepository) GetUserEvents(_ context.Context, userID int) ([]Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	user := er.users[userID]
+	if user == nil {
+		return nil, nil
+	}
+
+	result := make([]Event, 0, len(user.byDate))
+	for _, key := range user.byDate {
+		result = append(result, er.events[key.id])
+	}
+	return result, nil
+}
+
+func (er *EventRepository) GetEventsForDay(_ context.Context, userID int, date time.Time) ([]Event, error) {
+	from, to := DayRange(date)
+	return er.eventsBetween(userID, from, to), nil
+}
+
+func (er *EventRepository) GetEventsForWeek(_ context.Context, userID int, date time.Time) ([]Event, error) {
+	from, to := WeekRange(date)
+	return er.eventsBetween(userID, from, to), nil
+}
+
+func (er *EventRepository) GetEventsForMonth(_ context.Context, userID int, date time.Time) ([]Event, error) {
+	from, to := MonthRange(date)
+	return er.eventsBetween(userID, from, to), nil
+}
+
+func (er *EventRepository) GetEventsBetween(_ context.Context, userID int, from, to time.Time) ([]Event, error) {
+	return er.eventsBetween(userID, from, to), nil
+}
+
+func (er *EventRepository) GetRecurringEvents(_ context.Context, userID int, before time.Time) ([]Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	user := er.users[userID]
+	if user == nil {
+		return nil, nil
+	}
+
+	var result []Event
+	end := searchDate(user.series, before.UnixNano())
+	for _, key := range user.series[:end] {
+		result = append(result, er.events[key.id])
+	}
+	return result, nil
+}
+
+func (er *EventRepository) UpdateEvent(ctx context.Context, event Event) (Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	current, updated, err := er.update(event)
+	if err != nil {
+		return Event{}, err
+	}
+	er.record(ctx, HistoryUpdated, &current, &updated)
+
+	er.log.Info("Event updated",
+		"event_id", event.ID,
+		"user_id", event.UserID,
+		"new_title", event.Title,
+		"new_date", event.Date.Format("2006-01-02"),
+		"recurrence", event.Recurrence,
+	)
+
+	return updated, nil
+}
+
+func (er *EventRepository) DeleteEvent(ctx context.Context, eventID, userID, version int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	removed, err := er.delete(eventID, userID, version)
+	if err != nil {
+		return err
+	}
+	for _, event := range removed {
+		er.record(ctx, HistoryDeleted, &event, nil)
+	}
+
+	er.log.Info("Event deleted",
+		"event_id", eventID,
+		"user_id", userID,
+	)
+
+	return nil
+}
+
+func (er *EventRepository) DeleteOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, version int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	series, ok := er.findSeries(eventID, userID)
+	if !ok {
+		return ErrEventNotFound
+	}
+	if err := checkVersion(series, version); err != nil {
+		return err
+	}
+
+	if updated, changed := er.excludeOccurrence(series, occurrence); changed {
+		er.record(ctx, HistoryUpdated, &series, &updated)
+	}
+
+	er.log.Info("Event occurrence deleted",
+		"event_id", eventID,
+		"user_id", userID,
+		"occurrence", occurrence.Format("2006-01-02"),
+	)
+
+	return nil
+}
+
+func (er *EventRepository) ReplaceOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, override Event, version int) (Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	series, ok := er.findSeries(eventID, userID)
+	if !ok {
+		return Event{}, ErrEventNotFound
+	}
+	if err := checkVersion(series, version); err != nil {
+		return Event{}, err
+	}
+
+	if updated, changed := er.excludeOccurrence(series, occurrence); changed {
+		er.record(ctx, HistoryUpdated, &series, &updated)
+	}
+
+	override.UID = series.UID
+	override.UserID = userID
+	override.CalendarID = series.CalendarID
+	override.Recurrence = ""
+	override.ExDates = nil
+	override.RecurringEventID = eventID
+	override.OriginalDate = &occurrence
+	override = er.insert(override)
+	er.record(ctx, HistoryCreated, nil, &override)
+
+	er.log.Info("Event occurrence replaced",
+		"event_id", eventID,
+		"override_id", override.ID,
+		"user_id", userID,
+		"occurrence", occurrence.Format("2006-01-02"),
+		"new_date", override.Date.Format("2006-01-02"),
+	)
+
+	return override, nil
+}
+
+func (er *EventRepository) CountEvents(_ context.Context) (int, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	return len(er.events), nil
+}
+
+func (er *EventRepository) GetEventsWithReminders(_ context.Context, startsAfter time.Time) ([]Event, error) {
 	er.mu.RLock()
 	defer er.mu.RUnlock()
 
 	var result []Event
-	for _, event := range er.events {
-		if event.UserID == userID && sameDay(event.Date, date) {
+	for id := range er.withReminders {
+		event := er.events[id]
+		if event.IsRecurring() || !event.Date.Before(startsAfter) {
 			result = append(result, event)
 		}
 	}
-	return result
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) ClaimReminder(_ context.Context, key ReminderKey) (bool, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	k := newReminderKey(key)
+	if _, ok := er.reminders[k]; ok {
+		return false, nil
+	}
+	er.reminders[k] = struct{}{}
+	return true, nil
 }
 
-func (er *EventRepository) GetEventsForWeek(userID int, date time.Time) []Event {
+func (er *EventRepository) ReleaseReminder(_ context.Context, key ReminderKey) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	delete(er.reminders, newReminderKey(key))
+	return nil
+}
+
+func (er *EventRepository) CreateWebhook(_ context.Context, hook Webhook) (Webhook, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	hook.ID = er.nextWebhookID
+	hook.CreatedAt = time.Now()
+	er.webhooks[hook.ID] = hook
+	er.nextWebhookID++
+
+	return hook, nil
+}
+
+func (er *EventRepository) GetWebhook(_ context.Context, webhookID, userID int) (Webhook, error) {
 	er.mu.RLock()
 	defer er.mu.RUnlock()
 
-	var result []Event
-	year, week := date.ISOWeek()
-	for _, event := range er.events {
-		if event.UserID == userID {
-			eventYear, eventWeek := event.Date.ISOWeek()
-			if eventYear == year && eventWeek == week {
-				result = append(result, event)
-			}
+	hook, ok := er.webhooks[webhookID]
+	if !ok || hook.UserID != userID {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return hook, nil
+}
+
+func (er *EventRepository) ListWebhooks(_ context.Context, userID int) ([]Webhook, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	var result []Webhook
+	for _, hook := range er.webhooks {
+		if hook.UserID == userID {
+			result = append(result, hook)
 		}
 	}
-	return result
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) DeleteWebhook(_ context.Context, webhookID, userID int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if hook, ok := er.webhooks[webhookID]; !ok || hook.UserID != userID {
+		return ErrWebhookNotFound
+	}
+	delete(er.webhooks, webhookID)
+	return nil
 }
 
-func (er *EventRepository) GetEventsForMonth(userID int, date time.Time) []Event {
+func (er *EventRepository) CreateCalendar(_ context.Context, cal Calendar) (Calendar, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	cal.ID = er.nextCalendarID
+	cal.Role = ""
+	cal.CreatedAt = time.Now()
+	er.calendars[cal.ID] = cal
+	er.nextCalendarID++
+
+	cal.Role = RoleOwner
+	return cal, nil
+}
+
+func (er *EventRepository) GetCalendar(_ context.Context, calendarID, userID int) (Calendar, error) {
 	er.mu.RLock()
 	defer er.mu.RUnlock()
 
-	var result []Event
-	year := date.Year()
-	month := date.Month()
-	for _, event := range er.events {
-		if event.UserID == userID {
-			eventYear := event.Date.Year()
-			eventMonth := event.Date.Month()
-			if eventYear == year && eventMonth == month {
-				result = append(result, event)
+	cal, ok := er.calendars[calendarID]
+	if !ok {
+		return Calendar{}, ErrCalendarNotFound
+	}
+	if cal, ok = er.withRole(cal, userID); !ok {
+		return Calendar{}, ErrCalendarNotFound
+	}
+	return cal, nil
+}
+
+func (er *EventRepository) ListCalendars(_ context.Context, userID int) ([]Calendar, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	var result []Calendar
+	for _, cal := range er.calendars {
+		if cal, ok := er.withRole(cal, userID); ok {
+			result = append(result, cal)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) UpdateCalendar(_ context.Context, cal Calendar) (Calendar, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	current, ok := er.calendars[cal.ID]
+	if !ok || current.UserID != cal.UserID {
+		return Calendar{}, ErrCalendarNotFound
+	}
+	current.Name = cal.Name
+	er.calendars[cal.ID] = current
+
+	current.Role = RoleOwner
+	return current, nil
+}
+
+func (er *EventRepository) DeleteCalendar(ctx context.Context, calendarID, userID int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	cal, ok := er.calendars[calendarID]
+	if !ok || cal.UserID != userID {
+		return ErrCalendarNotFound
+	}
+
+	if user := er.users[userID]; user != nil {
+		var removed []Event
+		for _, key := range user.byDate {
+			if event := er.events[key.id]; event.CalendarID == calendarID {
+				removed = append(removed, event)
 			}
 		}
+		er.discard(removed)
+		for _, event := range removed {
+			er.record(ctx, HistoryDeleted, &event, nil)
+		}
 	}
-	return result
+	delete(er.shares, calendarID)
+	delete(er.calendars, calendarID)
+
+	er.log.Info("Calendar deleted",
+		"calendar_id", calendarID,
+		"user_id", userID,
+	)
+
+	return nil
+}
+
+func (er *EventRepository) ShareCalendar(_ context.Context, share CalendarShare) (CalendarShare, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if _, ok := er.calendars[share.CalendarID]; !ok {
+		return CalendarShare{}, ErrCalendarNotFound
+	}
+
+	shares := er.shares[share.CalendarID]
+	if shares == nil {
+		shares = make(map[int]CalendarShare)
+		er.shares[share.CalendarID] = shares
+	}
+	if current, ok := shares[share.UserID]; ok {
+		share.CreatedAt = current.CreatedAt
+	} else {
+		share.CreatedAt = time.Now()
+	}
+	shares[share.UserID] = share
+
+	return share, nil
 }
 
-func (er *EventRepository) UpdateEvent(eventID, userID int, date time.Time, title string) (Event, error) {
+func (er *EventRepository) UnshareCalendar(_ context.Context, calendarID, userID int) error {
 	er.mu.Lock()
 	defer er.mu.Unlock()
 
-	for i, event := range er.events {
-		if event.ID == eventID && event.UserID == userID {
-			er.events[i].Date = date
-			er.events[i].Title = title
-			er.events[i].UpdatedAt = time.Now()
+	if _, ok := er.shares[calendarID][userID]; !ok {
+		return ErrShareNotFound
+	}
+	delete(er.shares[calendarID], userID)
+	return nil
+}
+
+func (er *EventRepository) ListCalendarShares(_ context.Context, calendarID int) ([]CalendarShare, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
 
-			er.log.Info("Event updated",
-				"event_id", eventID,
-				"user_id", userID,
-				"new_title", title,
-				"new_date", date.Format("2006-01-02"),
-			)
+	if _, ok := er.calendars[calendarID]; !ok {
+		return nil, ErrCalendarNotFound
+	}
 
-			return er.events[i], nil
+	var result []CalendarShare
+	for _, share := range er.shares[calendarID] {
+		result = append(result, share)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) RestoreEvent(ctx context.Context, event Event) (Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if _, ok := er.find(event.ID, event.UserID); ok {
+		current, restored, err := er.update(event)
+		if err != nil {
+			return Event{}, err
 		}
+		er.record(ctx, HistoryRestored, &current, &restored)
+		return restored, nil
 	}
 
-	return Event{}, ErrEventNotFound
+	if _, ok := er.events[event.ID]; ok {
+		return Event{}, ErrEventExists
+	}
+	if _, ok := er.findByUID(event.UserID, event.UID); ok && event.RecurringEventID == 0 {
+		return Event{}, ErrEventExists
+	}
+	if event.RecurringEventID != 0 {
+		if _, ok := er.findSeries(event.RecurringEventID, event.UserID); !ok {
+			return Event{}, ErrEventNotFound
+		}
+	}
+
+	event.Version++
+	event.UpdatedAt = time.Now()
+	er.events[event.ID] = event
+	er.index(event)
+	er.nextID = max(er.nextID, event.ID+1)
+	delete(er.trash, event.ID)
+	er.record(ctx, HistoryRestored, nil, &event)
+
+	er.log.Info("Event restored",
+		"event_id", event.ID,
+		"user_id", event.UserID,
+	)
+
+	return event, nil
 }
 
-func (er *EventRepository) DeleteEvent(eventID, userID int) error {
+func (er *EventRepository) AddHistory(_ context.Context, entry HistoryEntry) (HistoryEntry, error) {
 	er.mu.Lock()
 	defer er.mu.Unlock()
 
-	for i, event := range er.events {
-		if event.ID == eventID && event.UserID == userID {
-			er.events = append(er.events[:i], er.events[i+1:]...)
+	return er.addHistory(entry), nil
+}
+
+func (er *EventRepository) ListHistory(_ context.Context, query HistoryQuery) ([]HistoryEntry, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
 
-			er.log.Info("Event deleted",
-				"event_id", eventID,
-				"user_id", userID,
-			)
+	end := len(er.history)
+	if query.BeforeID > 0 {
+		end = min(end, query.BeforeID-1)
+	}
 
-			return nil
+	var result []HistoryEntry
+	for i := end - 1; i >= 0; i-- {
+		entry := er.history[i]
+		if entry.UserID != query.UserID || (query.EventID != 0 && entry.EventID != query.EventID) {
+			continue
+		}
+		result = append(result, entry)
+		if len(result) == query.Limit {
+			break
 		}
 	}
 
-	return ErrEventNotFound
+	return result, nil
+}
+
+func (er *EventRepository) ListTrash(_ context.Context, userID int) ([]TrashedEvent, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	var result []TrashedEvent
+	for _, trashed := range er.trash {
+		if trashed.UserID == userID {
+			result = append(result, trashed)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(result[j].DeletedAt) {
+			return result[i].DeletedAt.After(result[j].DeletedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) GetTrashedEvent(_ context.Context, eventID, userID int) (TrashedEvent, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	trashed, ok := er.trash[eventID]
+	if !ok || trashed.UserID != userID {
+		return TrashedEvent{}, ErrEventNotFound
+	}
+	return trashed, nil
+}
+
+func (er *EventRepository) PurgeTrash(_ context.Context, before time.Time) (int, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	purged := make(map[int]struct{})
+	for id, trashed := range er.trash {
+		if trashed.DeletedAt.Before(before) {
+			delete(er.trash, id)
+			delete(er.attendees, id)
+			purged[id] = struct{}{}
+		}
+	}
+	for key := range er.reminders {
+		if _, ok := purged[key.eventID]; ok {
+			delete(er.reminders, key)
+		}
+	}
+
+	if len(purged) > 0 {
+		er.log.Info("Trash purged",
+			"events", len(purged),
+		)
+	}
+
+	return len(purged), nil
+}
+
+func (er *EventRepository) AddAttendee(_ context.Context, attendee Attendee) (Attendee, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if _, ok := er.find(attendee.EventID, attendee.OrganizerID); !ok {
+		return Attendee{}, ErrEventNotFound
+	}
+
+	attendees := er.attendees[attendee.EventID]
+	if attendees == nil {
+		attendees = make(map[int]Attendee)
+		er.attendees[attendee.EventID] = attendees
+	}
+	if current, ok := attendees[attendee.UserID]; ok {
+		return current, nil
+	}
+
+	attendee.Status = RSVPNeedsAction
+	attendee.InvitedAt = time.Now()
+	attendee.RespondedAt = nil
+	attendees[attendee.UserID] = attendee
+
+	return attendee, nil
+}
+
+func (er *EventRepository) RemoveAttendee(_ context.Context, eventID, userID int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if _, ok := er.attendees[eventID][userID]; !ok {
+		return ErrAttendeeNotFound
+	}
+	delete(er.attendees[eventID], userID)
+	return nil
+}
+
+func (er *EventRepository) ListAttendees(_ context.Context, eventID int) ([]Attendee, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	var result []Attendee
+	for _, attendee := range er.attendees[eventID] {
+		result = append(result, attendee)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) SetAttendeeStatus(_ context.Context, eventID, userID int, status string) (Attendee, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	attendee, ok := er.attendees[eventID][userID]
+	if !ok {
+		return Attendee{}, ErrAttendeeNotFound
+	}
+
+	now := time.Now()
+	attendee.Status = status
+	attendee.RespondedAt = &now
+	er.attendees[eventID][userID] = attendee
+
+	return attendee, nil
+}
+
+func (er *EventRepository) ResetAttendees(_ context.Context, eventID int) error {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	for userID, attendee := range er.attendees[eventID] {
+		attendee.Status = RSVPNeedsAction
+		attendee.RespondedAt = nil
+		er.attendees[eventID][userID] = attendee
+	}
+	return nil
+}
+
+func (er *EventRepository) ListInvitations(_ context.Context, userID int) ([]Attendee, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	var result []Attendee
+	for _, attendees := range er.attendees {
+		if attendee, ok := attendees[userID]; ok {
+			result = append(result, attendee)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EventID < result[j].EventID
+	})
+	return result, nil
+}
+
+func (er *EventRepository) Ping(_ context.Context) error {
+	return nil
+}
+
+func (er *EventRepository) Close() error {
+	return nil
+}
+
+// eventsBetween выбирает отдельные события, пересекающиеся с [from, to).
+// События на весь день сравниваются с календарными датами границ, поэтому
+// окно поиска расширяется на обе версии границ и на самую длинную длительность.
+func (er *EventRepository) eventsBetween(userID int, from, to time.Time) []Event {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	user := er.users[userID]
+	if user == nil {
+		return nil
+	}
+
+	lower := minTime(from, FloatingDate(from)).Add(-user.maxDuration)
+	upper := maxTime(to, FloatingDate(to))
+
+	var result []Event
+	start := searchDate(user.byDate, lower.UnixNano())
+	end := searchDate(user.byDate, upper.UnixNano())
+	for _, key := range user.byDate[start:end] {
+		event := er.events[key.id]
+		if !event.IsRecurring() && event.Overlaps(from, to) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// ApplyBatch применяет операции по порядку под одной блокировкой; если
+// операция не удалась, уже применённые отменяются в обратном порядке.
+func (er *EventRepository) ApplyBatch(ctx context.Context, ops []BatchOp) ([]Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	results := make([]Event, 0, len(ops))
+	undo := make([]func(), 0, len(ops))
+	var history []HistoryEntry
+	for i, op := range ops {
+		result, entries, revert, err := er.apply(ctx, op)
+		if err != nil {
+			for j := len(undo) - 1; j >= 0; j-- {
+				undo[j]()
+			}
+			return nil, &BatchError{Index: i, Err: err}
+		}
+		results = append(results, result)
+		undo = append(undo, revert)
+		history = append(history, entries...)
+	}
+	for _, entry := range history {
+		er.addHistory(entry)
+	}
+
+	er.log.Info("Event batch applied",
+		"operations", len(ops),
+	)
+
+	return results, nil
+}
+
+// apply выполняет операцию пакета и возвращает записи истории о ней и функцию,
+// которая её отменяет. Записи попадают в историю, только если применился весь пакет.
+func (er *EventRepository) apply(ctx context.Context, op BatchOp) (Event, []HistoryEntry, func(), error) {
+	switch op.Kind {
+	case BatchCreate:
+		created, err := er.create(op.Event)
+		if err != nil {
+			return Event{}, nil, nil, err
+		}
+		entries := []HistoryEntry{newHistoryEntry(ctx, HistoryCreated, nil, &created)}
+		return created, entries, func() { er.remove(created) }, nil
+	case BatchUpdate:
+		current, updated, err := er.update(op.Event)
+		if err != nil {
+			return Event{}, nil, nil, err
+		}
+		entries := []HistoryEntry{newHistoryEntry(ctx, HistoryUpdated, &current, &updated)}
+		return updated, entries, func() { er.replace(updated, current) }, nil
+	case BatchDelete:
+		removed, err := er.delete(op.Event.ID, op.Event.UserID, op.Event.Version)
+		if err != nil {
+			return Event{}, nil, nil, err
+		}
+		entries := make([]HistoryEntry, len(removed))
+		for i := range removed {
+			entries[i] = newHistoryEntry(ctx, HistoryDeleted, &removed[i], nil)
+		}
+		return removed[0], entries, func() {
+			for _, event := range removed {
+				delete(er.trash, event.ID)
+				er.events[event.ID] = event
+				er.index(event)
+			}
+		}, nil
+	}
+	return Event{}, nil, nil, unknownBatchOp(op.Kind)
+}
+
+func (er *EventRepository) create(event Event) (Event, error) {
+	if event.UID != "" {
+		if _, ok := er.findByUID(event.UserID, event.UID); ok {
+			return Event{}, ErrEventExists
+		}
+	}
+
+	return er.insert(event), nil
+}
+
+// update заменяет событие event.ID и возвращает его прежнюю и новую версии.
+func (er *EventRepository) update(event Event) (Event, Event, error) {
+	current, ok := er.find(event.ID, event.UserID)
+	if !ok {
+		return Event{}, Event{}, ErrEventNotFound
+	}
+	if err := checkVersion(current, event.Version); err != nil {
+		return Event{}, Event{}, err
+	}
+
+	updated := current
+	updated.Version++
+	updated.Date = event.Date
+	updated.End = event.End
+	updated.AllDay = event.AllDay
+	updated.TimeZone = event.TimeZone
+	updated.Title = event.Title
+	updated.Recurrence = event.Recurrence
+	updated.ExDates = event.ExDates
+	updated.Reminders = event.Reminders
+	updated.UpdatedAt = time.Now()
+	er.replace(current, updated)
+
+	return current, updated, nil
+}
+
+// delete удаляет событие вместе с заменами вхождений, если это серия, и
+// возвращает удалённые события: первым — само событие.
+func (er *EventRepository) delete(eventID, userID, version int) ([]Event, error) {
+	event, ok := er.find(eventID, userID)
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	if err := checkVersion(event, version); err != nil {
+		return nil, err
+	}
+
+	removed := []Event{event}
+	if event.IsRecurring() {
+		for _, key := range er.users[userID].byDate {
+			if override := er.events[key.id]; override.RecurringEventID == eventID {
+				removed = append(removed, override)
+			}
+		}
+	}
+	er.discard(removed)
+
+	return removed, nil
+}
+
+func (er *EventRepository) insert(event Event) Event {
+	now := time.Now()
+	if event.UID == "" {
+		event.UID = newUID()
+	}
+	event.ID = er.nextID
+	event.Version = 1
+	event.CreatedAt = now
+	event.UpdatedAt = now
+
+	er.events[event.ID] = event
+	er.index(event)
+	er.nextID++
+
+	return event
+}
+
+func (er *EventRepository) replace(current, updated Event) {
+	er.unindex(current)
+	er.events[updated.ID] = updated
+	er.index(updated)
+}
+
+func (er *EventRepository) remove(event Event) {
+	er.unindex(event)
+	delete(er.events, event.ID)
+}
+
+// discard удаляет события и кладёт их в корзину.
+func (er *EventRepository) discard(events []Event) {
+	now := time.Now()
+	for _, event := range events {
+		er.remove(event)
+		er.trash[event.ID] = TrashedEvent{Event: event, DeletedAt: now}
+	}
+}
+
+func (er *EventRepository) index(event Event) {
+	user := er.users[event.UserID]
+	if user == nil {
+		user = &userIndex{byUID: make(map[string]int), durations: make(map[time.Duration]int)}
+		er.users[event.UserID] = user
+	}
+
+	key := dateKey{date: event.Date.UnixNano(), id: event.ID}
+	user.byDate = insertKey(user.byDate, key)
+	if event.IsRecurring() {
+		user.series = insertKey(user.series, key)
+	}
+	if event.RecurringEventID == 0 {
+		user.byUID[event.UID] = event.ID
+	}
+	duration := event.EndTime().Sub(event.Date)
+	user.durations[duration]++
+	user.maxDuration = max(user.maxDuration, duration)
+
+	if len(event.Reminders) > 0 {
+		er.withReminders[event.ID] = struct{}{}
+	}
+}
+
+func (er *EventRepository) unindex(event Event) {
+	user := er.users[event.UserID]
+
+	key := dateKey{date: event.Date.UnixNano(), id: event.ID}
+	user.byDate = removeKey(user.byDate, key)
+	if event.IsRecurring() {
+		user.series = removeKey(user.series, key)
+	}
+	if event.RecurringEventID == 0 && user.byUID[event.UID] == event.ID {
+		delete(user.byUID, event.UID)
+	}
+
+	duration := event.EndTime().Sub(event.Date)
+	if user.durations[duration]--; user.durations[duration] == 0 {
+		delete(user.durations, duration)
+		if duration == user.maxDuration {
+			user.maxDuration = 0
+			for d := range user.durations {
+				user.maxDuration = max(user.maxDuration, d)
+			}
+		}
+	}
+
+	delete(er.withReminders, event.ID)
+}
+
+// withRole дополняет календарь ролью пользователя userID; false — календарь
+// пользователю не виден.
+func (er *EventRepository) withRole(cal Calendar, userID int) (Calendar, bool) {
+	if cal.UserID == userID {
+		cal.Role = RoleOwner
+		return cal, true
+	}
+
+	share, ok := er.shares[cal.ID][userID]
+	if !ok {
+		return Calendar{}, false
+	}
+	cal.Role = share.Role
+	return cal, true
+}
+
+func (er *EventRepository) find(eventID, userID int) (Event, bool) {
+	event, ok := er.events[eventID]
+	if !ok || event.UserID != userID {
+		return Event{}, false
+	}
+	return event, true
+}
+
+func (er *EventRepository) findByUID(userID int, uid string) (Event, bool) {
+	user := er.users[userID]
+	if user == nil {
+		return Event{}, false
+	}
+
+	id, ok := user.byUID[uid]
+	if !ok {
+		return Event{}, false
+	}
+	return er.events[id], true
+}
+
+func (er *EventRepository) findSeries(eventID, userID int) (Event, bool) {
+	event, ok := er.find(eventID, userID)
+	if !ok || !event.IsRecurring() {
+		return Event{}, false
+	}
+	return event, true
+}
+
+// excludeOccurrence исключает вхождение из серии и возвращает изменённую серию;
+// false — вхождение уже было исключено.
+func (er *EventRepository) excludeOccurrence(series Event, occurrence time.Time) (Event, bool) {
+	if series.IsExcluded(occurrence) {
+		return series, false
+	}
+
+	exDates := make([]time.Time, 0, len(series.ExDates)+1)
+	exDates = append(exDates, series.ExDates...)
+	series.ExDates = append(exDates, occurrence)
+	series.Version++
+	series.UpdatedAt = time.Now()
+	er.events[series.ID] = series
+	return series, true
+}
+
+// record дописывает изменение события в историю; вызывается под er.mu.
+func (er *EventRepository) record(ctx context.Context, action string, before, after *Event) {
+	er.addHistory(newHistoryEntry(ctx, action, before, after))
+}
+
+func (er *EventRepository) addHistory(entry HistoryEntry) HistoryEntry {
+	entry.ID = len(er.history) + 1
+	er.history = append(er.history, entry)
+	return entry
+}
+
+// checkVersion сравнивает версию события с ожидаемой; нулевая версия не проверяется.
+func checkVersion(event Event, version int) error {
+	if version != 0 && event.Version != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+// searchDate возвращает позицию первого ключа, начинающегося не раньше date.
+func searchDate(keys []dateKey, date int64) int {
+	return sort.Search(len(keys), func(i int) bool {
+		return keys[i].date >= date
+	})
+}
+
+func insertKey(keys []dateKey, key dateKey) []dateKey {
+	i := sort.Search(len(keys), func(i int) bool {
+		return !keys[i].less(key)
+	})
+	keys = append(keys, dateKey{})
+	copy(keys[i+1:], keys[i:])
+	keys[i] = key
+	return keys
+}
+
+func removeKey(keys []dateKey, key dateKey) []dateKey {
+	i := sort.Search(len(keys), func(i int) bool {
+		return !keys[i].less(key)
+	})
+	if i == len(keys) || keys[i] != key {
+		return keys
+	}
+	return append(keys[:i], keys[i+1:]...)
+}
+
+func (k dateKey) less(other dateKey) bool {
+	if k.date != other.date {
+		return k.date < other.date
+	}
+	return k.id < other.id
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func newReminderKey(key ReminderKey) reminderKey {
+	return reminderKey{
+		eventID:       key.EventID,
+		occurrence:    key.Occurrence.UnixNano(),
+		minutesBefore: key.MinutesBefore,
+	}
 }
 
 func sameDay(time1, time2 time.Time) bool {
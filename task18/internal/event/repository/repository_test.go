@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"log/slog"
 )
 
@@ -12,12 +14,130 @@ func testLogger() *slog.Logger {
 	return slog.Default()
 }
 
-func TestEventRepository_CreateEvent(t *testing.T) {
+type storageFactory func(t *testing.T) Storage
+
+func newMemoryStorage(t *testing.T) Storage {
+	return NewEventRepository(testLogger())
+}
+
+func newSQLiteStorage(t *testing.T) Storage {
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "events.db"), testLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestEventRepository(t *testing.T) {
+	runStorageSuite(t, newMemoryStorage)
+}
+
+func TestSQLiteRepository(t *testing.T) {
+	runStorageSuite(t, newSQLiteStorage)
+}
+
+func TestEventRepository_Index(t *testing.T) {
 	repo := NewEventRepository(testLogger())
+	day := func(d int) time.Time { return time.Date(2025, 9, d, 10, 0, 0, 0, time.UTC) }
+
+	events, err := repo.GetUserEvents(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Empty(t, events, "new repository must not contain placeholder events")
+
+	long, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: day(1), End: day(20), Title: "Conference"})
+	require.NoError(t, err)
+	moved, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: day(15), Title: "Moved"})
+	require.NoError(t, err)
+	series, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: day(2), Title: "Series", Recurrence: "FREQ=DAILY"})
+	require.NoError(t, err)
+	override, err := repo.ReplaceOccurrence(t.Context(), series.ID, 1, day(3), Event{Date: day(3).Add(time.Hour), Title: "Override"}, 0)
+	require.NoError(t, err)
+
+	t.Run("long event started before the period", func(t *testing.T) {
+		events, err := repo.GetEventsForDay(t.Context(), 1, day(18))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, long.ID, events[0].ID)
+	})
+
+	t.Run("update moves event in the date index", func(t *testing.T) {
+		_, err := repo.UpdateEvent(t.Context(), Event{ID: moved.ID, UserID: 1, Date: day(25), Title: "Moved"})
+		require.NoError(t, err)
+
+		events, err := repo.GetEventsForDay(t.Context(), 1, day(15))
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		events, err = repo.GetEventsForDay(t.Context(), 1, day(25))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, moved.ID, events[0].ID)
+	})
+
+	t.Run("user events are ordered by date", func(t *testing.T) {
+		events, err := repo.GetUserEvents(t.Context(), 1)
+		require.NoError(t, err)
+		require.Len(t, events, 4)
+		for i := 1; i < len(events); i++ {
+			assert.False(t, events[i].Date.Before(events[i-1].Date))
+		}
+	})
+
+	t.Run("deleting series removes its overrides", func(t *testing.T) {
+		require.NoError(t, repo.DeleteEvent(t.Context(), series.ID, 1, 0))
+
+		_, err := repo.GetEvent(t.Context(), override.ID, 1)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+
+		recurring, err := repo.GetRecurringEvents(t.Context(), 1, day(30))
+		require.NoError(t, err)
+		assert.Empty(t, recurring)
+
+		_, err = repo.CreateEvent(t.Context(), Event{UserID: 1, UID: series.UID, Date: day(2), Title: "Reimported"})
+		assert.NoError(t, err, "UID of deleted series must be free")
+	})
+
+	t.Run("search window shrinks after the longest event is gone", func(t *testing.T) {
+		require.Equal(t, 19*24*time.Hour, repo.users[1].maxDuration)
+
+		_, err := repo.UpdateEvent(t.Context(), Event{ID: long.ID, UserID: 1, Date: day(1), End: day(3), Title: "Conference"})
+		require.NoError(t, err)
+		assert.Equal(t, 2*24*time.Hour, repo.users[1].maxDuration)
+
+		require.NoError(t, repo.DeleteEvent(t.Context(), long.ID, 1, 0))
+		assert.Zero(t, repo.users[1].maxDuration)
 
+		events, err := repo.GetEventsForDay(t.Context(), 1, day(2))
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+}
+
+// runStorageSuite проверяет контракт Storage, общий для всех реализаций.
+func runStorageSuite(t *testing.T, newStorage storageFactory) {
+	t.Run("CreateEvent", func(t *testing.T) { testStorageCreateEvent(t, newStorage(t)) })
+	t.Run("UpdateEvent", func(t *testing.T) { testStorageUpdateEvent(t, newStorage(t)) })
+	t.Run("DeleteEvent", func(t *testing.T) { testStorageDeleteEvent(t, newStorage(t)) })
+	t.Run("GetEventsForPeriod", func(t *testing.T) { testStorageGetEventsForPeriod(t, newStorage(t)) })
+	t.Run("ConcurrentAccess", func(t *testing.T) { testStorageConcurrentAccess(t, newStorage(t)) })
+	t.Run("RecurringEvents", func(t *testing.T) { testStorageRecurringEvents(t, newStorage(t)) })
+	t.Run("UIDs", func(t *testing.T) { testStorageUIDs(t, newStorage(t)) })
+	t.Run("EventTime", func(t *testing.T) { testStorageEventTime(t, newStorage(t)) })
+	t.Run("Reminders", func(t *testing.T) { testStorageReminders(t, newStorage(t)) })
+	t.Run("Versions", func(t *testing.T) { testStorageVersions(t, newStorage(t)) })
+	t.Run("Batch", func(t *testing.T) { testStorageBatch(t, newStorage(t)) })
+	t.Run("Webhooks", func(t *testing.T) { testStorageWebhooks(t, newStorage(t)) })
+	t.Run("Calendars", func(t *testing.T) { testStorageCalendars(t, newStorage(t)) })
+	t.Run("History", func(t *testing.T) { testStorageHistory(t, newStorage(t)) })
+	t.Run("HistoryRecording", func(t *testing.T) { testStorageHistoryRecording(t, newStorage(t)) })
+	t.Run("RestoreEvent", func(t *testing.T) { testStorageRestoreEvent(t, newStorage(t)) })
+	t.Run("Trash", func(t *testing.T) { testStorageTrash(t, newStorage(t)) })
+	t.Run("Attendees", func(t *testing.T) { testStorageAttendees(t, newStorage(t)) })
+}
+
+func testStorageCreateEvent(t *testing.T, repo Storage) {
 	t.Run("successful event creation", func(t *testing.T) {
 		date := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
-		event, err := repo.CreateEvent(1, date, "New Party")
+		event, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "New Party"})
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, event.ID)
@@ -31,8 +151,8 @@ func TestEventRepository_CreateEvent(t *testing.T) {
 	t.Run("events have incremental IDs", func(t *testing.T) {
 		date := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
 
-		event1, err1 := repo.CreateEvent(1, date, "Event 1")
-		event2, err2 := repo.CreateEvent(2, date, "Event 2")
+		event1, err1 := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Event 1"})
+		event2, err2 := repo.CreateEvent(t.Context(), Event{UserID: 2, Date: date, Title: "Event 2"})
 
 		assert.NoError(t, err1)
 		assert.NoError(t, err2)
@@ -41,20 +161,24 @@ func TestEventRepository_CreateEvent(t *testing.T) {
 		assert.Equal(t, 1, event1.UserID)
 		assert.Equal(t, 2, event2.UserID)
 	})
-}
 
-func TestEventRepository_UpdateEvent(t *testing.T) {
-	repo := NewEventRepository(testLogger())
+	t.Run("events are counted", func(t *testing.T) {
+		count, err := repo.CountEvents(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+}
 
+func testStorageUpdateEvent(t *testing.T, repo Storage) {
 	t.Run("successful event update", func(t *testing.T) {
 		oldDate := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
-		event, err := repo.CreateEvent(1, oldDate, "Old Title")
+		event, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: oldDate, Title: "Old Title"})
 		assert.NoError(t, err)
 
 		time.Sleep(1 * time.Millisecond)
 
 		newDate := time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC)
-		updatedEvent, err := repo.UpdateEvent(event.ID, event.UserID, newDate, "Updated Title")
+		updatedEvent, err := repo.UpdateEvent(t.Context(), Event{ID: event.ID, UserID: event.UserID, Date: newDate, Title: "Updated Title"})
 
 		assert.NoError(t, err)
 		assert.Equal(t, event.ID, updatedEvent.ID)
@@ -66,7 +190,7 @@ func TestEventRepository_UpdateEvent(t *testing.T) {
 
 	t.Run("update non-existent event", func(t *testing.T) {
 		newDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
-		_, err := repo.UpdateEvent(999, 1, newDate, "Title")
+		_, err := repo.UpdateEvent(t.Context(), Event{ID: 999, UserID: 1, Date: newDate, Title: "Title"})
 
 		assert.Error(t, err)
 		assert.Equal(t, ErrEventNotFound, err)
@@ -74,66 +198,64 @@ func TestEventRepository_UpdateEvent(t *testing.T) {
 
 	t.Run("update event with wrong user id", func(t *testing.T) {
 		date := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
-		event, err := repo.CreateEvent(1, date, "Test Event")
+		event, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Test Event"})
 		assert.NoError(t, err)
 
-		_, err = repo.UpdateEvent(event.ID, 999, date, "New Title")
+		_, err = repo.UpdateEvent(t.Context(), Event{ID: event.ID, UserID: 999, Date: date, Title: "New Title"})
 
 		assert.Error(t, err)
 		assert.Equal(t, ErrEventNotFound, err)
 	})
 }
 
-func TestEventRepository_DeleteEvent(t *testing.T) {
-	repo := NewEventRepository(testLogger())
-
+func testStorageDeleteEvent(t *testing.T, repo Storage) {
 	t.Run("successful event deletion", func(t *testing.T) {
 		date := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
-		event, err := repo.CreateEvent(1, date, "Event to delete")
+		event, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Event to delete"})
 		assert.NoError(t, err)
 
-		err = repo.DeleteEvent(event.ID, event.UserID)
+		err = repo.DeleteEvent(t.Context(), event.ID, event.UserID, 0)
 		assert.NoError(t, err)
 
-		events := repo.GetEventsForDay(1, date)
+		events, err := repo.GetEventsForDay(t.Context(), 1, date)
+		assert.NoError(t, err)
 		assert.Empty(t, events)
 	})
 
 	t.Run("delete non-existent event", func(t *testing.T) {
-		err := repo.DeleteEvent(999, 1)
+		err := repo.DeleteEvent(t.Context(), 999, 1, 0)
 		assert.Error(t, err)
 		assert.Equal(t, ErrEventNotFound, err)
 	})
 
 	t.Run("delete event with wrong user id", func(t *testing.T) {
 		date := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
-		event, err := repo.CreateEvent(1, date, "Test Event")
+		event, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Test Event"})
 		assert.NoError(t, err)
 
-		err = repo.DeleteEvent(event.ID, 999)
+		err = repo.DeleteEvent(t.Context(), event.ID, 999, 0)
 		assert.Error(t, err)
 		assert.Equal(t, ErrEventNotFound, err)
 	})
 }
 
-func TestEventRepository_GetEventsForPeriod(t *testing.T) {
-	repo := NewEventRepository(testLogger())
-
+func testStorageGetEventsForPeriod(t *testing.T, repo Storage) {
 	testDate := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
 	sameWeekDate := time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC)
 	sameMonthDate := time.Date(2024, 12, 12, 0, 0, 0, 0, time.UTC)
 	nextMonthDate := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
 
-	repo.CreateEvent(1, testDate, "User 1 Event 1")
-	repo.CreateEvent(1, testDate, "User 1 Event 2")
-	repo.CreateEvent(1, sameWeekDate, "User 1 Same Week")
-	repo.CreateEvent(1, sameMonthDate, "User 1 Same Month")
-	repo.CreateEvent(1, nextMonthDate, "User 1 Next Month")
-	repo.CreateEvent(2, testDate, "User 2 Event")
+	repo.CreateEvent(t.Context(), Event{UserID: 1, Date: testDate, Title: "User 1 Event 1"})
+	repo.CreateEvent(t.Context(), Event{UserID: 1, Date: testDate, Title: "User 1 Event 2"})
+	repo.CreateEvent(t.Context(), Event{UserID: 1, Date: sameWeekDate, Title: "User 1 Same Week"})
+	repo.CreateEvent(t.Context(), Event{UserID: 1, Date: sameMonthDate, Title: "User 1 Same Month"})
+	repo.CreateEvent(t.Context(), Event{UserID: 1, Date: nextMonthDate, Title: "User 1 Next Month"})
+	repo.CreateEvent(t.Context(), Event{UserID: 2, Date: testDate, Title: "User 2 Event"})
 
 	t.Run("get events for day", func(t *testing.T) {
-		events := repo.GetEventsForDay(1, testDate)
+		events, err := repo.GetEventsForDay(t.Context(), 1, testDate)
 
+		assert.NoError(t, err)
 		assert.Len(t, events, 2)
 		for _, event := range events {
 			assert.Equal(t, 1, event.UserID)
@@ -142,29 +264,42 @@ func TestEventRepository_GetEventsForPeriod(t *testing.T) {
 	})
 
 	t.Run("get events for week", func(t *testing.T) {
-		events := repo.GetEventsForWeek(1, testDate)
+		events, err := repo.GetEventsForWeek(t.Context(), 1, testDate)
+		assert.NoError(t, err)
 		assert.Len(t, events, 3)
 	})
 
 	t.Run("get events for month", func(t *testing.T) {
-		events := repo.GetEventsForMonth(1, testDate)
+		events, err := repo.GetEventsForMonth(t.Context(), 1, testDate)
+		assert.NoError(t, err)
 		assert.Len(t, events, 4)
 	})
 
+	t.Run("get events between arbitrary dates", func(t *testing.T) {
+		events, err := repo.GetEventsBetween(t.Context(), 1, sameWeekDate, nextMonthDate.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, events, 4)
+
+		events, err = repo.GetEventsBetween(t.Context(), 1, sameMonthDate.Add(time.Hour), sameWeekDate)
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
 	t.Run("get events for different user", func(t *testing.T) {
-		events := repo.GetEventsForDay(2, testDate)
+		events, err := repo.GetEventsForDay(t.Context(), 2, testDate)
+		assert.NoError(t, err)
 		assert.Len(t, events, 1)
 		assert.Equal(t, 2, events[0].UserID)
 	})
 
 	t.Run("get events for non-existent user", func(t *testing.T) {
-		events := repo.GetEventsForDay(999, testDate)
+		events, err := repo.GetEventsForDay(t.Context(), 999, testDate)
+		assert.NoError(t, err)
 		assert.Empty(t, events)
 	})
 }
 
-func TestEventRepository_ConcurrentAccess(t *testing.T) {
-	repo := NewEventRepository(testLogger())
+func testStorageConcurrentAccess(t *testing.T, repo Storage) {
 	iterations := 100
 	date := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
 
@@ -173,7 +308,7 @@ func TestEventRepository_ConcurrentAccess(t *testing.T) {
 		for i := 0; i < iterations; i++ {
 			go func(index int) {
 				userID := (index % 10) + 1
-				_, err := repo.CreateEvent(userID, date, "Concurrent Event")
+				_, err := repo.CreateEvent(t.Context(), Event{UserID: userID, Date: date, Title: "Concurrent Event"})
 				assert.NoError(t, err)
 				done <- true
 			}(i)
@@ -185,10 +320,817 @@ func TestEventRepository_ConcurrentAccess(t *testing.T) {
 
 		totalEvents := 0
 		for userID := 1; userID <= 10; userID++ {
-			events := repo.GetEventsForMonth(userID, date)
+			events, err := repo.GetEventsForMonth(t.Context(), userID, date)
+			assert.NoError(t, err)
 			totalEvents += len(events)
 		}
 
 		assert.Equal(t, iterations, totalEvents)
 	})
 }
+
+func testStorageRecurringEvents(t *testing.T, repo Storage) {
+	start := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	occurrence := time.Date(2025, 9, 8, 10, 0, 0, 0, time.UTC)
+
+	series, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: start, Title: "Standup", Recurrence: "FREQ=WEEKLY"})
+	require.NoError(t, err)
+	single, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: start, Title: "One-off"})
+	require.NoError(t, err)
+
+	t.Run("period queries skip series", func(t *testing.T) {
+		events, err := repo.GetEventsForDay(t.Context(), 1, start)
+		assert.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, single.ID, events[0].ID)
+	})
+
+	t.Run("get recurring events", func(t *testing.T) {
+		events, err := repo.GetRecurringEvents(t.Context(), 1, start.AddDate(0, 1, 0))
+		assert.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, series.ID, events[0].ID)
+		assert.Equal(t, "FREQ=WEEKLY", events[0].Recurrence)
+
+		events, err = repo.GetRecurringEvents(t.Context(), 1, start)
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("delete occurrence adds exdate", func(t *testing.T) {
+		err := repo.DeleteOccurrence(t.Context(), series.ID, 1, occurrence, 0)
+		assert.NoError(t, err)
+
+		got, err := repo.GetEvent(t.Context(), series.ID, 1)
+		assert.NoError(t, err)
+		require.Len(t, got.ExDates, 1)
+		assert.True(t, got.IsExcluded(occurrence))
+	})
+
+	t.Run("replace occurrence creates override", func(t *testing.T) {
+		moved := time.Date(2025, 9, 16, 12, 0, 0, 0, time.UTC)
+		original := time.Date(2025, 9, 15, 10, 0, 0, 0, time.UTC)
+
+		override, err := repo.ReplaceOccurrence(t.Context(), series.ID, 1, original, Event{Date: moved, Title: "Moved Standup"}, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, series.ID, override.RecurringEventID)
+		assert.Equal(t, 1, override.UserID)
+		require.NotNil(t, override.OriginalDate)
+		assert.True(t, original.Equal(*override.OriginalDate))
+
+		events, err := repo.GetEventsForDay(t.Context(), 1, moved)
+		assert.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Moved Standup", events[0].Title)
+
+		got, err := repo.GetEvent(t.Context(), series.ID, 1)
+		assert.NoError(t, err)
+		assert.Len(t, got.ExDates, 2)
+		assert.True(t, got.IsExcluded(original))
+	})
+
+	t.Run("occurrence operations require a series", func(t *testing.T) {
+		err := repo.DeleteOccurrence(t.Context(), single.ID, 1, start, 0)
+		assert.Equal(t, ErrEventNotFound, err)
+
+		_, err = repo.ReplaceOccurrence(t.Context(), series.ID, 999, occurrence, Event{Date: start, Title: "Nope"}, 0)
+		assert.Equal(t, ErrEventNotFound, err)
+	})
+
+	t.Run("deleting series removes overrides", func(t *testing.T) {
+		err := repo.DeleteEvent(t.Context(), series.ID, 1, 0)
+		assert.NoError(t, err)
+
+		events, err := repo.GetEventsForWeek(t.Context(), 1, time.Date(2025, 9, 16, 0, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
+func testStorageUIDs(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("uid is generated", func(t *testing.T) {
+		event, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Generated"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, event.UID)
+	})
+
+	t.Run("uid is unique per user", func(t *testing.T) {
+		_, err := repo.CreateEvent(t.Context(), Event{UID: "meeting@example.com", UserID: 1, Date: date, Title: "First"})
+		assert.NoError(t, err)
+
+		_, err = repo.CreateEvent(t.Context(), Event{UID: "meeting@example.com", UserID: 1, Date: date, Title: "Second"})
+		assert.Equal(t, ErrEventExists, err)
+
+		_, err = repo.CreateEvent(t.Context(), Event{UID: "meeting@example.com", UserID: 2, Date: date, Title: "Other user"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("overrides share series uid", func(t *testing.T) {
+		series, err := repo.CreateEvent(t.Context(), Event{UID: "series@example.com", UserID: 1, Date: date, Title: "Series", Recurrence: "FREQ=DAILY"})
+		require.NoError(t, err)
+
+		override, err := repo.ReplaceOccurrence(t.Context(), series.ID, 1, date.AddDate(0, 0, 1), Event{Date: date.AddDate(0, 0, 1), Title: "Override"}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, series.UID, override.UID)
+
+		events, err := repo.GetUserEvents(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Len(t, events, 4)
+		for _, event := range events {
+			assert.Equal(t, 1, event.UserID)
+		}
+	})
+}
+
+func testStorageEventTime(t *testing.T, repo Storage) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	night, err := repo.CreateEvent(t.Context(), Event{
+		UserID:   1,
+		Date:     time.Date(2025, 9, 1, 23, 0, 0, 0, moscow),
+		End:      time.Date(2025, 9, 2, 1, 0, 0, 0, moscow),
+		TimeZone: "Europe/Moscow",
+		Title:    "Night shift",
+	})
+	require.NoError(t, err)
+
+	_, err = repo.CreateEvent(t.Context(), Event{
+		UserID:   1,
+		Date:     time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2025, 9, 4, 0, 0, 0, 0, time.UTC),
+		AllDay:   true,
+		TimeZone: "UTC",
+		Title:    "Holiday",
+	})
+	require.NoError(t, err)
+
+	t.Run("time zone is kept", func(t *testing.T) {
+		event, err := repo.GetEvent(t.Context(), night.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Moscow", event.TimeZone)
+		assert.Equal(t, "Europe/Moscow", event.Date.Location().String())
+		assert.Equal(t, 23, event.Date.Hour())
+		assert.True(t, night.End.Equal(event.End))
+	})
+
+	t.Run("event spanning midnight belongs to both days", func(t *testing.T) {
+		for _, day := range []int{1, 2} {
+			events, err := repo.GetEventsForDay(t.Context(), 1, time.Date(2025, 9, day, 0, 0, 0, 0, moscow))
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, night.ID, events[0].ID)
+		}
+	})
+
+	t.Run("day boundaries follow caller time zone", func(t *testing.T) {
+		// 23:00–01:00 по Москве — это 20:00–22:00 UTC 1 сентября.
+		events, err := repo.GetEventsForDay(t.Context(), 1, time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("all-day event does not depend on caller time zone", func(t *testing.T) {
+		for _, zone := range []string{"UTC", "Europe/Moscow", "America/New_York"} {
+			loc, err := time.LoadLocation(zone)
+			require.NoError(t, err)
+
+			events, err := repo.GetEventsForDay(t.Context(), 1, time.Date(2025, 9, 3, 0, 0, 0, 0, loc))
+			require.NoError(t, err)
+			require.Len(t, events, 1, zone)
+			assert.Equal(t, "Holiday", events[0].Title)
+
+			events, err = repo.GetEventsForDay(t.Context(), 1, time.Date(2025, 9, 4, 0, 0, 0, 0, loc))
+			require.NoError(t, err)
+			assert.Empty(t, events, zone)
+		}
+	})
+}
+
+func testStorageReminders(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	withReminders, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Call", Reminders: []int{15, 60}})
+	require.NoError(t, err)
+	_, err = repo.CreateEvent(t.Context(), Event{UserID: 2, Date: date.AddDate(0, 0, -7), Title: "Past", Reminders: []int{15}})
+	require.NoError(t, err)
+	_, err = repo.CreateEvent(t.Context(), Event{UserID: 2, Date: date.AddDate(0, 0, -7), Title: "Weekly", Reminders: []int{5}, Recurrence: "FREQ=WEEKLY"})
+	require.NoError(t, err)
+	_, err = repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Silent"})
+	require.NoError(t, err)
+
+	t.Run("reminders are stored", func(t *testing.T) {
+		event, err := repo.GetEvent(t.Context(), withReminders.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int{15, 60}, event.Reminders)
+
+		event.Reminders = nil
+		updated, err := repo.UpdateEvent(t.Context(), event)
+		require.NoError(t, err)
+		assert.Empty(t, updated.Reminders)
+
+		updated.Reminders = []int{30}
+		updated, err = repo.UpdateEvent(t.Context(), updated)
+		require.NoError(t, err)
+		assert.Equal(t, []int{30}, updated.Reminders)
+	})
+
+	t.Run("events with reminders", func(t *testing.T) {
+		events, err := repo.GetEventsWithReminders(t.Context(), date.AddDate(0, 0, -1))
+		require.NoError(t, err)
+
+		var titles []string
+		for _, event := range events {
+			titles = append(titles, event.Title)
+		}
+		assert.ElementsMatch(t, []string{"Call", "Weekly"}, titles)
+	})
+
+	t.Run("reminder is claimed once", func(t *testing.T) {
+		key := ReminderKey{EventID: withReminders.ID, Occurrence: date, MinutesBefore: 30}
+
+		claimed, err := repo.ClaimReminder(t.Context(), key)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = repo.ClaimReminder(t.Context(), ReminderKey{EventID: key.EventID, Occurrence: date.In(time.FixedZone("MSK", 3*3600)), MinutesBefore: 30})
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		claimed, err = repo.ClaimReminder(t.Context(), ReminderKey{EventID: key.EventID, Occurrence: date, MinutesBefore: 15})
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		require.NoError(t, repo.ReleaseReminder(t.Context(), key))
+		claimed, err = repo.ClaimReminder(t.Context(), key)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	})
+}
+
+func testStorageVersions(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	created, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Version)
+
+	t.Run("update increments version", func(t *testing.T) {
+		created.Title = "Daily standup"
+		updated, err := repo.UpdateEvent(t.Context(), created)
+		require.NoError(t, err)
+		assert.Equal(t, 2, updated.Version)
+
+		stored, err := repo.GetEvent(t.Context(), created.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, stored.Version)
+	})
+
+	t.Run("stale version is rejected", func(t *testing.T) {
+		created.Title = "Lost update"
+		_, err := repo.UpdateEvent(t.Context(), created)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+
+		err = repo.DeleteEvent(t.Context(), created.ID, 1, 1)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+
+		err = repo.DeleteOccurrence(t.Context(), created.ID, 1, date.AddDate(0, 0, 1), 1)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+
+		_, err = repo.ReplaceOccurrence(t.Context(), created.ID, 1, date.AddDate(0, 0, 2), Event{Date: date, Title: "Moved"}, 1)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+
+		stored, err := repo.GetEvent(t.Context(), created.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Daily standup", stored.Title)
+		assert.Empty(t, stored.ExDates)
+	})
+
+	t.Run("occurrence changes bump series version", func(t *testing.T) {
+		err := repo.DeleteOccurrence(t.Context(), created.ID, 1, date.AddDate(0, 0, 1), 2)
+		require.NoError(t, err)
+
+		_, err = repo.ReplaceOccurrence(t.Context(), created.ID, 1, date.AddDate(0, 0, 2), Event{Date: date, Title: "Moved"}, 3)
+		require.NoError(t, err)
+
+		stored, err := repo.GetEvent(t.Context(), created.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 4, stored.Version)
+	})
+
+	t.Run("missing event is not a version mismatch", func(t *testing.T) {
+		err := repo.DeleteEvent(t.Context(), 999, 1, 1)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+
+		err = repo.DeleteEvent(t.Context(), created.ID, 1, 4)
+		require.NoError(t, err)
+	})
+}
+
+func testStorageBatch(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC)
+
+	series, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Standup", Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	_, err = repo.ReplaceOccurrence(t.Context(), series.ID, 1, date.AddDate(0, 0, 1), Event{Date: date.Add(time.Hour), Title: "Moved"}, 0)
+	require.NoError(t, err)
+	single, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Review", UID: "review"})
+	require.NoError(t, err)
+
+	snapshot := func() []Event {
+		events, err := repo.GetUserEvents(t.Context(), 1)
+		require.NoError(t, err)
+		return events
+	}
+
+	t.Run("failed batch leaves no changes", func(t *testing.T) {
+		before := snapshot()
+
+		renamed := single
+		renamed.Title = "Renamed"
+		_, err := repo.ApplyBatch(t.Context(), []BatchOp{
+			{Kind: BatchCreate, Event: Event{UserID: 1, Date: date, Title: "New"}},
+			{Kind: BatchUpdate, Event: renamed},
+			{Kind: BatchDelete, Event: Event{ID: series.ID, UserID: 1}},
+			{Kind: BatchUpdate, Event: Event{ID: single.ID, UserID: 1, Date: date, Title: "Stale", Version: 1}},
+		})
+
+		var batchErr *BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 3, batchErr.Index)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+		assert.Equal(t, before, snapshot())
+
+		count, err := repo.CountEvents(t.Context())
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("duplicate uid fails the batch", func(t *testing.T) {
+		_, err := repo.ApplyBatch(t.Context(), []BatchOp{
+			{Kind: BatchCreate, Event: Event{UserID: 1, Date: date, Title: "First", UID: "dup"}},
+			{Kind: BatchCreate, Event: Event{UserID: 1, Date: date, Title: "Second", UID: "dup"}},
+		})
+		assert.ErrorIs(t, err, ErrEventExists)
+
+		_, err = repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "First", UID: "dup"})
+		require.NoError(t, err)
+	})
+
+	t.Run("successful batch applies all operations", func(t *testing.T) {
+		renamed := single
+		renamed.Title = "Renamed"
+		results, err := repo.ApplyBatch(t.Context(), []BatchOp{
+			{Kind: BatchCreate, Event: Event{UserID: 1, Date: date, Title: "New"}},
+			{Kind: BatchUpdate, Event: renamed},
+			{Kind: BatchDelete, Event: Event{ID: series.ID, UserID: 1, Version: series.Version + 1}},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "New", results[0].Title)
+		assert.Equal(t, "Renamed", results[1].Title)
+		assert.Equal(t, single.Version+1, results[1].Version)
+		assert.Equal(t, series.ID, results[2].ID)
+
+		_, err = repo.GetEvent(t.Context(), results[0].ID, 1)
+		require.NoError(t, err)
+		_, err = repo.GetEvent(t.Context(), series.ID, 1)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+
+		for _, e := range snapshot() {
+			assert.NotEqual(t, series.ID, e.RecurringEventID)
+		}
+	})
+}
+
+func testStorageWebhooks(t *testing.T, repo Storage) {
+	first, err := repo.CreateWebhook(t.Context(), Webhook{UserID: 1, URL: "http://example.com/a", Secret: "a", Events: []string{ChangeCreated}})
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+
+	second, err := repo.CreateWebhook(t.Context(), Webhook{UserID: 1, URL: "http://example.com/b", Secret: "b", Events: []string{}})
+	require.NoError(t, err)
+	_, err = repo.CreateWebhook(t.Context(), Webhook{UserID: 2, URL: "http://example.com/c", Secret: "c", Events: []string{}})
+	require.NoError(t, err)
+
+	t.Run("webhooks are listed per user", func(t *testing.T) {
+		hooks, err := repo.ListWebhooks(t.Context(), 1)
+		require.NoError(t, err)
+		require.Len(t, hooks, 2)
+		assert.Equal(t, first.ID, hooks[0].ID)
+		assert.Equal(t, []string{ChangeCreated}, hooks[0].Events)
+		assert.Equal(t, "a", hooks[0].Secret)
+		assert.Equal(t, second.ID, hooks[1].ID)
+	})
+
+	t.Run("foreign webhook is not found", func(t *testing.T) {
+		_, err := repo.GetWebhook(t.Context(), first.ID, 2)
+		assert.ErrorIs(t, err, ErrWebhookNotFound)
+
+		err = repo.DeleteWebhook(t.Context(), first.ID, 2)
+		assert.ErrorIs(t, err, ErrWebhookNotFound)
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+		require.NoError(t, repo.DeleteWebhook(t.Context(), first.ID, 1))
+
+		_, err := repo.GetWebhook(t.Context(), first.ID, 1)
+		assert.ErrorIs(t, err, ErrWebhookNotFound)
+	})
+}
+
+func testStorageCalendars(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	work, err := repo.CreateCalendar(t.Context(), Calendar{UserID: 1, Name: "work"})
+	require.NoError(t, err)
+	assert.NotZero(t, work.ID)
+	assert.Equal(t, RoleOwner, work.Role)
+
+	personal, err := repo.CreateCalendar(t.Context(), Calendar{UserID: 1, Name: "personal"})
+	require.NoError(t, err)
+
+	t.Run("events keep their calendar", func(t *testing.T) {
+		created, err := repo.CreateEvent(t.Context(), Event{UserID: 1, CalendarID: work.ID, Title: "standup", Date: date})
+		require.NoError(t, err)
+		assert.Equal(t, work.ID, created.CalendarID)
+
+		created.Title = "retro"
+		created.CalendarID = personal.ID
+		updated, err := repo.UpdateEvent(t.Context(), created)
+		require.NoError(t, err)
+		assert.Equal(t, work.ID, updated.CalendarID, "update does not move events between calendars")
+	})
+
+	t.Run("shared calendar is visible with its role", func(t *testing.T) {
+		_, err := repo.GetCalendar(t.Context(), work.ID, 2)
+		assert.ErrorIs(t, err, ErrCalendarNotFound)
+
+		share, err := repo.ShareCalendar(t.Context(), CalendarShare{CalendarID: work.ID, UserID: 2, Role: RoleViewer})
+		require.NoError(t, err)
+		assert.False(t, share.CreatedAt.IsZero())
+
+		_, err = repo.ShareCalendar(t.Context(), CalendarShare{CalendarID: work.ID, UserID: 2, Role: RoleEditor})
+		require.NoError(t, err)
+
+		cal, err := repo.GetCalendar(t.Context(), work.ID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, RoleEditor, cal.Role)
+		assert.True(t, cal.CanEdit())
+
+		calendars, err := repo.ListCalendars(t.Context(), 2)
+		require.NoError(t, err)
+		require.Len(t, calendars, 1)
+		assert.Equal(t, work.ID, calendars[0].ID)
+
+		calendars, err = repo.ListCalendars(t.Context(), 1)
+		require.NoError(t, err)
+		require.Len(t, calendars, 2)
+		assert.Equal(t, RoleOwner, calendars[1].Role)
+
+		shares, err := repo.ListCalendarShares(t.Context(), work.ID)
+		require.NoError(t, err)
+		require.Len(t, shares, 1)
+		assert.Equal(t, RoleEditor, shares[0].Role)
+	})
+
+	t.Run("only owner renames calendar", func(t *testing.T) {
+		_, err := repo.UpdateCalendar(t.Context(), Calendar{ID: personal.ID, UserID: 2, Name: "mine"})
+		assert.ErrorIs(t, err, ErrCalendarNotFound)
+
+		renamed, err := repo.UpdateCalendar(t.Context(), Calendar{ID: personal.ID, UserID: 1, Name: "home"})
+		require.NoError(t, err)
+		assert.Equal(t, "home", renamed.Name)
+	})
+
+	t.Run("unshare calendar", func(t *testing.T) {
+		require.NoError(t, repo.UnshareCalendar(t.Context(), work.ID, 2))
+		assert.ErrorIs(t, repo.UnshareCalendar(t.Context(), work.ID, 2), ErrShareNotFound)
+
+		_, err := repo.GetCalendar(t.Context(), work.ID, 2)
+		assert.ErrorIs(t, err, ErrCalendarNotFound)
+	})
+
+	t.Run("deleting calendar removes its events", func(t *testing.T) {
+		kept, err := repo.CreateEvent(t.Context(), Event{UserID: 1, CalendarID: personal.ID, Title: "gym", Date: date})
+		require.NoError(t, err)
+		_, err = repo.ShareCalendar(t.Context(), CalendarShare{CalendarID: work.ID, UserID: 3, Role: RoleViewer})
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, repo.DeleteCalendar(t.Context(), work.ID, 2), ErrCalendarNotFound)
+		require.NoError(t, repo.DeleteCalendar(t.Context(), work.ID, 1))
+
+		events, err := repo.GetUserEvents(t.Context(), 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, kept.ID, events[0].ID)
+
+		_, err = repo.ListCalendarShares(t.Context(), work.ID)
+		assert.ErrorIs(t, err, ErrCalendarNotFound)
+		calendars, err := repo.ListCalendars(t.Context(), 3)
+		require.NoError(t, err)
+		assert.Empty(t, calendars)
+	})
+}
+
+func testStorageHistory(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	old := &Event{ID: 1, UserID: 1, Title: "draft", Date: date, End: date, TimeZone: "UTC", Version: 1}
+	updated := &Event{ID: 1, UserID: 1, Title: "final", Date: date, End: date, TimeZone: "UTC", Version: 2}
+
+	entries := []HistoryEntry{
+		{UserID: 1, EventID: 1, Action: HistoryCreated, ActorID: 1, New: old, Time: date},
+		{UserID: 1, EventID: 2, Action: HistoryCreated, ActorID: 1, New: &Event{ID: 2, UserID: 1, Title: "other", Date: date, End: date, TimeZone: "UTC", Version: 1}, Time: date},
+		{UserID: 1, EventID: 1, Action: HistoryUpdated, ActorID: 2, RequestID: "req-1", Old: old, New: updated, Time: date.Add(time.Hour)},
+		{UserID: 2, EventID: 3, Action: HistoryDeleted, Old: &Event{ID: 3, UserID: 2, Title: "foreign", Date: date, End: date, TimeZone: "UTC", Version: 1}, Time: date},
+	}
+	for i, entry := range entries {
+		added, err := repo.AddHistory(t.Context(), entry)
+		require.NoError(t, err)
+		assert.Equal(t, i+1, added.ID)
+	}
+
+	t.Run("entries are listed newest first", func(t *testing.T) {
+		listed, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 1})
+		require.NoError(t, err)
+		require.Len(t, listed, 3)
+		assert.Equal(t, []int{3, 2, 1}, []int{listed[0].ID, listed[1].ID, listed[2].ID})
+
+		assert.Equal(t, HistoryUpdated, listed[0].Action)
+		assert.Equal(t, 2, listed[0].ActorID)
+		assert.Equal(t, "req-1", listed[0].RequestID)
+		require.NotNil(t, listed[0].Old)
+		require.NotNil(t, listed[0].New)
+		assert.Equal(t, "draft", listed[0].Old.Title)
+		assert.Equal(t, "final", listed[0].New.Title)
+		assert.True(t, date.Equal(listed[0].New.Date))
+		assert.Nil(t, listed[2].Old)
+	})
+
+	t.Run("entries are filtered by event and paged", func(t *testing.T) {
+		listed, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 1, EventID: 1, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, 3, listed[0].ID)
+
+		listed, err = repo.ListHistory(t.Context(), HistoryQuery{UserID: 1, EventID: 1, BeforeID: 3})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, 1, listed[0].ID)
+	})
+
+	t.Run("deleted snapshot is kept", func(t *testing.T) {
+		listed, err := repo.ListHistory(t.Context(), HistoryQuery{UserID: 2})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Nil(t, listed[0].New)
+		assert.Equal(t, "foreign", listed[0].Snapshot().Title)
+	})
+}
+
+func testStorageRestoreEvent(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	created, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Title: "draft", Date: date})
+	require.NoError(t, err)
+
+	t.Run("existing event is restored as a new version", func(t *testing.T) {
+		updated, err := repo.UpdateEvent(t.Context(), Event{ID: created.ID, UserID: 1, Title: "final", Date: date, Version: created.Version})
+		require.NoError(t, err)
+
+		past := created
+		past.Version = updated.Version
+		restored, err := repo.RestoreEvent(t.Context(), past)
+		require.NoError(t, err)
+		assert.Equal(t, "draft", restored.Title)
+		assert.Equal(t, updated.Version+1, restored.Version)
+
+		past.Version = updated.Version
+		_, err = repo.RestoreEvent(t.Context(), past)
+		assert.ErrorIs(t, err, ErrVersionMismatch)
+	})
+
+	t.Run("deleted event is restored with the same ID", func(t *testing.T) {
+		current, err := repo.GetEvent(t.Context(), created.ID, 1)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteEvent(t.Context(), created.ID, 1, 0))
+
+		restored, err := repo.RestoreEvent(t.Context(), current)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, restored.ID)
+		assert.Equal(t, created.UID, restored.UID)
+		assert.Equal(t, current.Version+1, restored.Version)
+
+		found, err := repo.GetEvent(t.Context(), created.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, restored.Version, found.Version)
+
+		next, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Title: "next", Date: date})
+		require.NoError(t, err)
+		assert.Greater(t, next.ID, created.ID)
+	})
+
+	t.Run("taken uid is not restored", func(t *testing.T) {
+		current, err := repo.GetEvent(t.Context(), created.ID, 1)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteEvent(t.Context(), created.ID, 1, 0))
+		_, err = repo.CreateEvent(t.Context(), Event{UserID: 1, UID: current.UID, Title: "clone", Date: date})
+		require.NoError(t, err)
+
+		_, err = repo.RestoreEvent(t.Context(), current)
+		assert.ErrorIs(t, err, ErrEventExists)
+	})
+}
+
+func testStorageTrash(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	series, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Title: "standup", Date: date, Recurrence: "FREQ=DAILY;COUNT=5"})
+	require.NoError(t, err)
+	override, err := repo.ReplaceOccurrence(t.Context(), series.ID, 1, date.AddDate(0, 0, 1), Event{Title: "moved", Date: date.AddDate(0, 0, 1)}, 0)
+	require.NoError(t, err)
+	single, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Title: "lunch", Date: date})
+	require.NoError(t, err)
+
+	t.Run("deleted events are moved to trash", func(t *testing.T) {
+		before := time.Now()
+		require.NoError(t, repo.DeleteEvent(t.Context(), series.ID, 1, 0))
+
+		trash, err := repo.ListTrash(t.Context(), 1)
+		require.NoError(t, err)
+		require.Len(t, trash, 2)
+		assert.ElementsMatch(t, []int{series.ID, override.ID}, []int{trash[0].ID, trash[1].ID})
+		assert.False(t, trash[0].DeletedAt.Before(before.Truncate(time.Second)))
+
+		trashed, err := repo.GetTrashedEvent(t.Context(), series.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "standup", trashed.Title)
+		assert.Equal(t, series.Recurrence, trashed.Recurrence)
+
+		_, err = repo.GetTrashedEvent(t.Context(), series.ID, 2)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+		_, err = repo.GetTrashedEvent(t.Context(), single.ID, 1)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+
+		events, err := repo.GetEventsForDay(t.Context(), 1, date.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("restored event leaves trash", func(t *testing.T) {
+		trashed, err := repo.GetTrashedEvent(t.Context(), series.ID, 1)
+		require.NoError(t, err)
+
+		_, err = repo.RestoreEvent(t.Context(), trashed.Event)
+		require.NoError(t, err)
+
+		_, err = repo.GetTrashedEvent(t.Context(), series.ID, 1)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+		trash, err := repo.ListTrash(t.Context(), 1)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, override.ID, trash[0].ID)
+	})
+
+	t.Run("failed batch does not trash events", func(t *testing.T) {
+		_, err := repo.ApplyBatch(t.Context(), []BatchOp{
+			{Kind: BatchDelete, Event: Event{ID: single.ID, UserID: 1}},
+			{Kind: BatchDelete, Event: Event{ID: 999, UserID: 1}},
+		})
+		require.Error(t, err)
+
+		_, err = repo.GetTrashedEvent(t.Context(), single.ID, 1)
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+
+	t.Run("calendar events are trashed", func(t *testing.T) {
+		cal, err := repo.CreateCalendar(t.Context(), Calendar{UserID: 1, Name: "work"})
+		require.NoError(t, err)
+		meeting, err := repo.CreateEvent(t.Context(), Event{UserID: 1, CalendarID: cal.ID, Title: "sync", Date: date})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteCalendar(t.Context(), cal.ID, 1))
+
+		trashed, err := repo.GetTrashedEvent(t.Context(), meeting.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, cal.ID, trashed.CalendarID)
+	})
+
+	t.Run("sent reminders are kept while in trash", func(t *testing.T) {
+		key := ReminderKey{EventID: single.ID, Occurrence: date, MinutesBefore: 15}
+		claimed, err := repo.ClaimReminder(t.Context(), key)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		require.NoError(t, repo.DeleteEvent(t.Context(), single.ID, 1, 0))
+		claimed, err = repo.ClaimReminder(t.Context(), key)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+	})
+
+	t.Run("purge removes old entries", func(t *testing.T) {
+		purged, err := repo.PurgeTrash(t.Context(), time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = repo.PurgeTrash(t.Context(), time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 3, purged)
+
+		trash, err := repo.ListTrash(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+
+		claimed, err := repo.ClaimReminder(t.Context(), ReminderKey{EventID: single.ID, Occurrence: date, MinutesBefore: 15})
+		require.NoError(t, err)
+		assert.True(t, claimed, "purge removes sent reminders of purged events")
+	})
+}
+
+func testStorageAttendees(t *testing.T, repo Storage) {
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	meeting, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Title: "planning", Date: date})
+	require.NoError(t, err)
+
+	t.Run("invite requires organizer event", func(t *testing.T) {
+		_, err := repo.AddAttendee(t.Context(), Attendee{EventID: meeting.ID, OrganizerID: 2, UserID: 3})
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+
+	t.Run("invited attendees need action", func(t *testing.T) {
+		attendee, err := repo.AddAttendee(t.Context(), Attendee{EventID: meeting.ID, OrganizerID: 1, UserID: 3})
+		require.NoError(t, err)
+		assert.Equal(t, RSVPNeedsAction, attendee.Status)
+		assert.False(t, attendee.InvitedAt.IsZero())
+		assert.Nil(t, attendee.RespondedAt)
+
+		_, err = repo.AddAttendee(t.Context(), Attendee{EventID: meeting.ID, OrganizerID: 1, UserID: 2})
+		require.NoError(t, err)
+
+		attendees, err := repo.ListAttendees(t.Context(), meeting.ID)
+		require.NoError(t, err)
+		require.Len(t, attendees, 2)
+		assert.Equal(t, 2, attendees[0].UserID)
+		assert.Equal(t, 3, attendees[1].UserID)
+	})
+
+	t.Run("responses are kept on repeated invite and reset", func(t *testing.T) {
+		attendee, err := repo.SetAttendeeStatus(t.Context(), meeting.ID, 2, RSVPAccepted)
+		require.NoError(t, err)
+		assert.Equal(t, RSVPAccepted, attendee.Status)
+		require.NotNil(t, attendee.RespondedAt)
+
+		attendee, err = repo.AddAttendee(t.Context(), Attendee{EventID: meeting.ID, OrganizerID: 1, UserID: 2})
+		require.NoError(t, err)
+		assert.Equal(t, RSVPAccepted, attendee.Status)
+
+		require.NoError(t, repo.ResetAttendees(t.Context(), meeting.ID))
+		attendees, err := repo.ListAttendees(t.Context(), meeting.ID)
+		require.NoError(t, err)
+		for _, attendee := range attendees {
+			assert.Equal(t, RSVPNeedsAction, attendee.Status)
+			assert.Nil(t, attendee.RespondedAt)
+		}
+
+		_, err = repo.SetAttendeeStatus(t.Context(), meeting.ID, 4, RSVPDeclined)
+		assert.ErrorIs(t, err, ErrAttendeeNotFound)
+	})
+
+	t.Run("invitations of user", func(t *testing.T) {
+		invitations, err := repo.ListInvitations(t.Context(), 2)
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		assert.Equal(t, meeting.ID, invitations[0].EventID)
+		assert.Equal(t, 1, invitations[0].OrganizerID)
+
+		invitations, err = repo.ListInvitations(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, invitations)
+	})
+
+	t.Run("remove attendee", func(t *testing.T) {
+		require.NoError(t, repo.RemoveAttendee(t.Context(), meeting.ID, 3))
+		assert.ErrorIs(t, repo.RemoveAttendee(t.Context(), meeting.ID, 3), ErrAttendeeNotFound)
+
+		attendees, err := repo.ListAttendees(t.Context(), meeting.ID)
+		require.NoError(t, err)
+		require.Len(t, attendees, 1)
+	})
+
+	t.Run("attendees survive trash until purge", func(t *testing.T) {
+		require.NoError(t, repo.DeleteEvent(t.Context(), meeting.ID, 1, 0))
+		attendees, err := repo.ListAttendees(t.Context(), meeting.ID)
+		require.NoError(t, err)
+		assert.Len(t, attendees, 1)
+
+		_, err = repo.PurgeTrash(t.Context(), time.Now().Add(time.Second))
+		require.NoError(t, err)
+		attendees, err = repo.ListAttendees(t.Context(), meeting.ID)
+		require.NoError(t, err)
+		assert.Empty(t, attendees)
+	})
+}
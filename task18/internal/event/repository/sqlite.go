@@ -0,0 +1,1483 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeLayout хранит время в UTC с фиксированной точностью,
+// чтобы строки сравнивались в SQL так же, как сами моменты времени.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+const eventColumns = `id, uid, user_id, title, date, end_date, all_day, timezone, recurrence, exdates, recurring_event_id, original_date, reminders, created_at, updated_at, version, calendar_id`
+
+type SQLiteRepository struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func NewSQLiteRepository(path string, logger *slog.Logger) (*SQLiteRepository, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+
+	// SQLite допускает только одного писателя, а для ":memory:" каждое
+	// новое соединение означает новую пустую базу.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate sqlite: %w", err)
+	}
+
+	return &SQLiteRepository{
+		db:  db,
+		log: logger,
+	}, nil
+}
+
+func (sr *SQLiteRepository) CreateEvent(ctx context.Context, event Event) (Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Event{}, err
+	}
+	defer tx.Rollback()
+
+	created, err := insertEvent(ctx, tx, event)
+	if isUniqueViolation(err) {
+		return Event{}, ErrEventExists
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("insert event: %w", err)
+	}
+	if err := recordHistory(ctx, tx, HistoryCreated, nil, &created); err != nil {
+		return Event{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Event{}, fmt.Errorf("create event: %w", err)
+	}
+
+	sr.log.Info("Event created",
+		"event_id", created.ID,
+		"user_id", created.UserID,
+		"date", created.Date.Format("2006-01-02"),
+		"title", created.Title,
+		"recurrence", created.Recurrence,
+	)
+
+	return created, nil
+}
+
+func (sr *SQLiteRepository) GetEvent(ctx context.Context, eventID, userID int) (Event, error) {
+	row := sr.db.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = ? AND user_id = ?`, eventID, userID)
+
+	event, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, ErrEventNotFound
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("get event: %w", err)
+	}
+
+	return event, nil
+}
+
+func (sr *SQLiteRepository) GetUserEvents(ctx context.Context, userID int) ([]Event, error) {
+	return sr.queryEvents(ctx, `SELECT `+eventColumns+` FROM events
+		WHERE user_id = ?
+		ORDER BY date, id`,
+		userID)
+}
+
+func (sr *SQLiteRepository) UpdateEvent(ctx context.Context, event Event) (Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Event{}, err
+	}
+	defer tx.Rollback()
+
+	updated, err := changeEvent(ctx, tx, HistoryUpdated, event)
+	if err != nil {
+		return Event{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Event{}, fmt.Errorf("update event: %w", err)
+	}
+
+	sr.log.Info("Event updated",
+		"event_id", event.ID,
+		"user_id", event.UserID,
+		"new_title", event.Title,
+		"new_date", event.Date.Format("2006-01-02"),
+		"recurrence", event.Recurrence,
+	)
+
+	return updated, nil
+}
+
+func (sr *SQLiteRepository) DeleteEvent(ctx context.Context, eventID, userID, version int) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := deleteEvent(ctx, tx, eventID, userID, version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+
+	sr.log.Info("Event deleted",
+		"event_id", eventID,
+		"user_id", userID,
+	)
+
+	return nil
+}
+
+// ApplyBatch применяет операции в одной транзакции.
+func (sr *SQLiteRepository) ApplyBatch(ctx context.Context, ops []BatchOp) ([]Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]Event, 0, len(ops))
+	for i, op := range ops {
+		var result Event
+		switch op.Kind {
+		case BatchCreate:
+			result, err = insertEvent(ctx, tx, op.Event)
+			if isUniqueViolation(err) {
+				err = ErrEventExists
+			}
+			if err == nil {
+				err = recordHistory(ctx, tx, HistoryCreated, nil, &result)
+			}
+		case BatchUpdate:
+			result, err = changeEvent(ctx, tx, HistoryUpdated, op.Event)
+		case BatchDelete:
+			var removed []Event
+			removed, err = deleteEvent(ctx, tx, op.Event.ID, op.Event.UserID, op.Event.Version)
+			if err == nil {
+				result = removed[0]
+			}
+		default:
+			err = unknownBatchOp(op.Kind)
+		}
+		if err != nil {
+			return nil, &BatchError{Index: i, Err: err}
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("apply batch: %w", err)
+	}
+
+	sr.log.Info("Event batch applied",
+		"operations", len(ops),
+	)
+
+	return results, nil
+}
+
+func (sr *SQLiteRepository) GetEventsForDay(ctx context.Context, userID int, date time.Time) ([]Event, error) {
+	from, to := DayRange(date)
+	return sr.eventsBetween(ctx, userID, from, to)
+}
+
+func (sr *SQLiteRepository) GetEventsForWeek(ctx context.Context, userID int, date time.Time) ([]Event, error) {
+	from, to := WeekRange(date)
+	return sr.eventsBetween(ctx, userID, from, to)
+}
+
+func (sr *SQLiteRepository) GetEventsForMonth(ctx context.Context, userID int, date time.Time) ([]Event, error) {
+	from, to := MonthRange(date)
+	return sr.eventsBetween(ctx, userID, from, to)
+}
+
+func (sr *SQLiteRepository) GetEventsBetween(ctx context.Context, userID int, from, to time.Time) ([]Event, error) {
+	return sr.eventsBetween(ctx, userID, from, to)
+}
+
+func (sr *SQLiteRepository) GetRecurringEvents(ctx context.Context, userID int, before time.Time) ([]Event, error) {
+	return sr.queryEvents(ctx, `SELECT `+eventColumns+` FROM events
+		WHERE user_id = ? AND recurrence != '' AND date < ?
+		ORDER BY date, id`,
+		userID, formatTime(before))
+}
+
+func (sr *SQLiteRepository) DeleteOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, version int) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := excludeOccurrence(ctx, tx, eventID, userID, occurrence, version); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete occurrence: %w", err)
+	}
+
+	sr.log.Info("Event occurrence deleted",
+		"event_id", eventID,
+		"user_id", userID,
+		"occurrence", occurrence.Format("2006-01-02"),
+	)
+
+	return nil
+}
+
+func (sr *SQLiteRepository) ReplaceOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, override Event, version int) (Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Event{}, err
+	}
+	defer tx.Rollback()
+
+	series, err := excludeOccurrence(ctx, tx, eventID, userID, occurrence, version)
+	if err != nil {
+		return Event{}, err
+	}
+
+	override.UID = series.UID
+	override.UserID = userID
+	override.CalendarID = series.CalendarID
+	override.Recurrence = ""
+	override.ExDates = nil
+	override.RecurringEventID = eventID
+	override.OriginalDate = &occurrence
+
+	created, err := insertEvent(ctx, tx, override)
+	if err != nil {
+		return Event{}, fmt.Errorf("insert override: %w", err)
+	}
+	if err := recordHistory(ctx, tx, HistoryCreated, nil, &created); err != nil {
+		return Event{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Event{}, fmt.Errorf("replace occurrence: %w", err)
+	}
+
+	sr.log.Info("Event occurrence replaced",
+		"event_id", eventID,
+		"override_id", created.ID,
+		"user_id", userID,
+		"occurrence", occurrence.Format("2006-01-02"),
+		"new_date", created.Date.Format("2006-01-02"),
+	)
+
+	return created, nil
+}
+
+func (sr *SQLiteRepository) CountEvents(ctx context.Context) (int, error) {
+	var count int
+	if err := sr.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM events`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count events: %w", err)
+	}
+	return count, nil
+}
+
+func (sr *SQLiteRepository) GetEventsWithReminders(ctx context.Context, startsAfter time.Time) ([]Event, error) {
+	return sr.queryEvents(ctx, `SELECT `+eventColumns+` FROM events
+		WHERE reminders != '[]' AND (recurrence != '' OR date >= ?)
+		ORDER BY date, id`,
+		formatTime(startsAfter))
+}
+
+func (sr *SQLiteRepository) ClaimReminder(ctx context.Context, key ReminderKey) (bool, error) {
+	res, err := sr.db.ExecContext(ctx, `INSERT OR IGNORE INTO reminder_deliveries (event_id, occurrence, minutes_before, sent_at)
+		VALUES (?, ?, ?, ?)`,
+		key.EventID, formatTime(key.Occurrence), key.MinutesBefore, formatTime(time.Now()))
+	if err != nil {
+		return false, fmt.Errorf("claim reminder: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claim reminder: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+func (sr *SQLiteRepository) ReleaseReminder(ctx context.Context, key ReminderKey) error {
+	if _, err := sr.db.ExecContext(ctx, `DELETE FROM reminder_deliveries
+		WHERE event_id = ? AND occurrence = ? AND minutes_before = ?`,
+		key.EventID, formatTime(key.Occurrence), key.MinutesBefore); err != nil {
+		return fmt.Errorf("release reminder: %w", err)
+	}
+	return nil
+}
+
+func (sr *SQLiteRepository) CreateWebhook(ctx context.Context, hook Webhook) (Webhook, error) {
+	events, err := json.Marshal(webhookEvents(hook.Events))
+	if err != nil {
+		return Webhook{}, fmt.Errorf("encode webhook events: %w", err)
+	}
+
+	hook.CreatedAt = time.Now()
+	res, err := sr.db.ExecContext(ctx, `INSERT INTO webhooks (user_id, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?)`,
+		hook.UserID, hook.URL, hook.Secret, string(events), formatTime(hook.CreatedAt))
+	if err != nil {
+		return Webhook{}, fmt.Errorf("insert webhook: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Webhook{}, fmt.Errorf("insert webhook: %w", err)
+	}
+	hook.ID = int(id)
+
+	return hook, nil
+}
+
+func (sr *SQLiteRepository) GetWebhook(ctx context.Context, webhookID, userID int) (Webhook, error) {
+	hook, err := scanWebhook(sr.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks
+		WHERE id = ? AND user_id = ?`, webhookID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	if err != nil {
+		return Webhook{}, fmt.Errorf("get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+func (sr *SQLiteRepository) ListWebhooks(ctx context.Context, userID int) ([]Webhook, error) {
+	rows, err := sr.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook: %w", err)
+		}
+		result = append(result, hook)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr *SQLiteRepository) DeleteWebhook(ctx context.Context, webhookID, userID int) error {
+	res, err := sr.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ? AND user_id = ?`, webhookID, userID)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (sr *SQLiteRepository) CreateCalendar(ctx context.Context, cal Calendar) (Calendar, error) {
+	cal.CreatedAt = time.Now()
+	res, err := sr.db.ExecContext(ctx, `INSERT INTO calendars (user_id, name, created_at) VALUES (?, ?, ?)`,
+		cal.UserID, cal.Name, formatTime(cal.CreatedAt))
+	if err != nil {
+		return Calendar{}, fmt.Errorf("insert calendar: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Calendar{}, fmt.Errorf("insert calendar: %w", err)
+	}
+	cal.ID = int(id)
+	cal.Role = RoleOwner
+
+	return cal, nil
+}
+
+func (sr *SQLiteRepository) GetCalendar(ctx context.Context, calendarID, userID int) (Calendar, error) {
+	cal, err := scanCalendar(sr.db.QueryRowContext(ctx, `SELECT c.id, c.user_id, c.name, c.created_at,
+			CASE WHEN c.user_id = ? THEN 'owner' ELSE s.role END
+		FROM calendars c
+		LEFT JOIN calendar_shares s ON s.calendar_id = c.id AND s.user_id = ?
+		WHERE c.id = ? AND (c.user_id = ? OR s.user_id IS NOT NULL)`,
+		userID, userID, calendarID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Calendar{}, ErrCalendarNotFound
+	}
+	if err != nil {
+		return Calendar{}, fmt.Errorf("get calendar: %w", err)
+	}
+	return cal, nil
+}
+
+func (sr *SQLiteRepository) ListCalendars(ctx context.Context, userID int) ([]Calendar, error) {
+	rows, err := sr.db.QueryContext(ctx, `SELECT id, user_id, name, created_at, 'owner' FROM calendars
+		WHERE user_id = ?
+		UNION ALL
+		SELECT c.id, c.user_id, c.name, c.created_at, s.role FROM calendars c
+		JOIN calendar_shares s ON s.calendar_id = c.id
+		WHERE s.user_id = ?
+		ORDER BY 1`,
+		userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query calendars: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Calendar
+	for rows.Next() {
+		cal, err := scanCalendar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan calendar: %w", err)
+		}
+		result = append(result, cal)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr *SQLiteRepository) UpdateCalendar(ctx context.Context, cal Calendar) (Calendar, error) {
+	updated, err := scanCalendar(sr.db.QueryRowContext(ctx, `UPDATE calendars SET name = ?
+		WHERE id = ? AND user_id = ?
+		RETURNING id, user_id, name, created_at, 'owner'`,
+		cal.Name, cal.ID, cal.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Calendar{}, ErrCalendarNotFound
+	}
+	if err != nil {
+		return Calendar{}, fmt.Errorf("update calendar: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteCalendar удаляет календарь и его события в одной транзакции; доступы
+// к календарю удаляются каскадно.
+func (sr *SQLiteRepository) DeleteCalendar(ctx context.Context, calendarID, userID int) error {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM calendars WHERE id = ? AND user_id = ?`, calendarID, userID)
+	if err != nil {
+		return fmt.Errorf("delete calendar: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete calendar: %w", err)
+	}
+	if affected == 0 {
+		return ErrCalendarNotFound
+	}
+
+	// Замены вхождений лежат в календаре своей серии.
+	events, err := selectEvents(ctx, tx, `SELECT `+eventColumns+` FROM events WHERE user_id = ? AND calendar_id = ?`,
+		userID, calendarID)
+	if err != nil {
+		return err
+	}
+	if err := trashEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := recordHistory(ctx, tx, HistoryDeleted, &event, nil); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM events WHERE user_id = ? AND calendar_id = ?`, userID, calendarID); err != nil {
+		return fmt.Errorf("delete calendar events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("delete calendar: %w", err)
+	}
+
+	sr.log.Info("Calendar deleted",
+		"calendar_id", calendarID,
+		"user_id", userID,
+	)
+
+	return nil
+}
+
+func (sr *SQLiteRepository) ShareCalendar(ctx context.Context, share CalendarShare) (CalendarShare, error) {
+	var exists int
+	err := sr.db.QueryRowContext(ctx, `SELECT 1 FROM calendars WHERE id = ?`, share.CalendarID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CalendarShare{}, ErrCalendarNotFound
+	}
+	if err != nil {
+		return CalendarShare{}, fmt.Errorf("check calendar: %w", err)
+	}
+
+	var createdAt string
+	err = sr.db.QueryRowContext(ctx, `INSERT INTO calendar_shares (calendar_id, user_id, role, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (calendar_id, user_id) DO UPDATE SET role = excluded.role
+		RETURNING created_at`,
+		share.CalendarID, share.UserID, share.Role, formatTime(time.Now())).Scan(&createdAt)
+	if err != nil {
+		return CalendarShare{}, fmt.Errorf("share calendar: %w", err)
+	}
+
+	if share.CreatedAt, err = parseTime(createdAt); err != nil {
+		return CalendarShare{}, err
+	}
+	return share, nil
+}
+
+func (sr *SQLiteRepository) UnshareCalendar(ctx context.Context, calendarID, userID int) error {
+	res, err := sr.db.ExecContext(ctx, `DELETE FROM calendar_shares WHERE calendar_id = ? AND user_id = ?`, calendarID, userID)
+	if err != nil {
+		return fmt.Errorf("unshare calendar: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unshare calendar: %w", err)
+	}
+	if affected == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+func (sr *SQLiteRepository) ListCalendarShares(ctx context.Context, calendarID int) ([]CalendarShare, error) {
+	var exists int
+	err := sr.db.QueryRowContext(ctx, `SELECT 1 FROM calendars WHERE id = ?`, calendarID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCalendarNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("check calendar: %w", err)
+	}
+
+	rows, err := sr.db.QueryContext(ctx, `SELECT calendar_id, user_id, role, created_at FROM calendar_shares
+		WHERE calendar_id = ? ORDER BY user_id`, calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("query calendar shares: %w", err)
+	}
+	defer rows.Close()
+
+	var result []CalendarShare
+	for rows.Next() {
+		var (
+			share     CalendarShare
+			createdAt string
+		)
+		if err := rows.Scan(&share.CalendarID, &share.UserID, &share.Role, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan calendar share: %w", err)
+		}
+		if share.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, err
+		}
+		result = append(result, share)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr *SQLiteRepository) RestoreEvent(ctx context.Context, event Event) (Event, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Event{}, err
+	}
+	defer tx.Rollback()
+
+	restored, err := changeEvent(ctx, tx, HistoryRestored, event)
+	if errors.Is(err, ErrEventNotFound) {
+		restored, err = reinsertEvent(ctx, tx, event)
+		if err == nil {
+			err = recordHistory(ctx, tx, HistoryRestored, nil, &restored)
+		}
+	}
+	if err != nil {
+		return Event{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Event{}, fmt.Errorf("restore event: %w", err)
+	}
+
+	sr.log.Info("Event restored",
+		"event_id", restored.ID,
+		"user_id", restored.UserID,
+		"version", restored.Version,
+	)
+
+	return restored, nil
+}
+
+const historyColumns = `id, user_id, event_id, action, actor_id, request_id, old_event, new_event, created_at`
+
+func (sr *SQLiteRepository) AddHistory(ctx context.Context, entry HistoryEntry) (HistoryEntry, error) {
+	return insertHistory(ctx, sr.db, entry)
+}
+
+func (sr *SQLiteRepository) ListHistory(ctx context.Context, query HistoryQuery) ([]HistoryEntry, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := sr.db.QueryContext(ctx, `SELECT `+historyColumns+` FROM event_history
+		WHERE user_id = ? AND (? = 0 OR event_id = ?) AND (? = 0 OR id < ?)
+		ORDER BY id DESC
+		LIMIT ?`,
+		query.UserID, query.EventID, query.EventID, query.BeforeID, query.BeforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query history: %w", err)
+	}
+	defer rows.Close()
+
+	var result []HistoryEntry
+	for rows.Next() {
+		entry, err := scanHistoryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan history: %w", err)
+		}
+		result = append(result, entry)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr *SQLiteRepository) ListTrash(ctx context.Context, userID int) ([]TrashedEvent, error) {
+	rows, err := sr.db.QueryContext(ctx, `SELECT event, deleted_at FROM event_trash
+		WHERE user_id = ? ORDER BY deleted_at DESC, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query trash: %w", err)
+	}
+	defer rows.Close()
+
+	var result []TrashedEvent
+	for rows.Next() {
+		trashed, err := scanTrashedEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan trash: %w", err)
+		}
+		result = append(result, trashed)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr *SQLiteRepository) GetTrashedEvent(ctx context.Context, eventID, userID int) (TrashedEvent, error) {
+	trashed, err := scanTrashedEvent(sr.db.QueryRowContext(ctx, `SELECT event, deleted_at FROM event_trash
+		WHERE id = ? AND user_id = ?`, eventID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return TrashedEvent{}, ErrEventNotFound
+	}
+	if err != nil {
+		return TrashedEvent{}, fmt.Errorf("get trashed event: %w", err)
+	}
+
+	return trashed, nil
+}
+
+func (sr *SQLiteRepository) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_attendees
+		WHERE event_id IN (SELECT id FROM event_trash WHERE deleted_at < ?)`, formatTime(before)); err != nil {
+		return 0, fmt.Errorf("purge attendees: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reminder_deliveries
+		WHERE event_id IN (SELECT id FROM event_trash WHERE deleted_at < ?)`, formatTime(before)); err != nil {
+		return 0, fmt.Errorf("purge reminder deliveries: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM event_trash WHERE deleted_at < ?`, formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("purge trash: %w", err)
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge trash: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("purge trash: %w", err)
+	}
+
+	if purged > 0 {
+		sr.log.Info("Trash purged",
+			"events", purged,
+		)
+	}
+
+	return int(purged), nil
+}
+
+const attendeeColumns = `event_id, organizer_id, user_id, status, invited_at, responded_at`
+
+func (sr *SQLiteRepository) AddAttendee(ctx context.Context, attendee Attendee) (Attendee, error) {
+	var exists int
+	err := sr.db.QueryRowContext(ctx, `SELECT 1 FROM events WHERE id = ? AND user_id = ?`,
+		attendee.EventID, attendee.OrganizerID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attendee{}, ErrEventNotFound
+	}
+	if err != nil {
+		return Attendee{}, fmt.Errorf("check event: %w", err)
+	}
+
+	if _, err := sr.db.ExecContext(ctx, `INSERT INTO event_attendees (event_id, organizer_id, user_id, status, invited_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (event_id, user_id) DO NOTHING`,
+		attendee.EventID, attendee.OrganizerID, attendee.UserID, RSVPNeedsAction, formatTime(time.Now())); err != nil {
+		return Attendee{}, fmt.Errorf("add attendee: %w", err)
+	}
+
+	return sr.getAttendee(ctx, attendee.EventID, attendee.UserID)
+}
+
+func (sr *SQLiteRepository) RemoveAttendee(ctx context.Context, eventID, userID int) error {
+	res, err := sr.db.ExecContext(ctx, `DELETE FROM event_attendees WHERE event_id = ? AND user_id = ?`, eventID, userID)
+	if err != nil {
+		return fmt.Errorf("remove attendee: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("remove attendee: %w", err)
+	}
+	if affected == 0 {
+		return ErrAttendeeNotFound
+	}
+	return nil
+}
+
+func (sr *SQLiteRepository) ListAttendees(ctx context.Context, eventID int) ([]Attendee, error) {
+	return sr.queryAttendees(ctx, `SELECT `+attendeeColumns+` FROM event_attendees
+		WHERE event_id = ? ORDER BY user_id`, eventID)
+}
+
+func (sr *SQLiteRepository) SetAttendeeStatus(ctx context.Context, eventID, userID int, status string) (Attendee, error) {
+	res, err := sr.db.ExecContext(ctx, `UPDATE event_attendees SET status = ?, responded_at = ?
+		WHERE event_id = ? AND user_id = ?`, status, formatTime(time.Now()), eventID, userID)
+	if err != nil {
+		return Attendee{}, fmt.Errorf("set attendee status: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return Attendee{}, fmt.Errorf("set attendee status: %w", err)
+	}
+	if affected == 0 {
+		return Attendee{}, ErrAttendeeNotFound
+	}
+
+	return sr.getAttendee(ctx, eventID, userID)
+}
+
+func (sr *SQLiteRepository) ResetAttendees(ctx context.Context, eventID int) error {
+	if _, err := sr.db.ExecContext(ctx, `UPDATE event_attendees SET status = ?, responded_at = NULL
+		WHERE event_id = ?`, RSVPNeedsAction, eventID); err != nil {
+		return fmt.Errorf("reset attendees: %w", err)
+	}
+	return nil
+}
+
+func (sr *SQLiteRepository) ListInvitations(ctx context.Context, userID int) ([]Attendee, error) {
+	return sr.queryAttendees(ctx, `SELECT `+attendeeColumns+` FROM event_attendees
+		WHERE user_id = ? ORDER BY event_id`, userID)
+}
+
+func (sr *SQLiteRepository) getAttendee(ctx context.Context, eventID, userID int) (Attendee, error) {
+	attendee, err := scanAttendee(sr.db.QueryRowContext(ctx, `SELECT `+attendeeColumns+` FROM event_attendees
+		WHERE event_id = ? AND user_id = ?`, eventID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attendee{}, ErrAttendeeNotFound
+	}
+	if err != nil {
+		return Attendee{}, fmt.Errorf("get attendee: %w", err)
+	}
+	return attendee, nil
+}
+
+func (sr *SQLiteRepository) queryAttendees(ctx context.Context, query string, args ...any) ([]Attendee, error) {
+	rows, err := sr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query attendees: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Attendee
+	for rows.Next() {
+		attendee, err := scanAttendee(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan attendee: %w", err)
+		}
+		result = append(result, attendee)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr *SQLiteRepository) Ping(ctx context.Context) error {
+	if err := sr.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping sqlite: %w", err)
+	}
+	return nil
+}
+
+func (sr *SQLiteRepository) Close() error {
+	return sr.db.Close()
+}
+
+// eventsBetween выбирает отдельные события, пересекающиеся с [from, to), по тем же
+// правилам, что и Event.Overlaps.
+func (sr *SQLiteRepository) eventsBetween(ctx context.Context, userID int, from, to time.Time) ([]Event, error) {
+	floatingFrom, floatingTo := FloatingDate(from), FloatingDate(to)
+	return sr.queryEvents(ctx, `SELECT `+eventColumns+` FROM events
+		WHERE user_id = ? AND recurrence = '' AND (
+			(all_day = 0 AND date < ? AND (end_date > ? OR date >= ?)) OR
+			(all_day = 1 AND date < ? AND (end_date > ? OR date >= ?)))
+		ORDER BY date, id`,
+		userID,
+		formatTime(to), formatTime(from), formatTime(from),
+		formatTime(floatingTo), formatTime(floatingFrom), formatTime(floatingFrom))
+}
+
+func (sr *SQLiteRepository) queryEvents(ctx context.Context, query string, args ...any) ([]Event, error) {
+	return selectEvents(ctx, sr.db, query, args...)
+}
+
+func selectEvents(ctx context.Context, q rowsQueryer, query string, args ...any) ([]Event, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		result = append(result, event)
+	}
+
+	return result, rows.Err()
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type rowsQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func insertEvent(ctx context.Context, q queryer, event Event) (Event, error) {
+	exDates, err := formatExDates(event.ExDates)
+	if err != nil {
+		return Event{}, err
+	}
+
+	reminders, err := formatReminders(event.Reminders)
+	if err != nil {
+		return Event{}, err
+	}
+
+	var recurringEventID, originalDate any
+	if event.RecurringEventID != 0 {
+		recurringEventID = event.RecurringEventID
+	}
+	if event.OriginalDate != nil {
+		originalDate = formatTime(*event.OriginalDate)
+	}
+
+	if event.UID == "" {
+		event.UID = newUID()
+	}
+
+	now := formatTime(time.Now())
+	row := q.QueryRowContext(ctx, `INSERT INTO events (uid, user_id, calendar_id, title, date, end_date, all_day, timezone,
+			recurrence, exdates, recurring_event_id, original_date, reminders, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING `+eventColumns,
+		event.UID, event.UserID, event.CalendarID, event.Title, formatTime(event.Date), formatTime(event.EndTime()), event.AllDay,
+		timeZoneName(event), event.Recurrence, exDates, recurringEventID, originalDate, reminders, now, now)
+
+	return scanEvent(row)
+}
+
+func updateEvent(ctx context.Context, q queryer, event Event) (Event, error) {
+	exDates, err := formatExDates(event.ExDates)
+	if err != nil {
+		return Event{}, err
+	}
+
+	reminders, err := formatReminders(event.Reminders)
+	if err != nil {
+		return Event{}, err
+	}
+
+	row := q.QueryRowContext(ctx, `UPDATE events SET title = ?, date = ?, end_date = ?, all_day = ?, timezone = ?,
+			recurrence = ?, exdates = ?, reminders = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND (? = 0 OR version = ?)
+		RETURNING `+eventColumns,
+		event.Title, formatTime(event.Date), formatTime(event.EndTime()), event.AllDay, timeZoneName(event),
+		event.Recurrence, exDates, reminders, formatTime(time.Now()), event.ID, event.UserID,
+		event.Version, event.Version)
+
+	updated, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, missingOrModified(ctx, q, event.ID, event.UserID)
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("update event: %w", err)
+	}
+
+	return updated, nil
+}
+
+// deleteEvent удаляет событие и возвращает его вместе с заменами вхождений
+// серии, которые удаляются каскадно. Удалённые события попадают в корзину и
+// в историю.
+func deleteEvent(ctx context.Context, tx *sql.Tx, eventID, userID, version int) ([]Event, error) {
+	overrides, err := selectEvents(ctx, tx, `SELECT `+eventColumns+` FROM events
+		WHERE recurring_event_id = ? AND user_id = ?`, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := tx.QueryRowContext(ctx, `DELETE FROM events WHERE id = ? AND user_id = ? AND (? = 0 OR version = ?)
+		RETURNING `+eventColumns,
+		eventID, userID, version, version)
+
+	deleted, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, missingOrModified(ctx, tx, eventID, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("delete event: %w", err)
+	}
+
+	removed := append([]Event{deleted}, overrides...)
+	if err := trashEvents(ctx, tx, removed); err != nil {
+		return nil, err
+	}
+	for _, event := range removed {
+		if err := recordHistory(ctx, tx, HistoryDeleted, &event, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return removed, nil
+}
+
+func trashEvents(ctx context.Context, tx *sql.Tx, events []Event) error {
+	deletedAt := formatTime(time.Now())
+	for _, event := range events {
+		snapshot, err := formatSnapshot(&event)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO event_trash (id, user_id, event, deleted_at)
+			VALUES (?, ?, ?, ?)`, event.ID, event.UserID, snapshot, deletedAt); err != nil {
+			return fmt.Errorf("trash event: %w", err)
+		}
+	}
+	return nil
+}
+
+func excludeOccurrence(ctx context.Context, tx *sql.Tx, eventID, userID int, occurrence time.Time, version int) (Event, error) {
+	series, err := scanEvent(tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events
+		WHERE id = ? AND user_id = ? AND recurrence != ''`, eventID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, ErrEventNotFound
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("get series: %w", err)
+	}
+	if err := checkVersion(series, version); err != nil {
+		return Event{}, err
+	}
+
+	if series.IsExcluded(occurrence) {
+		return series, nil
+	}
+
+	exDates, err := formatExDates(append(series.ExDates, occurrence))
+	if err != nil {
+		return Event{}, err
+	}
+
+	updated, err := scanEvent(tx.QueryRowContext(ctx, `UPDATE events SET exdates = ?, updated_at = ?, version = version + 1
+		WHERE id = ?
+		RETURNING `+eventColumns,
+		exDates, formatTime(time.Now()), eventID))
+	if err != nil {
+		return Event{}, fmt.Errorf("update exdates: %w", err)
+	}
+	if err := recordHistory(ctx, tx, HistoryUpdated, &series, &updated); err != nil {
+		return Event{}, err
+	}
+
+	return series, nil
+}
+
+// reinsertEvent возвращает удалённое событие под прежним ID со следующей версией
+// и убирает его из корзины.
+func reinsertEvent(ctx context.Context, tx *sql.Tx, event Event) (Event, error) {
+	var exists int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM events WHERE id = ?`, event.ID).Scan(&exists)
+	if err == nil {
+		return Event{}, ErrEventExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Event{}, fmt.Errorf("check event: %w", err)
+	}
+
+	var recurringEventID, originalDate any
+	if event.RecurringEventID != 0 {
+		err := tx.QueryRowContext(ctx, `SELECT 1 FROM events WHERE id = ? AND user_id = ? AND recurrence != ''`,
+			event.RecurringEventID, event.UserID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Event{}, ErrEventNotFound
+		}
+		if err != nil {
+			return Event{}, fmt.Errorf("check series: %w", err)
+		}
+		recurringEventID = event.RecurringEventID
+	}
+	if event.OriginalDate != nil {
+		originalDate = formatTime(*event.OriginalDate)
+	}
+
+	exDates, err := formatExDates(event.ExDates)
+	if err != nil {
+		return Event{}, err
+	}
+
+	reminders, err := formatReminders(event.Reminders)
+	if err != nil {
+		return Event{}, err
+	}
+
+	row := tx.QueryRowContext(ctx, `INSERT INTO events (id, uid, user_id, calendar_id, title, date, end_date, all_day, timezone,
+			recurrence, exdates, recurring_event_id, original_date, reminders, created_at, updated_at, version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING `+eventColumns,
+		event.ID, event.UID, event.UserID, event.CalendarID, event.Title, formatTime(event.Date), formatTime(event.EndTime()),
+		event.AllDay, timeZoneName(event), event.Recurrence, exDates, recurringEventID, originalDate, reminders,
+		formatTime(event.CreatedAt), formatTime(time.Now()), event.Version+1)
+
+	restored, err := scanEvent(row)
+	if isUniqueViolation(err) {
+		return Event{}, ErrEventExists
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("reinsert event: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM event_trash WHERE id = ? AND user_id = ?`, event.ID, event.UserID); err != nil {
+		return Event{}, fmt.Errorf("remove event from trash: %w", err)
+	}
+
+	return restored, nil
+}
+
+// changeEvent изменяет событие как updateEvent и записывает изменение в историю
+// с состоянием до него, прочитанным в той же транзакции.
+func changeEvent(ctx context.Context, tx *sql.Tx, action string, event Event) (Event, error) {
+	current, err := scanEvent(tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = ? AND user_id = ?`,
+		event.ID, event.UserID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Event{}, ErrEventNotFound
+	}
+	if err != nil {
+		return Event{}, fmt.Errorf("get event: %w", err)
+	}
+
+	updated, err := updateEvent(ctx, tx, event)
+	if err != nil {
+		return Event{}, err
+	}
+	if err := recordHistory(ctx, tx, action, &current, &updated); err != nil {
+		return Event{}, err
+	}
+
+	return updated, nil
+}
+
+func recordHistory(ctx context.Context, q queryer, action string, before, after *Event) error {
+	_, err := insertHistory(ctx, q, newHistoryEntry(ctx, action, before, after))
+	return err
+}
+
+func insertHistory(ctx context.Context, q queryer, entry HistoryEntry) (HistoryEntry, error) {
+	oldEvent, err := formatSnapshot(entry.Old)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	newEvent, err := formatSnapshot(entry.New)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	err = q.QueryRowContext(ctx, `INSERT INTO event_history (user_id, event_id, action, actor_id, request_id,
+			old_event, new_event, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		entry.UserID, entry.EventID, entry.Action, entry.ActorID, entry.RequestID,
+		oldEvent, newEvent, formatTime(entry.Time)).Scan(&entry.ID)
+	if err != nil {
+		return HistoryEntry{}, fmt.Errorf("insert history: %w", err)
+	}
+
+	return entry, nil
+}
+
+// missingOrModified объясняет, почему условное изменение не затронуло ни одной
+// строки: события нет или его версия уже другая.
+func missingOrModified(ctx context.Context, q queryer, eventID, userID int) error {
+	var exists int
+	err := q.QueryRowContext(ctx, `SELECT 1 FROM events WHERE id = ? AND user_id = ?`, eventID, userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("check event: %w", err)
+	}
+	return ErrVersionMismatch
+}
+
+const webhookColumns = `id, user_id, url, secret, events, created_at`
+
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var (
+		hook      Webhook
+		events    string
+		createdAt string
+	)
+	if err := row.Scan(&hook.ID, &hook.UserID, &hook.URL, &hook.Secret, &events, &createdAt); err != nil {
+		return Webhook{}, err
+	}
+
+	if err := json.Unmarshal([]byte(events), &hook.Events); err != nil {
+		return Webhook{}, fmt.Errorf("decode webhook events: %w", err)
+	}
+
+	var err error
+	if hook.CreatedAt, err = parseTime(createdAt); err != nil {
+		return Webhook{}, err
+	}
+	return hook, nil
+}
+
+func scanHistoryEntry(row rowScanner) (HistoryEntry, error) {
+	var (
+		entry              HistoryEntry
+		oldEvent, newEvent sql.NullString
+		createdAt          string
+	)
+
+	if err := row.Scan(&entry.ID, &entry.UserID, &entry.EventID, &entry.Action, &entry.ActorID, &entry.RequestID,
+		&oldEvent, &newEvent, &createdAt); err != nil {
+		return HistoryEntry{}, err
+	}
+
+	var err error
+	if entry.Old, err = parseSnapshot(oldEvent); err != nil {
+		return HistoryEntry{}, err
+	}
+	if entry.New, err = parseSnapshot(newEvent); err != nil {
+		return HistoryEntry{}, err
+	}
+	if entry.Time, err = parseTime(createdAt); err != nil {
+		return HistoryEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func scanTrashedEvent(row rowScanner) (TrashedEvent, error) {
+	var snapshot sql.NullString
+	var deletedAt string
+	if err := row.Scan(&snapshot, &deletedAt); err != nil {
+		return TrashedEvent{}, err
+	}
+
+	event, err := parseSnapshot(snapshot)
+	if err != nil {
+		return TrashedEvent{}, err
+	}
+
+	trashed := TrashedEvent{Event: *event}
+	if trashed.DeletedAt, err = parseTime(deletedAt); err != nil {
+		return TrashedEvent{}, err
+	}
+	return trashed, nil
+}
+
+func scanAttendee(row rowScanner) (Attendee, error) {
+	var (
+		attendee    Attendee
+		invitedAt   string
+		respondedAt sql.NullString
+	)
+	if err := row.Scan(&attendee.EventID, &attendee.OrganizerID, &attendee.UserID, &attendee.Status,
+		&invitedAt, &respondedAt); err != nil {
+		return Attendee{}, err
+	}
+
+	var err error
+	if attendee.InvitedAt, err = parseTime(invitedAt); err != nil {
+		return Attendee{}, err
+	}
+	if respondedAt.Valid {
+		responded, err := parseTime(respondedAt.String)
+		if err != nil {
+			return Attendee{}, err
+		}
+		attendee.RespondedAt = &responded
+	}
+	return attendee, nil
+}
+
+func scanCalendar(row rowScanner) (Calendar, error) {
+	var (
+		cal       Calendar
+		createdAt string
+	)
+	if err := row.Scan(&cal.ID, &cal.UserID, &cal.Name, &createdAt, &cal.Role); err != nil {
+		return Calendar{}, err
+	}
+
+	var err error
+	if cal.CreatedAt, err = parseTime(createdAt); err != nil {
+		return Calendar{}, err
+	}
+	return cal, nil
+}
+
+func webhookEvents(events []string) []string {
+	if events == nil {
+		return []string{}
+	}
+	return events
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row rowScanner) (Event, error) {
+	var (
+		event                Event
+		date, end, createdAt string
+		updatedAt, exDates   string
+		reminders            string
+		recurringEventID     sql.NullInt64
+		originalDate         sql.NullString
+	)
+
+	if err := row.Scan(&event.ID, &event.UID, &event.UserID, &event.Title, &date, &end, &event.AllDay,
+		&event.TimeZone, &event.Recurrence, &exDates,
+		&recurringEventID, &originalDate, &reminders, &createdAt, &updatedAt, &event.Version, &event.CalendarID); err != nil {
+		return Event{}, err
+	}
+
+	var err error
+	if event.Date, err = parseTime(date); err != nil {
+		return Event{}, err
+	}
+	if event.End, err = parseTime(end); err != nil {
+		return Event{}, err
+	}
+	if event.CreatedAt, err = parseTime(createdAt); err != nil {
+		return Event{}, err
+	}
+	if event.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return Event{}, err
+	}
+	if event.ExDates, err = parseExDates(exDates); err != nil {
+		return Event{}, err
+	}
+	if event.Reminders, err = parseReminders(reminders); err != nil {
+		return Event{}, err
+	}
+
+	event.RecurringEventID = int(recurringEventID.Int64)
+	if originalDate.Valid {
+		original, err := parseTime(originalDate.String)
+		if err != nil {
+			return Event{}, err
+		}
+		event.OriginalDate = &original
+	}
+
+	return inLocation(event), nil
+}
+
+// inLocation переводит времена события в его часовой пояс, чтобы серии
+// разворачивались по местному времени, а не по UTC. События на весь день
+// остаются в UTC.
+func inLocation(event Event) Event {
+	if event.AllDay {
+		return event
+	}
+
+	loc, err := time.LoadLocation(event.TimeZone)
+	if err != nil {
+		return event
+	}
+
+	event.Date = event.Date.In(loc)
+	event.End = event.End.In(loc)
+	for i := range event.ExDates {
+		event.ExDates[i] = event.ExDates[i].In(loc)
+	}
+	if event.OriginalDate != nil {
+		original := event.OriginalDate.In(loc)
+		event.OriginalDate = &original
+	}
+
+	return event
+}
+
+func timeZoneName(event Event) string {
+	if event.TimeZone == "" {
+		return "UTC"
+	}
+	return event.TimeZone
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(sqliteTimeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse time %q: %w", value, err)
+	}
+	return t, nil
+}
+
+func formatExDates(exDates []time.Time) (string, error) {
+	values := make([]string, 0, len(exDates))
+	for _, exDate := range exDates {
+		values = append(values, formatTime(exDate))
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("encode exdates: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseExDates(value string) ([]time.Time, error) {
+	var values []string
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return nil, fmt.Errorf("decode exdates: %w", err)
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	exDates := make([]time.Time, 0, len(values))
+	for _, v := range values {
+		exDate, err := parseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		exDates = append(exDates, exDate)
+	}
+	return exDates, nil
+}
+
+// formatSnapshot хранит состояние события в истории и корзине как JSON; nil — состояния нет.
+func formatSnapshot(event *Event) (any, error) {
+	if event == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("encode event snapshot: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseSnapshot(value sql.NullString) (*Event, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+
+	var event Event
+	if err := json.Unmarshal([]byte(value.String), &event); err != nil {
+		return nil, fmt.Errorf("decode event snapshot: %w", err)
+	}
+	event = inLocation(event)
+	return &event, nil
+}
+
+func formatReminders(reminders []int) (string, error) {
+	if reminders == nil {
+		reminders = []int{}
+	}
+
+	data, err := json.Marshal(reminders)
+	if err != nil {
+		return "", fmt.Errorf("encode reminders: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseReminders(value string) ([]int, error) {
+	var reminders []int
+	if err := json.Unmarshal([]byte(value), &reminders); err != nil {
+		return nil, fmt.Errorf("decode reminders: %w", err)
+	}
+
+	if len(reminders) == 0 {
+		return nil, nil
+	}
+	return reminders, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteRepository_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	date := time.Date(2025, 9, 1, 10, 30, 0, 0, time.UTC)
+
+	repo, err := NewSQLiteRepository(path, testLogger())
+	require.NoError(t, err)
+
+	created, err := repo.CreateEvent(t.Context(), Event{UserID: 1, Date: date, Title: "Persistent Event", Reminders: []int{10}})
+	require.NoError(t, err)
+	reminder := ReminderKey{EventID: created.ID, Occurrence: date, MinutesBefore: 10}
+	claimed, err := repo.ClaimReminder(t.Context(), reminder)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, repo.Close())
+
+	t.Run("events survive reopen", func(t *testing.T) {
+		reopened, err := NewSQLiteRepository(path, testLogger())
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		events, err := reopened.GetEventsForDay(t.Context(), 1, date)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, created.ID, events[0].ID)
+		assert.Equal(t, "Persistent Event", events[0].Title)
+		assert.True(t, date.Equal(events[0].Date))
+		assert.Equal(t, []int{10}, events[0].Reminders)
+	})
+
+	t.Run("sent reminders survive reopen", func(t *testing.T) {
+		reopened, err := NewSQLiteRepository(path, testLogger())
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		claimed, err := reopened.ClaimReminder(t.Context(), reminder)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+	})
+
+	t.Run("migrations are applied once", func(t *testing.T) {
+		reopened, err := NewSQLiteRepository(path, testLogger())
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		migrations, err := loadMigrations()
+		require.NoError(t, err)
+
+		var applied int
+		err = reopened.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied)
+		require.NoError(t, err)
+		assert.Equal(t, len(migrations), applied)
+	})
+}
+
+func TestSQLiteRepository_EventTimeMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	db, err := sql.Open("sqlite", "file:"+path)
+	require.NoError(t, err)
+
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`)
+	require.NoError(t, err)
+	for _, m := range migrations {
+		if m.version < 4 {
+			require.NoError(t, applyMigration(db, m))
+		}
+	}
+
+	now := formatTime(time.Now())
+	_, err = db.Exec(`INSERT INTO events (uid, user_id, title, date, created_at, updated_at) VALUES
+		('old', 1, 'Old event', ?, ?, ?),
+		('timed', 1, 'Timed event', ?, ?, ?)`,
+		formatTime(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)), now, now,
+		formatTime(time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)), now, now)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo, err := NewSQLiteRepository(path, testLogger())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	events, err := repo.GetUserEvents(t.Context(), 1)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.True(t, events[0].AllDay)
+	assert.Equal(t, time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC), events[0].End)
+	assert.Equal(t, "UTC", events[0].TimeZone)
+
+	assert.False(t, events[1].AllDay)
+	assert.True(t, events[1].Date.Equal(events[1].End))
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Storage описывает хранилище, от которого зависит сервис календаря: события,
+// календари, участники, история, корзина, напоминания и подписки.
+// Все операции, кроме Close, принимают контекст вызова с его отменой и трассой.
+type Storage interface {
+	EventStorage
+	CalendarStorage
+	AttendeeStorage
+	HistoryStorage
+	TrashStorage
+	ReminderStorage
+	WebhookStorage
+
+	// Ping проверяет, что хранилище доступно.
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// EventStorage хранит события пользователей.
+// CreateEvent присваивает событию UID, если он не задан, и возвращает
+// ErrEventExists, если у пользователя уже есть событие с таким UID.
+// Выборки за день, неделю, месяц и интервал [from, to) возвращают только
+// отдельные события; серии отдаёт GetRecurringEvents, а разворачивает их
+// сервис календаря.
+// Каждое изменение события увеличивает его Version. Изменения принимают
+// ожидаемую версию (в UpdateEvent — event.Version, у вхождений — версию серии)
+// и атомарно возвращают ErrVersionMismatch, если событие уже изменилось;
+// нулевая версия проверку отключает. Изменения записываются в историю самим
+// хранилищем, атомарно с изменением (см. history.go).
+// ApplyBatch применяет операции по порядку атомарно: при первой ошибке ни одна
+// операция не остаётся применённой, а ошибка оборачивается в *BatchError.
+// Для удаления возвращается удалённое событие.
+// CountEvents возвращает число всех хранимых событий, включая серии и замены вхождений.
+type EventStorage interface {
+	CreateEvent(ctx context.Context, event Event) (Event, error)
+	UpdateEvent(ctx context.Context, event Event) (Event, error)
+	DeleteEvent(ctx context.Context, eventID, userID, version int) error
+	GetEvent(ctx context.Context, eventID, userID int) (Event, error)
+	GetUserEvents(ctx context.Context, userID int) ([]Event, error)
+	GetEventsForDay(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	GetEventsForWeek(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	GetEventsForMonth(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	GetEventsBetween(ctx context.Context, userID int, from, to time.Time) ([]Event, error)
+	GetRecurringEvents(ctx context.Context, userID int, before time.Time) ([]Event, error)
+	DeleteOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, version int) error
+	ReplaceOccurrence(ctx context.Context, eventID, userID int, occurrence time.Time, override Event, version int) (Event, error)
+	ApplyBatch(ctx context.Context, ops []BatchOp) ([]Event, error)
+	CountEvents(ctx context.Context) (int, error)
+}
+
+// CalendarStorage хранит календари и доступы к ним.
+// Календари (Calendar) принадлежат пользователю UserID, события календаря
+// хранятся с UserID владельца и его CalendarID; нулевой CalendarID — события
+// вне календарей. GetCalendar и ListCalendars возвращают календари, которыми
+// пользователь владеет или которые ему открыты, с его ролью; UpdateCalendar
+// и DeleteCalendar меняют только календари владельца. DeleteCalendar удаляет
+// календарь вместе с его событиями и доступами.
+type CalendarStorage interface {
+	CreateCalendar(ctx context.Context, cal Calendar) (Calendar, error)
+	GetCalendar(ctx context.Context, calendarID, userID int) (Calendar, error)
+	ListCalendars(ctx context.Context, userID int) ([]Calendar, error)
+	UpdateCalendar(ctx context.Context, cal Calendar) (Calendar, error)
+	DeleteCalendar(ctx context.Context, calendarID, userID int) error
+	ShareCalendar(ctx context.Context, share CalendarShare) (CalendarShare, error)
+	UnshareCalendar(ctx context.Context, calendarID, userID int) error
+	ListCalendarShares(ctx context.Context, calendarID int) ([]CalendarShare, error)
+}
+
+// AttendeeStorage хранит участников событий.
+// Участники (Attendee) приглашаются на событие организатора: AddAttendee
+// возвращает ErrEventNotFound, если у организатора нет события, а для уже
+// приглашённого возвращает прежнюю запись. SetAttendeeStatus меняет ответ и,
+// как RemoveAttendee, возвращает ErrAttendeeNotFound для неприглашённого;
+// ResetAttendees сбрасывает ответы всех участников события.
+// ListInvitations возвращает приглашения пользователя. Участники остаются,
+// пока событие в корзине, и удаляются вместе с ним из корзины.
+type AttendeeStorage interface {
+	AddAttendee(ctx context.Context, attendee Attendee) (Attendee, error)
+	RemoveAttendee(ctx context.Context, eventID, userID int) error
+	ListAttendees(ctx context.Context, eventID int) ([]Attendee, error)
+	SetAttendeeStatus(ctx context.Context, eventID, userID int, status string) (Attendee, error)
+	ResetAttendees(ctx context.Context, eventID int) error
+	ListInvitations(ctx context.Context, userID int) ([]Attendee, error)
+}
+
+// HistoryStorage хранит историю изменений событий. AddHistory дописывает
+// запись явно, ListHistory читает историю от новых записей к старым; история
+// не меняется и не удаляется вместе с событием.
+type HistoryStorage interface {
+	AddHistory(ctx context.Context, entry HistoryEntry) (HistoryEntry, error)
+	ListHistory(ctx context.Context, query HistoryQuery) ([]HistoryEntry, error)
+}
+
+// TrashStorage хранит удалённые события до окончательного удаления.
+// RestoreEvent возвращает событие event.ID к содержимому event: существующее
+// событие меняется как в UpdateEvent, удалённое создаётся заново с тем же ID
+// и убирается из корзины.
+// event.Version — ожидаемая текущая версия, а для удалённого события — версия
+// перед удалением; восстановленное событие получает следующую.
+type TrashStorage interface {
+	RestoreEvent(ctx context.Context, event Event) (Event, error)
+	ListTrash(ctx context.Context, userID int) ([]TrashedEvent, error)
+	GetTrashedEvent(ctx context.Context, eventID, userID int) (TrashedEvent, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+}
+
+// ReminderStorage хранит отметки об отправленных напоминаниях.
+// ClaimReminder отмечает напоминание отправленным и возвращает false, если
+// оно уже было отмечено; ReleaseReminder снимает отметку после неудачной отправки.
+// Отметки остаются, пока событие в корзине, и удаляются вместе с ним из корзины.
+type ReminderStorage interface {
+	GetEventsWithReminders(ctx context.Context, startsAfter time.Time) ([]Event, error)
+	ClaimReminder(ctx context.Context, key ReminderKey) (bool, error)
+	ReleaseReminder(ctx context.Context, key ReminderKey) error
+}
+
+// WebhookStorage хранит подписки на изменения. Подписки (Webhook) принадлежат
+// пользователю: GetWebhook и DeleteWebhook возвращают ErrWebhookNotFound для
+// чужой подписки.
+type WebhookStorage interface {
+	CreateWebhook(ctx context.Context, hook Webhook) (Webhook, error)
+	GetWebhook(ctx context.Context, webhookID, userID int) (Webhook, error)
+	ListWebhooks(ctx context.Context, userID int) ([]Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID, userID int) error
+}
+
+var (
+	_ Storage = (*EventRepository)(nil)
+	_ Storage = (*SQLiteRepository)(nil)
+	_ Storage = (*instrumentedStorage)(nil)
+	_ Storage = (*publishingStorage)(nil)
+)
+
+// BatchError сообщает, на какой операции пакета остановилось его применение.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+func unknownBatchOp(kind string) error {
+	return fmt.Errorf("%w: unknown batch operation %q", ErrInvalidDataInput, kind)
+}
+
+// DayRange возвращает границы [начало, конец) дня, содержащего date, в часовом поясе date.
+func DayRange(date time.Time) (time.Time, time.Time) {
+	year, month, day := date.Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+// WeekRange возвращает границы ISO-недели (с понедельника), содержащей date.
+func WeekRange(date time.Time) (time.Time, time.Time) {
+	dayStart, _ := DayRange(date)
+	offset := (int(dayStart.Weekday()) + 6) % 7
+	start := dayStart.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// MonthRange возвращает границы календарного месяца, содержащего date.
+func MonthRange(date time.Time) (time.Time, time.Time) {
+	year, month, _ := date.Date()
+	start := time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// FloatingDate переносит календарную дату t в полночь UTC: так хранятся
+// события на весь день, не привязанные к часовому поясу.
+func FloatingDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newUID() string {
+	return uuid.New().String()
+}
@@ -1,31 +1,103 @@
 package event
 
 import (
+	"calendar/internal/recurrence"
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// MaxReminderMinutes — самое раннее напоминание: за четыре недели до начала.
+const MaxReminderMinutes = 4 * 7 * 24 * 60
+
+const (
+	// MaxRangeDays ограничивает интервал выборки: серии разворачиваются на весь интервал.
+	MaxRangeDays = 366
+
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+
+	// MaxBatchOperations ограничивает пакетный запрос: атомарный пакет
+	// держит хранилище заблокированным, пока применяется целиком.
+	MaxBatchOperations = 1000
+
+	// MaxFreeBusyUsers ограничивает число участников в запросе занятости.
+	MaxFreeBusyUsers = 50
+)
+
 var (
 	ErrInvalidUserID  = errors.New("userID must be positive integer")
 	ErrInvalidEventID = errors.New("eventID must be positive integer")
 	ErrInvalidDate    = errors.New("date must be in YYYY-MM-DD format")
+	ErrDateRequired   = errors.New("date parameter is required")
+	ErrInvalidPeriod  = errors.New("period must be one of day, week, month")
 	ErrEmptyTitle     = errors.New("title cannot be empty")
 	ErrTitleTooLong   = errors.New("title too long (max 255 characters)")
+
+	ErrInvalidDateTime = errors.New("date must be in YYYY-MM-DD, YYYY-MM-DDTHH:MM[:SS] or RFC 3339 format")
+	ErrInvalidEnd      = errors.New("end must be in the same format as date and not before it")
+	ErrInvalidDuration = errors.New("duration must be positive, e.g. 45m or 1h30m; whole days for all-day events")
+	ErrEndAndDuration  = errors.New("end and duration cannot be set together")
+	ErrInvalidTimeZone = errors.New("timezone must be an IANA time zone name, e.g. Europe/Moscow")
+	ErrAllDayWithTime  = errors.New("all-day events take dates without time of day")
+
+	ErrInvalidReminder = fmt.Errorf("reminders must be between 0 and %d minutes before start", MaxReminderMinutes)
+
+	ErrInvalidRange = fmt.Errorf("from and to must be dates or date-times, to after from, at most %d days apart", MaxRangeDays)
+	ErrInvalidLimit = fmt.Errorf("limit must be between 1 and %d", MaxPageLimit)
+	ErrInvalidSort  = errors.New("sort must be date or created_at")
+	ErrInvalidOrder = errors.New("order must be asc or desc")
+
+	ErrInvalidBatchSize = fmt.Errorf("operations must contain between 1 and %d items", MaxBatchOperations)
+	ErrInvalidBatchMode = errors.New("mode must be atomic or best_effort")
+	ErrInvalidBatchOp   = errors.New("op must be create, update or delete")
+
+	ErrInvalidWebhookURL    = errors.New("url must be an absolute http or https URL")
+	ErrInvalidWebhookEvents = errors.New("events must contain only created, updated or deleted")
+	ErrWebhookSecretTooLong = errors.New("secret too long (max 255 characters)")
+	ErrInvalidWebhookID     = errors.New("webhookID must be positive integer")
+
+	ErrInvalidCalendarID   = errors.New("calendarID must be positive integer")
+	ErrEmptyCalendarName   = errors.New("name cannot be empty")
+	ErrCalendarNameTooLong = errors.New("name too long (max 100 characters)")
+	ErrInvalidRole         = errors.New("role must be viewer or editor")
+	ErrShareWithOwner      = errors.New("calendar cannot be shared with its owner")
+
+	ErrInvalidRSVP     = errors.New("status must be accepted, declined or tentative")
+	ErrInviteOrganizer = errors.New("organizer cannot be invited to own event")
+
+	ErrInvalidUsers        = fmt.Errorf("users must be a comma-separated list of 1 to %d positive user IDs", MaxFreeBusyUsers)
+	ErrInvalidWorkingHours = errors.New("work_start and work_end must be HH:MM, work_start before work_end, work_end at most 24:00")
+	ErrInvalidSlotDuration = errors.New("duration must be positive and at most 24h, e.g. 30m or 1h30m")
+
+	ErrInvalidRecurrence        = errors.New("recurrence must be a valid RRULE")
+	ErrExDatesWithoutRecurrence = errors.New("exdates require recurrence")
+	ErrOccurrenceRecurrence     = errors.New("recurrence and exdates cannot be set for a single occurrence")
 )
 
+// EventTime — время события, разобранное из запроса.
+type EventTime struct {
+	Start    time.Time
+	End      time.Time
+	AllDay   bool
+	TimeZone string
+}
+
+var dateTimeLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05"}
+
 func ValidateCreateRequest(userID int, dateStr, title string) error {
 	if userID <= 0 {
 		return ErrInvalidUserID
 	}
 
-	if err := validateDate(dateStr); err != nil {
+	if _, _, err := parseDateTime(dateStr, time.UTC); err != nil {
 		return err
 	}
 
-	if err := validateTitle(title); err != nil {
+	if err := ValidateTitle(title); err != nil {
 		return err
 	}
 
@@ -41,11 +113,11 @@ func ValidateUpdateRequest(eventID, userID int, dateStr, title string) error {
 		return ErrInvalidUserID
 	}
 
-	if err := validateDate(dateStr); err != nil {
+	if _, _, err := parseDateTime(dateStr, time.UTC); err != nil {
 		return err
 	}
 
-	if err := validateTitle(title); err != nil {
+	if err := ValidateTitle(title); err != nil {
 		return err
 	}
 
@@ -64,6 +136,372 @@ func ValidateDeleteRequest(eventID, userID int) error {
 	return nil
 }
 
+// ParseAndValidateEventTime разбирает время события в часовом поясе timeZone
+// (по умолчанию UTC). Дата без времени означает событие на весь день; для него
+// end — последний день события включительно. Конец задаётся либо end, либо duration;
+// если не задано ни то, ни другое, событие на весь день длится один день,
+// а остальные не имеют длительности.
+func ParseAndValidateEventTime(dateStr, endStr, durationStr, timeZone string, allDay bool) (EventTime, error) {
+	loc, err := ParseTimeZone(timeZone)
+	if err != nil {
+		return EventTime{}, err
+	}
+
+	start, dateOnly, err := parseDateTime(dateStr, loc)
+	if err != nil {
+		return EventTime{}, err
+	}
+
+	if allDay && !dateOnly {
+		return EventTime{}, ErrAllDayWithTime
+	}
+	allDay = dateOnly
+
+	if endStr != "" && durationStr != "" {
+		return EventTime{}, ErrEndAndDuration
+	}
+
+	result := EventTime{Start: start, AllDay: allDay, TimeZone: loc.String()}
+	switch {
+	case endStr != "":
+		end, endDateOnly, err := parseDateTime(endStr, loc)
+		if err != nil || endDateOnly != allDay || end.Before(start) {
+			return EventTime{}, ErrInvalidEnd
+		}
+		if allDay {
+			end = end.AddDate(0, 0, 1)
+		}
+		result.End = end
+	case durationStr != "":
+		duration, err := time.ParseDuration(durationStr)
+		if err != nil || duration <= 0 {
+			return EventTime{}, ErrInvalidDuration
+		}
+		if allDay {
+			if duration%(24*time.Hour) != 0 {
+				return EventTime{}, ErrInvalidDuration
+			}
+			result.End = start.AddDate(0, 0, int(duration/(24*time.Hour)))
+		} else {
+			result.End = start.Add(duration)
+		}
+	case allDay:
+		result.End = start.AddDate(0, 0, 1)
+	default:
+		result.End = start
+	}
+
+	return result, nil
+}
+
+// ParseTimeZone загружает часовой пояс по имени IANA; пустое имя означает UTC.
+func ParseTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	// "Local" зависит от настроек сервера, а не от пользователя.
+	if name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+
+	return loc, nil
+}
+
+func ValidateRecurrence(rule string, exDates []string) error {
+	if rule == "" {
+		if len(exDates) > 0 {
+			return ErrExDatesWithoutRecurrence
+		}
+		return nil
+	}
+
+	if _, err := recurrence.Parse(rule); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+
+	for _, exDate := range exDates {
+		if err := validateDate(exDate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ValidateReminders(reminders []int) error {
+	for _, minutes := range reminders {
+		if minutes < 0 || minutes > MaxReminderMinutes {
+			return ErrInvalidReminder
+		}
+	}
+
+	return nil
+}
+
+func ValidateOccurrenceRequest(occurrenceDate, rule string, exDates []string) error {
+	if err := validateDate(occurrenceDate); err != nil {
+		return err
+	}
+
+	if rule != "" || len(exDates) > 0 {
+		return ErrOccurrenceRecurrence
+	}
+
+	return nil
+}
+
+func ValidateUserIDParam(userIDStr string) (int, error) {
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidUserID
+	}
+
+	return userID, nil
+}
+
+// ParseAndValidateRange разбирает интервал [from, to) в часовом поясе timeZone.
+// Если to — дата без времени, этот день входит в интервал целиком.
+func ParseAndValidateRange(fromStr, toStr, timeZone string) (time.Time, time.Time, error) {
+	loc, err := ParseTimeZone(timeZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	from, _, err := parseDateTime(fromStr, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+
+	to, toDateOnly, err := parseDateTime(toStr, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+	if toDateOnly {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	if !to.After(from) || to.After(from.AddDate(0, 0, MaxRangeDays)) {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+
+	return from, to, nil
+}
+
+// ParseAndValidateLimit разбирает размер страницы; пустое значение означает DefaultPageLimit.
+func ParseAndValidateLimit(limitStr string) (int, error) {
+	if limitStr == "" {
+		return DefaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > MaxPageLimit {
+		return 0, ErrInvalidLimit
+	}
+
+	return limit, nil
+}
+
+func ValidateSort(sortBy, order string) error {
+	if sortBy != "" && sortBy != "date" && sortBy != "created_at" {
+		return ErrInvalidSort
+	}
+
+	if order != "" && order != "asc" && order != "desc" {
+		return ErrInvalidOrder
+	}
+
+	return nil
+}
+
+func ValidateBatch(mode string, operations int) error {
+	if mode != "" && mode != "atomic" && mode != "best_effort" {
+		return ErrInvalidBatchMode
+	}
+
+	if operations == 0 || operations > MaxBatchOperations {
+		return ErrInvalidBatchSize
+	}
+
+	return nil
+}
+
+func ValidateWebhook(rawURL, secret string, events []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	if len(secret) > 255 {
+		return ErrWebhookSecretTooLong
+	}
+
+	for _, eventType := range events {
+		if eventType != "created" && eventType != "updated" && eventType != "deleted" {
+			return ErrInvalidWebhookEvents
+		}
+	}
+
+	return nil
+}
+
+func ValidateWebhookIDParam(webhookIDStr string) (int, error) {
+	webhookID, err := strconv.Atoi(webhookIDStr)
+	if err != nil || webhookID <= 0 {
+		return 0, ErrInvalidWebhookID
+	}
+
+	return webhookID, nil
+}
+
+func ValidateCalendarIDParam(calendarIDStr string) (int, error) {
+	calendarID, err := strconv.Atoi(calendarIDStr)
+	if err != nil || calendarID <= 0 {
+		return 0, ErrInvalidCalendarID
+	}
+
+	return calendarID, nil
+}
+
+// ValidateCalendarFilter разбирает необязательный параметр calendar_id;
+// 0 означает все календари.
+func ValidateCalendarFilter(calendarIDStr string) (int, error) {
+	if calendarIDStr == "" {
+		return 0, nil
+	}
+
+	return ValidateCalendarIDParam(calendarIDStr)
+}
+
+// ValidateCalendarID проверяет calendar_id в теле запроса; 0 — событие вне календарей.
+func ValidateCalendarID(calendarID int) error {
+	if calendarID < 0 {
+		return ErrInvalidCalendarID
+	}
+
+	return nil
+}
+
+func ValidateCalendarName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrEmptyCalendarName
+	}
+
+	if len(name) > 100 {
+		return ErrCalendarNameTooLong
+	}
+
+	return nil
+}
+
+func ValidateShare(ownerID, userID int, role string) error {
+	if userID == ownerID {
+		return ErrShareWithOwner
+	}
+
+	if role != "viewer" && role != "editor" {
+		return ErrInvalidRole
+	}
+
+	return nil
+}
+
+func ValidateRSVP(status string) error {
+	if status != "accepted" && status != "declined" && status != "tentative" {
+		return ErrInvalidRSVP
+	}
+
+	return nil
+}
+
+// ParseUserIDs разбирает список пользователей вида "1,2,3"; повторы отбрасываются.
+// Список длиннее MaxFreeBusyUsers отклоняется до разбора.
+func ParseUserIDs(usersStr string) ([]int, error) {
+	parts := strings.SplitN(usersStr, ",", MaxFreeBusyUsers+1)
+	if len(parts) > MaxFreeBusyUsers {
+		return nil, ErrInvalidUsers
+	}
+
+	var userIDs []int
+	seen := make(map[int]bool)
+	for _, part := range parts {
+		userID, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || userID <= 0 {
+			return nil, ErrInvalidUsers
+		}
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	return userIDs, nil
+}
+
+// ParseWorkingHours разбирает рабочие часы "HH:MM" как смещения от начала дня.
+// Пустые значения — весь день, с 00:00 до 24:00.
+func ParseWorkingHours(startStr, endStr string) (time.Duration, time.Duration, error) {
+	if startStr == "" {
+		startStr = "00:00"
+	}
+	if endStr == "" {
+		endStr = "24:00"
+	}
+
+	start, err := parseClock(startStr)
+	if err != nil {
+		return 0, 0, ErrInvalidWorkingHours
+	}
+
+	end, err := parseClock(endStr)
+	if err != nil || end <= start {
+		return 0, 0, ErrInvalidWorkingHours
+	}
+
+	return start, end, nil
+}
+
+func parseClock(clock string) (time.Duration, error) {
+	if clock == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseSlotDuration разбирает длину искомого окна; пустая строка — окна не нужны.
+func ParseSlotDuration(durationStr string) (time.Duration, error) {
+	if durationStr == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(durationStr)
+	if err != nil || d <= 0 || d > 24*time.Hour {
+		return 0, ErrInvalidSlotDuration
+	}
+
+	return d, nil
+}
+
+func ValidateEventIDParam(eventIDStr string) (int, error) {
+	eventID, err := strconv.Atoi(eventIDStr)
+	if err != nil || eventID <= 0 {
+		return 0, ErrInvalidEventID
+	}
+
+	return eventID, nil
+}
+
 func ValidateQueryParams(userIDStr, dateStr string) (int, error) {
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil || userID <= 0 {
@@ -101,7 +539,28 @@ func validateDate(dateStr string) error {
 	return nil
 }
 
-func validateTitle(title string) error {
+// parseDateTime разбирает дату или дату со временем. Время без смещения
+// считается местным временем loc; dateOnly сообщает, что время не указано.
+func parseDateTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if validateDate(value) == nil {
+		t, err = time.ParseInLocation("2006-01-02", value, loc)
+		return t, true, err
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t, false, nil
+		}
+	}
+
+	if t, err = time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), false, nil
+	}
+
+	return time.Time{}, false, ErrInvalidDateTime
+}
+
+func ValidateTitle(title string) error {
 	if strings.TrimSpace(title) == "" {
 		return ErrEmptyTitle
 	}
@@ -125,3 +584,35 @@ func ParseAndValidateDate(dateStr string) (time.Time, error) {
 
 	return date, nil
 }
+
+// ParseAndValidateDateInZone разбирает дату YYYY-MM-DD как полночь в часовом поясе timeZone.
+func ParseAndValidateDateInZone(dateStr, timeZone string) (time.Time, error) {
+	loc, err := ParseTimeZone(timeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date, err := ParseAndValidateDate(dateStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), nil
+}
+
+func ParseAndValidateDates(dates []string) ([]time.Time, error) {
+	if dates == nil {
+		return nil, nil
+	}
+
+	result := make([]time.Time, 0, len(dates))
+	for _, dateStr := range dates {
+		date, err := ParseAndValidateDate(dateStr)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, date)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,223 @@
+package event
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndValidateEventTime(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		date     string
+		end      string
+		duration string
+		timeZone string
+		allDay   bool
+		want     EventTime
+		err      error
+	}{
+		{
+			name: "date only is all-day",
+			date: "2025-09-01",
+			want: EventTime{
+				Start:    time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC),
+				AllDay:   true,
+				TimeZone: "UTC",
+			},
+		},
+		{
+			name:     "local time with duration",
+			date:     "2025-09-01T10:00",
+			duration: "1h30m",
+			timeZone: "Europe/Moscow",
+			want: EventTime{
+				Start:    time.Date(2025, 9, 1, 10, 0, 0, 0, moscow),
+				End:      time.Date(2025, 9, 1, 11, 30, 0, 0, moscow),
+				TimeZone: "Europe/Moscow",
+			},
+		},
+		{
+			name:     "offset is converted to time zone",
+			date:     "2025-09-01T07:00:00Z",
+			end:      "2025-09-01T12:00",
+			timeZone: "Europe/Moscow",
+			want: EventTime{
+				Start:    time.Date(2025, 9, 1, 10, 0, 0, 0, moscow),
+				End:      time.Date(2025, 9, 1, 12, 0, 0, 0, moscow),
+				TimeZone: "Europe/Moscow",
+			},
+		},
+		{
+			name:   "all-day end is inclusive",
+			date:   "2025-09-01",
+			end:    "2025-09-03",
+			allDay: true,
+			want: EventTime{
+				Start:    time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+				End:      time.Date(2025, 9, 4, 0, 0, 0, 0, time.UTC),
+				AllDay:   true,
+				TimeZone: "UTC",
+			},
+		},
+		{name: "bad date", date: "01.09.2025", err: ErrInvalidDateTime},
+		{name: "all-day with time", date: "2025-09-01T10:00", allDay: true, err: ErrAllDayWithTime},
+		{name: "end before start", date: "2025-09-01T10:00", end: "2025-09-01T09:00", err: ErrInvalidEnd},
+		{name: "end without time", date: "2025-09-01T10:00", end: "2025-09-02", err: ErrInvalidEnd},
+		{name: "end and duration", date: "2025-09-01T10:00", end: "2025-09-01T11:00", duration: "1h", err: ErrEndAndDuration},
+		{name: "negative duration", date: "2025-09-01T10:00", duration: "-1h", err: ErrInvalidDuration},
+		{name: "partial day duration", date: "2025-09-01", duration: "36h", err: ErrInvalidDuration},
+		{name: "unknown time zone", date: "2025-09-01T10:00", timeZone: "Mars/Olympus", err: ErrInvalidTimeZone},
+		{name: "server local time zone", date: "2025-09-01T10:00", timeZone: "Local", err: ErrInvalidTimeZone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAndValidateEventTime(tt.date, tt.end, tt.duration, tt.timeZone, tt.allDay)
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.want.Start.Equal(got.Start), "start %s", got.Start)
+			assert.True(t, tt.want.End.Equal(got.End), "end %s", got.End)
+			assert.Equal(t, tt.want.AllDay, got.AllDay)
+			assert.Equal(t, tt.want.TimeZone, got.TimeZone)
+		})
+	}
+}
+
+func TestParseAndValidateRange(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	t.Run("date to is inclusive", func(t *testing.T) {
+		from, to, err := ParseAndValidateRange("2025-09-01", "2025-09-30", "Europe/Moscow")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, moscow), from)
+		assert.Equal(t, time.Date(2025, 10, 1, 0, 0, 0, 0, moscow), to)
+	})
+
+	t.Run("date-time to is exclusive", func(t *testing.T) {
+		from, to, err := ParseAndValidateRange("2025-09-01T09:00", "2025-09-01T18:00", "")
+		require.NoError(t, err)
+		assert.Equal(t, 9*time.Hour, to.Sub(from))
+	})
+
+	for _, tt := range []struct{ name, from, to string }{
+		{"missing from", "", "2025-09-30"},
+		{"to before from", "2025-09-30", "2025-09-01"},
+		{"empty range", "2025-09-01T10:00", "2025-09-01T10:00"},
+		{"too long", "2025-01-01", "2026-01-02"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseAndValidateRange(tt.from, tt.to, "")
+			assert.ErrorIs(t, err, ErrInvalidRange)
+		})
+	}
+}
+
+func TestParseAndValidateLimit(t *testing.T) {
+	limit, err := ParseAndValidateLimit("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPageLimit, limit)
+
+	limit, err = ParseAndValidateLimit("10")
+	require.NoError(t, err)
+	assert.Equal(t, 10, limit)
+
+	for _, limitStr := range []string{"0", "-1", "501", "ten"} {
+		_, err := ParseAndValidateLimit(limitStr)
+		assert.ErrorIs(t, err, ErrInvalidLimit, limitStr)
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	assert.NoError(t, ValidateBatch("", 1))
+	assert.NoError(t, ValidateBatch("best_effort", MaxBatchOperations))
+
+	assert.ErrorIs(t, ValidateBatch("all", 1), ErrInvalidBatchMode)
+	assert.ErrorIs(t, ValidateBatch("atomic", 0), ErrInvalidBatchSize)
+	assert.ErrorIs(t, ValidateBatch("atomic", MaxBatchOperations+1), ErrInvalidBatchSize)
+}
+
+func TestValidateWebhook(t *testing.T) {
+	assert.NoError(t, ValidateWebhook("https://example.com/hooks", "", nil))
+	assert.NoError(t, ValidateWebhook("http://localhost:8081/hook", "secret", []string{"created", "deleted"}))
+
+	assert.ErrorIs(t, ValidateWebhook("example.com/hooks", "", nil), ErrInvalidWebhookURL)
+	assert.ErrorIs(t, ValidateWebhook("ftp://example.com", "", nil), ErrInvalidWebhookURL)
+	assert.ErrorIs(t, ValidateWebhook("https://", "", nil), ErrInvalidWebhookURL)
+	assert.ErrorIs(t, ValidateWebhook("https://example.com", strings.Repeat("s", 256), nil), ErrWebhookSecretTooLong)
+	assert.ErrorIs(t, ValidateWebhook("https://example.com", "", []string{"moved"}), ErrInvalidWebhookEvents)
+}
+
+func TestValidateCalendar(t *testing.T) {
+	assert.NoError(t, ValidateCalendarName("work"))
+	assert.ErrorIs(t, ValidateCalendarName("  "), ErrEmptyCalendarName)
+	assert.ErrorIs(t, ValidateCalendarName(strings.Repeat("n", 101)), ErrCalendarNameTooLong)
+
+	calendarID, err := ValidateCalendarFilter("")
+	assert.NoError(t, err)
+	assert.Zero(t, calendarID)
+	calendarID, err = ValidateCalendarFilter("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calendarID)
+	_, err = ValidateCalendarFilter("0")
+	assert.ErrorIs(t, err, ErrInvalidCalendarID)
+
+	assert.NoError(t, ValidateShare(1, 2, "viewer"))
+	assert.NoError(t, ValidateShare(1, 2, "editor"))
+	assert.ErrorIs(t, ValidateShare(1, 1, "editor"), ErrShareWithOwner)
+	assert.ErrorIs(t, ValidateShare(1, 2, "owner"), ErrInvalidRole)
+}
+
+func TestValidateRSVP(t *testing.T) {
+	for _, status := range []string{"accepted", "declined", "tentative"} {
+		assert.NoError(t, ValidateRSVP(status))
+	}
+	assert.ErrorIs(t, ValidateRSVP("needs_action"), ErrInvalidRSVP)
+	assert.ErrorIs(t, ValidateRSVP(""), ErrInvalidRSVP)
+}
+
+func TestValidateFreeBusy(t *testing.T) {
+	userIDs, err := ParseUserIDs("1, 2,1")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, userIDs)
+	_, err = ParseUserIDs("")
+	assert.ErrorIs(t, err, ErrInvalidUsers)
+	_, err = ParseUserIDs("1,x")
+	assert.ErrorIs(t, err, ErrInvalidUsers)
+	_, err = ParseUserIDs(strings.Repeat("1,", MaxFreeBusyUsers) + "1")
+	assert.ErrorIs(t, err, ErrInvalidUsers)
+
+	start, end, err := ParseWorkingHours("", "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), start)
+	assert.Equal(t, 24*time.Hour, end)
+	start, end, err = ParseWorkingHours("09:30", "18:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 9*time.Hour+30*time.Minute, start)
+	assert.Equal(t, 18*time.Hour, end)
+	_, _, err = ParseWorkingHours("18:00", "09:00")
+	assert.ErrorIs(t, err, ErrInvalidWorkingHours)
+	_, _, err = ParseWorkingHours("9", "18:00")
+	assert.ErrorIs(t, err, ErrInvalidWorkingHours)
+
+	d, err := ParseSlotDuration("")
+	assert.NoError(t, err)
+	assert.Zero(t, d)
+	d, err = ParseSlotDuration("45m")
+	assert.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, d)
+	_, err = ParseSlotDuration("25h")
+	assert.ErrorIs(t, err, ErrInvalidSlotDuration)
+}
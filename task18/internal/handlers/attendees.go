@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListAttendees возвращает приглашённых на событие
+// @Summary Участники события
+// @Description Возвращает приглашённых на событие с их ответами и сводку ответов. Список доступен всем, кто видит
+// @Description событие, в том числе приглашённым; у замены вхождения это участники серии.
+// @Tags attendees
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Success 200 {object} repository.SuccessResponse{result=repository.AttendeesResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID}/attendees [get]
+func (h *Handlers) ListAttendees(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	attendees, summary, err := h.serviceCalendar.Attendees(r.Context(), eventID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	if attendees == nil {
+		attendees = []repository.Attendee{}
+	}
+
+	sendResponse(w, repository.AttendeesResponse{Attendees: attendees, Summary: summary}, http.StatusOK)
+}
+
+// InviteAttendee приглашает пользователя на событие
+// @Summary Пригласить на событие
+// @Description Приглашает пользователя userID на событие; приглашать может тот, кто может менять событие. Приглашение
+// @Description на серию распространяется на все вхождения. Приглашённый видит событие в своих выборках за день,
+// @Description неделю и месяц, пока не отклонит его. Повторное приглашение не меняет ответ; перенос события
+// @Description сбрасывает ответы всех приглашённых в needs_action.
+// @Tags attendees
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param userID path int true "ID приглашаемого пользователя"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Attendee}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID}/attendees/{userID} [put]
+func (h *Handlers) InviteAttendee(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, attendeeID, ok := attendeePath(w, r)
+	if !ok {
+		return
+	}
+
+	attendee, err := h.serviceCalendar.InviteAttendee(r.Context(), eventID, userID, attendeeID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Attendee invited in handle",
+		"event_id", eventID,
+		"user_id", userID,
+		"attendee_id", attendeeID,
+	)
+
+	sendResponse(w, attendee, http.StatusOK)
+}
+
+// RemoveAttendee отменяет приглашение на событие
+// @Summary Отменить приглашение
+// @Description Отменить приглашение может тот, кто может менять событие, или сам приглашённый.
+// @Tags attendees
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param userID path int true "ID приглашённого пользователя"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 204 "Приглашение отменено"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID}/attendees/{userID} [delete]
+func (h *Handlers) RemoveAttendee(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, attendeeID, ok := attendeePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.serviceCalendar.RemoveAttendee(r.Context(), eventID, userID, attendeeID); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Attendee removed in handle",
+		"event_id", eventID,
+		"user_id", userID,
+		"attendee_id", attendeeID,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RespondToInvitation сохраняет ответ на приглашение
+// @Summary Ответить на приглашение
+// @Description Сохраняет ответ пользователя на приглашение: accepted, declined или tentative. Отклонённое событие
+// @Description пропадает из выборок пользователя за день, неделю и месяц, но остаётся в списке приглашений.
+// @Tags attendees
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param rsvp body repository.RSVPRequest true "Ответ"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Attendee}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID}/rsvp [put]
+func (h *Handlers) RespondToInvitation(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.RSVPRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := event.ValidateRSVP(req.Status); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	attendee, err := h.serviceCalendar.RespondToInvitation(r.Context(), eventID, userID, req.Status)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Invitation answered in handle",
+		"event_id", eventID,
+		"user_id", userID,
+		"status", attendee.Status,
+	)
+
+	sendResponse(w, attendee, http.StatusOK)
+}
+
+// ListInvitations возвращает приглашения пользователя
+// @Summary Приглашения
+// @Description Возвращает события, на которые приглашён пользователь, с его ответами, включая отклонённые.
+// @Tags attendees
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} repository.SuccessResponse{result=repository.InvitationsResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/invitations [get]
+func (h *Handlers) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.serviceCalendar.Invitations(r.Context(), userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, repository.InvitationsResponse{Invitations: invitations}, http.StatusOK)
+}
+
+func attendeePath(w http.ResponseWriter, r *http.Request) (int, int, int, bool) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	attendeeID, err := event.ValidateUserIDParam(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendParamError(w, err)
+		return 0, 0, 0, false
+	}
+
+	return userID, eventID, attendeeID, true
+}
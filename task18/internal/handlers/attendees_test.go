@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_Attendees(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	organizer := testRouter(h, 1)
+	guest := testRouter(h, 2)
+
+	rec := doRequest(organizer, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T10:00", "duration": "1h", "title": "Planning"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	t.Run("organizer invites guest", func(t *testing.T) {
+		rec := doRequest(organizer, http.MethodPut, "/users/1/events/1/attendees/2", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var attendee repository.Attendee
+		decodeResult(t, rec, &attendee)
+		assert.Equal(t, 2, attendee.UserID)
+		assert.Equal(t, repository.RSVPNeedsAction, attendee.Status)
+
+		rec = doRequest(organizer, http.MethodPut, "/users/1/events/1/attendees/1", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var p problem.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, "user_id", p.Errors[0].Field)
+	})
+
+	t.Run("guest sees and answers invitation", func(t *testing.T) {
+		rec := doRequest(guest, http.MethodGet, "/users/2/events?date=2025-09-01&period=day", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), "Planning")
+
+		rec = doRequest(guest, http.MethodPut, "/users/2/events/1/rsvp", `{"status": "maybe"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		rec = doRequest(guest, http.MethodPut, "/users/2/events/1/rsvp", `{"status": "accepted"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/invitations", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var invitations repository.InvitationsResponse
+		decodeResult(t, rec, &invitations)
+		require.Len(t, invitations.Invitations, 1)
+		assert.Equal(t, "Planning", invitations.Invitations[0].Event.Title)
+		assert.Equal(t, repository.RSVPAccepted, invitations.Invitations[0].Status)
+	})
+
+	t.Run("organizer sees summary", func(t *testing.T) {
+		rec := doRequest(organizer, http.MethodGet, "/users/1/events/1/attendees", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var result repository.AttendeesResponse
+		decodeResult(t, rec, &result)
+		require.Len(t, result.Attendees, 1)
+		assert.Equal(t, repository.RSVPSummary{Accepted: 1}, result.Summary)
+	})
+
+	t.Run("guest cannot invite others", func(t *testing.T) {
+		rec := doRequest(guest, http.MethodPut, "/users/2/events/1/attendees/3", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("guest leaves event", func(t *testing.T) {
+		rec := doRequest(guest, http.MethodDelete, "/users/2/events/1/attendees/2", "")
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/events/1", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = doRequest(organizer, http.MethodDelete, "/users/1/events/1/attendees/2", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"errors"
+	"net/http"
+)
+
+const (
+	batchCreated = "created"
+	batchUpdated = "updated"
+	batchDeleted = "deleted"
+	batchFailed  = "failed"
+	batchSkipped = "skipped"
+)
+
+// BatchEvents применяет пакет операций над событиями пользователя
+// @Summary Пакетное изменение событий
+// @Description Создает, заменяет и удаляет события пользователя одним запросом; операции применяются по порядку.
+// @Description Событие в create и update задаётся полями /users/{id}/events, update заменяет событие целиком.
+// @Description version в update и delete — ожидаемая версия события, как в If-Match.
+// @Description В режиме atomic (по умолчанию) применяются все операции или ни одной: если хотя бы одна не прошла
+// @Description проверку или не применилась, ответ содержит её ошибку, а остальные операции отмечены как skipped.
+// @Description В режиме best_effort каждая операция применяется независимо, ответ всегда 200 с результатом по каждой.
+// @Tags events
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param batch body repository.BatchRequest true "Операции пакета" SchemaExample({"mode": "atomic", "operations": [{"op": "create", "date": "2025-09-01T10:00", "duration": "1h", "title": "example string"}, {"op": "delete", "event_id": 1, "version": 2}]})
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.BatchResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} repository.BatchErrorResponse
+// @Failure 409 {object} repository.BatchErrorResponse
+// @Failure 412 {object} repository.BatchErrorResponse
+// @Failure 422 {object} repository.BatchErrorResponse
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/batch [post]
+func (h *Handlers) BatchEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.BatchRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := event.ValidateBatch(req.Mode, len(req.Operations)); err != nil {
+		sendParamError(w, err)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = repository.BatchModeAtomic
+	}
+
+	resp := repository.BatchResponse{
+		Mode:  req.Mode,
+		Items: make([]repository.BatchResult, len(req.Operations)),
+	}
+	ops := make([]repository.BatchOp, len(req.Operations))
+	var invalid error
+	for i, op := range req.Operations {
+		resp.Items[i] = repository.BatchResult{Index: i, Op: op.Op, EventID: op.EventID}
+
+		var err error
+		ops[i], err = batchOpFromRequest(userID, op)
+		if err != nil {
+			setBatchFailure(&resp.Items[i], err)
+			if invalid == nil {
+				invalid = &repository.BatchError{Index: i, Err: err}
+			}
+		}
+	}
+
+	if req.Mode == repository.BatchModeBestEffort {
+		h.applyEach(r, ops, &resp)
+		sendResponse(w, resp, http.StatusOK)
+		return
+	}
+
+	if invalid != nil {
+		sendBatchError(w, invalid, resp)
+		return
+	}
+
+	results, err := h.serviceCalendar.ApplyBatch(r.Context(), ops)
+	var batchErr *repository.BatchError
+	if errors.As(err, &batchErr) {
+		setBatchFailure(&resp.Items[batchErr.Index], batchErr.Err)
+		sendBatchError(w, err, resp)
+		return
+	}
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	for i, result := range results {
+		setBatchResult(&resp, i, result)
+	}
+
+	h.log.Debug("Event batch applied in handle",
+		"user_id", userID,
+		"operations", len(ops),
+	)
+
+	sendResponse(w, resp, http.StatusOK)
+}
+
+// applyEach применяет прошедшие проверку операции по одной, как отдельные запросы.
+func (h *Handlers) applyEach(r *http.Request, ops []repository.BatchOp, resp *repository.BatchResponse) {
+	for i, op := range ops {
+		if resp.Items[i].Status == batchFailed {
+			resp.Failed++
+			continue
+		}
+
+		var result repository.Event
+		var err error
+		switch op.Kind {
+		case repository.BatchCreate:
+			result, err = h.serviceCalendar.CreateEvent(r.Context(), op.Event)
+		case repository.BatchUpdate:
+			result, err = h.serviceCalendar.UpdateEvent(r.Context(), op.Event)
+		case repository.BatchDelete:
+			result = op.Event
+			err = h.serviceCalendar.DeleteEvent(r.Context(), op.Event.ID, op.Event.UserID, op.Event.Version)
+		}
+		if err != nil {
+			setBatchFailure(&resp.Items[i], err)
+			resp.Failed++
+			continue
+		}
+
+		setBatchResult(resp, i, result)
+	}
+}
+
+func setBatchResult(resp *repository.BatchResponse, i int, result repository.Event) {
+	item := &resp.Items[i]
+	item.EventID = result.ID
+	switch item.Op {
+	case repository.BatchCreate:
+		item.Status = batchCreated
+		item.Event = &result
+		resp.Created++
+	case repository.BatchUpdate:
+		item.Status = batchUpdated
+		item.Event = &result
+		resp.Updated++
+	case repository.BatchDelete:
+		item.Status = batchDeleted
+		resp.Deleted++
+	}
+}
+
+// setBatchFailure отмечает операцию неудачной. Ошибка сопоставляется коду так
+// же, как в ответе одиночного запроса; неизвестные ошибки не раскрываются.
+func setBatchFailure(item *repository.BatchResult, err error) {
+	p := problemFor(err, http.StatusUnprocessableEntity)
+	item.Status = batchFailed
+	item.Error = p.Detail
+	item.Code = p.Code
+	item.Errors = p.Errors
+}
+
+// batchOpFromRequest проверяет операцию пакета теми же валидаторами, что и
+// одиночные запросы, и собирает из неё операцию хранилища.
+func batchOpFromRequest(userID int, op repository.BatchOperation) (repository.BatchOp, error) {
+	switch op.Op {
+	case repository.BatchCreate:
+		if err := event.ValidateCreateRequest(userID, op.Date, op.Title); err != nil {
+			return repository.BatchOp{}, err
+		}
+
+		created, err := eventFromRequest(op.EventRequest())
+		if err != nil {
+			return repository.BatchOp{}, err
+		}
+		created.UserID = userID
+
+		return repository.BatchOp{Kind: op.Op, Event: created}, nil
+	case repository.BatchUpdate:
+		if err := event.ValidateUpdateRequest(op.EventID, userID, op.Date, op.Title); err != nil {
+			return repository.BatchOp{}, err
+		}
+
+		req := op.EventRequest()
+		if req.ExDates == nil {
+			req.ExDates = []string{}
+		}
+		if req.Reminders == nil {
+			req.Reminders = []int{}
+		}
+
+		updated, err := eventFromRequest(req)
+		if err != nil {
+			return repository.BatchOp{}, err
+		}
+		updated.ID = op.EventID
+		updated.UserID = userID
+		updated.Version = op.Version
+
+		return repository.BatchOp{Kind: op.Op, Event: updated}, nil
+	case repository.BatchDelete:
+		if err := event.ValidateDeleteRequest(op.EventID, userID); err != nil {
+			return repository.BatchOp{}, err
+		}
+
+		return repository.BatchOp{
+			Kind:  op.Op,
+			Event: repository.Event{ID: op.EventID, UserID: userID, Version: op.Version},
+		}, nil
+	}
+
+	return repository.BatchOp{}, event.ErrInvalidBatchOp
+}
+
+// sendBatchError отвечает на отклонённый атомарный пакет: все операции, кроме
+// неудачных, отмечаются как пропущенные, а ответ дополняет описание ошибки
+// результатом пакета.
+func sendBatchError(w http.ResponseWriter, err error, resp repository.BatchResponse) {
+	for i := range resp.Items {
+		if resp.Items[i].Status == batchFailed {
+			resp.Failed++
+		} else {
+			resp.Items[i].Status = batchSkipped
+		}
+	}
+
+	p := problemFor(err, http.StatusUnprocessableEntity)
+	problem.Write(w, p.Status, repository.BatchErrorResponse{Problem: p, Result: resp})
+}
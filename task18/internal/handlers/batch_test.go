@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeBatch(t *testing.T, body []byte) repository.BatchResponse {
+	var response struct {
+		Result repository.BatchResponse `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(body, &response), string(body))
+	return response.Result
+}
+
+func batchStatuses(resp repository.BatchResponse) []string {
+	statuses := make([]string, len(resp.Items))
+	for i, item := range resp.Items {
+		statuses[i] = item.Status
+	}
+	return statuses
+}
+
+func TestHandlers_BatchEvents(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T10:00", "title": "Standup"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	standup := decodeEvent(t, rec)
+
+	t.Run("atomic batch is applied", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events/batch", `{"operations": [
+			{"op": "create", "date": "2025-09-02T10:00", "duration": "1h", "title": "Review", "reminders": [15]},
+			{"op": "update", "event_id": 1, "version": 1, "date": "2025-09-01T11:00", "title": "Late standup"}
+		]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		resp := decodeBatch(t, rec.Body.Bytes())
+		assert.Equal(t, repository.BatchModeAtomic, resp.Mode)
+		assert.Equal(t, []string{"created", "updated"}, batchStatuses(resp))
+		assert.Equal(t, 1, resp.Created)
+		assert.Equal(t, 1, resp.Updated)
+		require.NotNil(t, resp.Items[0].Event)
+		assert.Equal(t, []int{15}, resp.Items[0].Event.Reminders)
+		assert.Equal(t, resp.Items[0].Event.ID, resp.Items[0].EventID)
+		assert.Equal(t, 2, resp.Items[1].Event.Version)
+	})
+
+	t.Run("invalid operation rejects atomic batch", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events/batch", `{"mode": "atomic", "operations": [
+			{"op": "create", "date": "2025-09-03T10:00", "title": "Planning"},
+			{"op": "create", "date": "not a date", "title": "Broken"},
+			{"op": "move", "event_id": 1}
+		]}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+		var response repository.BatchErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, problem.CodeValidationFailed, response.Code)
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, "operations[1].date", response.Errors[0].Field)
+		assert.Equal(t, []string{"skipped", "failed", "failed"}, batchStatuses(response.Result))
+		assert.Equal(t, 2, response.Result.Failed)
+		assert.Equal(t, problem.FieldInvalid, response.Result.Items[2].Errors[0].Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events?date=2025-09-03", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "Planning")
+	})
+
+	t.Run("storage error rolls back atomic batch", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events/batch", `{"operations": [
+			{"op": "delete", "event_id": 1},
+			{"op": "update", "event_id": 999, "date": "2025-09-03T10:00", "title": "Missing"}
+		]}`)
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+		var response repository.BatchErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, problem.CodeEventNotFound, response.Code)
+		assert.Equal(t, []string{"skipped", "failed"}, batchStatuses(response.Result))
+		assert.Equal(t, problem.CodeEventNotFound, response.Result.Items[1].Code)
+
+		rec = doRequest(router, http.MethodGet, eventLocation(1, standup), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("best effort reports each operation", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events/batch", `{"mode": "best_effort", "operations": [
+			{"op": "update", "event_id": 1, "version": 1, "date": "2025-09-01T12:00", "title": "Stale"},
+			{"op": "create", "date": "2025-09-04T10:00", "title": ""},
+			{"op": "delete", "event_id": 1}
+		]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		resp := decodeBatch(t, rec.Body.Bytes())
+		assert.Equal(t, []string{"failed", "failed", "deleted"}, batchStatuses(resp))
+		assert.Equal(t, repository.ErrVersionMismatch.Error(), resp.Items[0].Error)
+		assert.Equal(t, problem.CodeVersionMismatch, resp.Items[0].Code)
+		assert.Equal(t, problem.CodeValidationFailed, resp.Items[1].Code)
+		require.Len(t, resp.Items[1].Errors, 1)
+		assert.Equal(t, "title", resp.Items[1].Errors[0].Field)
+		assert.Equal(t, problem.FieldRequired, resp.Items[1].Errors[0].Code)
+		assert.Equal(t, 2, resp.Failed)
+		assert.Equal(t, 1, resp.Deleted)
+
+		rec = doRequest(router, http.MethodGet, eventLocation(1, standup), "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("batch size and mode are checked", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events/batch", `{"operations": []}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(router, http.MethodPost, "/users/1/events/batch", `{"mode": "all", "operations": [{"op": "delete", "event_id": 1}]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("foreign user is forbidden", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/2/events/batch", `{"operations": [{"op": "delete", "event_id": 1}]}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AddCalendar создает календарь пользователя
+// @Summary Создать календарь
+// @Description Создает именованный календарь (например, work или personal). События попадают в календарь
+// @Description через calendar_id при создании; события без calendar_id не принадлежат ни одному календарю.
+// @Tags calendars
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param calendar body repository.CalendarRequest true "Календарь"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 201 {object} repository.SuccessResponse{result=repository.Calendar}
+// @Header 201 {string} Location "/users/{id}/calendars/{calendarID}"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars [post]
+func (h *Handlers) AddCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.CalendarRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := event.ValidateCalendarName(req.Name); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	created, err := h.serviceCalendar.CreateCalendar(r.Context(), repository.Calendar{UserID: userID, Name: req.Name})
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Calendar created in handle",
+		"calendar_id", created.ID,
+		"user_id", userID,
+	)
+
+	w.Header().Set("Location", fmt.Sprintf("/users/%d/calendars/%d", userID, created.ID))
+	sendResponse(w, created, http.StatusCreated)
+}
+
+// ListCalendars возвращает календари, видимые пользователю
+// @Summary Список календарей
+// @Description Возвращает собственные календари пользователя и открытые ему чужие; role — роль пользователя в календаре.
+// @Tags calendars
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} repository.SuccessResponse{result=[]repository.Calendar}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars [get]
+func (h *Handlers) ListCalendars(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	calendars, err := h.serviceCalendar.Calendars(r.Context(), userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, calendars, http.StatusOK)
+}
+
+// GetCalendar возвращает календарь
+// @Summary Получить календарь
+// @Tags calendars
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param calendarID path int true "ID календаря"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Calendar}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars/{calendarID} [get]
+func (h *Handlers) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, calendarID, ok := calendarPath(w, r)
+	if !ok {
+		return
+	}
+
+	cal, err := h.serviceCalendar.GetCalendar(r.Context(), calendarID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, cal, http.StatusOK)
+}
+
+// RenameCalendar переименовывает календарь
+// @Summary Переименовать календарь
+// @Description Переименовывать календарь может только его владелец.
+// @Tags calendars
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param calendarID path int true "ID календаря"
+// @Param calendar body repository.CalendarRequest true "Календарь"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Calendar}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars/{calendarID} [put]
+func (h *Handlers) RenameCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, calendarID, ok := calendarPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.CalendarRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := event.ValidateCalendarName(req.Name); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	renamed, err := h.serviceCalendar.RenameCalendar(r.Context(), calendarID, userID, req.Name)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, renamed, http.StatusOK)
+}
+
+// RemoveCalendar удаляет календарь
+// @Summary Удалить календарь
+// @Description Удаляет календарь вместе с его событиями и доступами других пользователей; это может только владелец.
+// @Tags calendars
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param calendarID path int true "ID календаря"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 204 "Календарь удален"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars/{calendarID} [delete]
+func (h *Handlers) RemoveCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, calendarID, ok := calendarPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.serviceCalendar.DeleteCalendar(r.Context(), calendarID, userID); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Calendar deleted in handle",
+		"calendar_id", calendarID,
+		"user_id", userID,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListCalendarShares возвращает, кому открыт календарь
+// @Summary Доступы к календарю
+// @Description Список пользователей, которым владелец открыл календарь, с их ролями; доступен только владельцу.
+// @Tags calendars
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param calendarID path int true "ID календаря"
+// @Success 200 {object} repository.SuccessResponse{result=[]repository.CalendarShare}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars/{calendarID}/shares [get]
+func (h *Handlers) ListCalendarShares(w http.ResponseWriter, r *http.Request) {
+	userID, calendarID, ok := calendarPath(w, r)
+	if !ok {
+		return
+	}
+
+	shares, err := h.serviceCalendar.CalendarShares(r.Context(), calendarID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, shares, http.StatusOK)
+}
+
+// ShareCalendar открывает календарь другому пользователю
+// @Summary Открыть календарь пользователю
+// @Description Открывает календарь пользователю userID с ролью viewer (только чтение) или editor (чтение и изменение событий)
+// @Description или меняет роль. События открытого календаря попадают в выборки пользователя за день, неделю и месяц.
+// @Tags calendars
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param calendarID path int true "ID календаря"
+// @Param userID path int true "ID пользователя, которому открывается календарь"
+// @Param share body repository.ShareRequest true "Роль"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.CalendarShare}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars/{calendarID}/shares/{userID} [put]
+func (h *Handlers) ShareCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, calendarID, shareUserID, ok := sharePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.ShareRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := event.ValidateShare(userID, shareUserID, req.Role); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	share, err := h.serviceCalendar.ShareCalendar(r.Context(), userID, repository.CalendarShare{
+		CalendarID: calendarID,
+		UserID:     shareUserID,
+		Role:       req.Role,
+	})
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Calendar shared in handle",
+		"calendar_id", calendarID,
+		"user_id", userID,
+		"share_user_id", shareUserID,
+		"role", share.Role,
+	)
+
+	sendResponse(w, share, http.StatusOK)
+}
+
+// UnshareCalendar закрывает пользователю доступ к календарю
+// @Summary Закрыть доступ к календарю
+// @Description Закрыть доступ может владелец календаря или сам пользователь, которому календарь открыт.
+// @Tags calendars
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param calendarID path int true "ID календаря"
+// @Param userID path int true "ID пользователя, которому закрывается доступ"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 204 "Доступ закрыт"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/calendars/{calendarID}/shares/{userID} [delete]
+func (h *Handlers) UnshareCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, calendarID, shareUserID, ok := sharePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.serviceCalendar.UnshareCalendar(r.Context(), calendarID, userID, shareUserID); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Calendar unshared in handle",
+		"calendar_id", calendarID,
+		"user_id", userID,
+		"share_user_id", shareUserID,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func calendarPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	calendarID, err := event.ValidateCalendarIDParam(chi.URLParam(r, "calendarID"))
+	if err != nil {
+		sendParamError(w, err)
+		return 0, 0, false
+	}
+
+	return userID, calendarID, true
+}
+
+func sharePath(w http.ResponseWriter, r *http.Request) (int, int, int, bool) {
+	userID, calendarID, ok := calendarPath(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	shareUserID, err := event.ValidateUserIDParam(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendParamError(w, err)
+		return 0, 0, 0, false
+	}
+
+	return userID, calendarID, shareUserID, true
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_Calendars(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	owner := testRouter(h, 1)
+	guest := testRouter(h, 2)
+
+	rec := doRequest(owner, http.MethodPost, "/users/1/calendars", `{"name": "team"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var team repository.Calendar
+	decodeResult(t, rec, &team)
+	assert.Equal(t, repository.RoleOwner, team.Role)
+	calendarPath := "/users/1/calendars/" + strconv.Itoa(team.ID)
+	assert.Equal(t, calendarPath, rec.Header().Get("Location"))
+
+	rec = doRequest(owner, http.MethodPost, "/users/1/events",
+		`{"calendar_id": `+strconv.Itoa(team.ID)+`, "date": "2025-09-01T10:00", "title": "Planning"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	planning := decodeEvent(t, rec)
+	assert.Equal(t, team.ID, planning.CalendarID)
+
+	rec = doRequest(guest, http.MethodPost, "/users/2/events", `{"date": "2025-09-01T15:00", "title": "Dentist"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	guestEvent := "/users/2/events/" + strconv.Itoa(planning.ID)
+
+	t.Run("calendar is hidden until shared", func(t *testing.T) {
+		rec := doRequest(guest, http.MethodGet, "/users/2/calendars/"+strconv.Itoa(team.ID), "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = doRequest(guest, http.MethodGet, guestEvent, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/events?date=2025-09-01", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, decodeEvents(t, rec), 1)
+	})
+
+	t.Run("viewer sees events but cannot change them", func(t *testing.T) {
+		rec := doRequest(owner, http.MethodPut, calendarPath+"/shares/2", `{"role": "viewer"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/events?date=2025-09-01", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		events := decodeEvents(t, rec)
+		require.Len(t, events, 2)
+		assert.Equal(t, "Planning", events[0].Title)
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/events?date=2025-09-01&calendar_id="+strconv.Itoa(team.ID), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, decodeEvents(t, rec), 1)
+
+		rec = doRequest(guest, http.MethodGet, guestEvent, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = doRequest(guest, http.MethodPatch, guestEvent, `{"title": "Hijacked"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, problem.CodePermissionDenied, decodeProblem(t, rec).Code)
+
+		rec = doRequest(guest, http.MethodPost, "/users/2/events",
+			`{"calendar_id": `+strconv.Itoa(team.ID)+`, "date": "2025-09-02T10:00", "title": "Extra"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("editor changes events of shared calendar", func(t *testing.T) {
+		rec := doRequest(owner, http.MethodPut, calendarPath+"/shares/2", `{"role": "editor"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = doRequest(guest, http.MethodPatch, guestEvent, `{"title": "Sprint planning"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, 1, decodeEvent(t, rec).UserID, "event stays with the calendar owner")
+
+		rec = doRequest(owner, http.MethodGet, "/users/1/events/"+strconv.Itoa(planning.ID), "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Sprint planning", decodeEvent(t, rec).Title)
+
+		rec = doRequest(guest, http.MethodPost, "/users/2/events",
+			`{"calendar_id": `+strconv.Itoa(team.ID)+`, "date": "2025-09-02T10:00", "title": "Retro"}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		retro := decodeEvent(t, rec)
+		assert.Equal(t, 1, retro.UserID)
+		assert.Equal(t, "/users/2/events/"+strconv.Itoa(retro.ID), rec.Header().Get("Location"))
+	})
+
+	t.Run("only owner manages calendar", func(t *testing.T) {
+		rec := doRequest(guest, http.MethodPut, "/users/2/calendars/"+strconv.Itoa(team.ID), `{"name": "mine"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/calendars/"+strconv.Itoa(team.ID)+"/shares", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = doRequest(owner, http.MethodGet, calendarPath+"/shares", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var shares []repository.CalendarShare
+		decodeResult(t, rec, &shares)
+		require.Len(t, shares, 1)
+		assert.Equal(t, repository.RoleEditor, shares[0].Role)
+	})
+
+	t.Run("invalid share is rejected", func(t *testing.T) {
+		rec := doRequest(owner, http.MethodPut, calendarPath+"/shares/1", `{"role": "editor"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "user_id", decodeProblem(t, rec).Errors[0].Field)
+
+		rec = doRequest(owner, http.MethodPut, calendarPath+"/shares/3", `{"role": "owner"}`)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, "role", decodeProblem(t, rec).Errors[0].Field)
+	})
+
+	t.Run("guest leaves shared calendar", func(t *testing.T) {
+		rec := doRequest(guest, http.MethodDelete, "/users/2/calendars/"+strconv.Itoa(team.ID)+"/shares/2", "")
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+		rec = doRequest(guest, http.MethodGet, "/users/2/calendars", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var calendars []repository.Calendar
+		decodeResult(t, rec, &calendars)
+		assert.Empty(t, calendars)
+	})
+
+	t.Run("deleting calendar removes its events", func(t *testing.T) {
+		rec := doRequest(owner, http.MethodDelete, calendarPath, "")
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+		rec = doRequest(owner, http.MethodGet, "/users/1/events?date=2025-09-01&period=week", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, decodeEvents(t, rec))
+	})
+}
+
+func decodeEvents(t *testing.T, rec *httptest.ResponseRecorder) []repository.Event {
+	t.Helper()
+	var events repository.EventsResponse
+	decodeResult(t, rec, &events)
+	return events.Events
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) problem.Problem {
+	t.Helper()
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p), rec.Body.String())
+	return p
+}
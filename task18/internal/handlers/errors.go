@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/ical"
+	"calendar/internal/problem"
+	"calendar/internal/webhook"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+var (
+	errInvalidJSON        = errors.New("invalid JSON")
+	errUnauthenticated    = errors.New("authentication required")
+	errForbidden          = errors.New("access to another user's calendar is forbidden")
+	errRouteNotFound      = errors.New("route not found")
+	errFileRequired       = errors.New("multipart field file is required")
+	errInvalidLastEventID = errors.New("Last-Event-ID must be a change ID")
+)
+
+// errorStatuses сопоставляет ошибки статусу ответа и коду. Ошибки
+// сравниваются через errors.Is по порядку, первая совпавшая определяет ответ.
+var errorStatuses = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{errInvalidJSON, http.StatusBadRequest, problem.CodeInvalidJSON},
+	{errUnauthenticated, http.StatusUnauthorized, problem.CodeUnauthenticated},
+	{errForbidden, http.StatusForbidden, problem.CodeForbidden},
+	{errRouteNotFound, http.StatusNotFound, problem.CodeRouteNotFound},
+	{calendar.ErrInvalidCursor, http.StatusBadRequest, problem.CodeInvalidCursor},
+	{ical.ErrInvalidCalendar, http.StatusBadRequest, problem.CodeInvalidCalendar},
+	{repository.ErrEventNotFound, http.StatusNotFound, problem.CodeEventNotFound},
+	{repository.ErrWebhookNotFound, http.StatusNotFound, problem.CodeWebhookNotFound},
+	{webhook.ErrDeliveryNotFound, http.StatusNotFound, problem.CodeDeliveryNotFound},
+	{repository.ErrCalendarNotFound, http.StatusNotFound, problem.CodeCalendarNotFound},
+	{repository.ErrShareNotFound, http.StatusNotFound, problem.CodeShareNotFound},
+	{calendar.ErrVersionNotFound, http.StatusNotFound, problem.CodeVersionNotFound},
+	{repository.ErrAttendeeNotFound, http.StatusNotFound, problem.CodeAttendeeNotFound},
+	{calendar.ErrPermissionDenied, http.StatusForbidden, problem.CodePermissionDenied},
+	{calendar.ErrFreeBusyDenied, http.StatusForbidden, problem.CodeForbidden},
+	{repository.ErrEventExists, http.StatusConflict, problem.CodeEventExists},
+	{repository.ErrVersionMismatch, http.StatusPreconditionFailed, problem.CodeVersionMismatch},
+	{repository.ErrInvalidDataInput, http.StatusUnprocessableEntity, problem.CodeInvalidData},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, problem.CodeTimeout},
+}
+
+// fieldErrors сопоставляет ошибки проверки запроса полю и коду ошибки поля.
+var fieldErrors = []struct {
+	err   error
+	field string
+	code  string
+}{
+	{event.ErrInvalidUserID, "user_id", problem.FieldInvalid},
+	{event.ErrInvalidEventID, "event_id", problem.FieldInvalid},
+	{event.ErrInvalidWebhookID, "webhook_id", problem.FieldInvalid},
+	{event.ErrInvalidCalendarID, "calendar_id", problem.FieldInvalid},
+	{event.ErrDateRequired, "date", problem.FieldRequired},
+	{event.ErrInvalidDate, "date", problem.FieldInvalid},
+	{event.ErrInvalidDateTime, "date", problem.FieldInvalid},
+	{event.ErrAllDayWithTime, "date", problem.FieldConflict},
+	{event.ErrInvalidPeriod, "period", problem.FieldInvalid},
+	{event.ErrEmptyTitle, "title", problem.FieldRequired},
+	{event.ErrTitleTooLong, "title", problem.FieldTooLong},
+	{event.ErrInvalidEnd, "end", problem.FieldInvalid},
+	{event.ErrInvalidDuration, "duration", problem.FieldInvalid},
+	{event.ErrEndAndDuration, "duration", problem.FieldConflict},
+	{event.ErrInvalidTimeZone, "timezone", problem.FieldInvalid},
+	{event.ErrInvalidReminder, "reminders", problem.FieldOutOfRange},
+	{event.ErrInvalidRecurrence, "recurrence", problem.FieldInvalid},
+	{event.ErrOccurrenceRecurrence, "recurrence", problem.FieldConflict},
+	{event.ErrExDatesWithoutRecurrence, "exdates", problem.FieldConflict},
+	{event.ErrInvalidRange, "from", problem.FieldInvalid},
+	{event.ErrInvalidLimit, "limit", problem.FieldOutOfRange},
+	{event.ErrInvalidSort, "sort", problem.FieldInvalid},
+	{event.ErrInvalidOrder, "order", problem.FieldInvalid},
+	{event.ErrInvalidBatchSize, "operations", problem.FieldOutOfRange},
+	{event.ErrInvalidBatchMode, "mode", problem.FieldInvalid},
+	{event.ErrInvalidBatchOp, "op", problem.FieldInvalid},
+	{event.ErrInvalidWebhookURL, "url", problem.FieldInvalid},
+	{webhook.ErrForbiddenAddress, "url", problem.FieldInvalid},
+	{event.ErrInvalidWebhookEvents, "events", problem.FieldInvalid},
+	{event.ErrWebhookSecretTooLong, "secret", problem.FieldTooLong},
+	{event.ErrEmptyCalendarName, "name", problem.FieldRequired},
+	{event.ErrCalendarNameTooLong, "name", problem.FieldTooLong},
+	{event.ErrInvalidRole, "role", problem.FieldInvalid},
+	{event.ErrShareWithOwner, "user_id", problem.FieldConflict},
+	{event.ErrInvalidRSVP, "status", problem.FieldInvalid},
+	{event.ErrInviteOrganizer, "user_id", problem.FieldConflict},
+	{event.ErrInvalidUsers, "users", problem.FieldInvalid},
+	{event.ErrInvalidWorkingHours, "work_start", problem.FieldInvalid},
+	{event.ErrInvalidSlotDuration, "duration", problem.FieldInvalid},
+	{errFileRequired, "file", problem.FieldRequired},
+	{errInvalidLastEventID, "Last-Event-ID", problem.FieldInvalid},
+}
+
+// sendError отвечает на ошибку тела запроса или сервиса; ошибки проверки
+// полей получают статус 422.
+func sendError(w http.ResponseWriter, err error) {
+	problem.Send(w, problemFor(err, http.StatusUnprocessableEntity))
+}
+
+// sendParamError отвечает на ошибку разбора параметров пути и строки запроса,
+// а также тела устаревших маршрутов; ошибки проверки полей получают статус 400.
+func sendParamError(w http.ResponseWriter, err error) {
+	problem.Send(w, problemFor(err, http.StatusBadRequest))
+}
+
+// problemFor строит ответ на err. Ошибки проверки полей, в том числе
+// объединённые errors.Join, попадают в Errors со статусом invalidStatus.
+// Неизвестные ошибки пишутся в лог и отдаются клиенту без подробностей.
+func problemFor(err error, invalidStatus int) problem.Problem {
+	if fields := fieldProblems(err, ""); len(fields) > 0 {
+		details := make([]string, len(fields))
+		for i, field := range fields {
+			details[i] = field.Detail
+		}
+
+		p := problem.New(invalidStatus, problem.CodeValidationFailed, strings.Join(details, "; "))
+		p.Errors = fields
+		return p
+	}
+
+	for _, mapping := range errorStatuses {
+		if errors.Is(err, mapping.err) {
+			return problem.New(mapping.status, mapping.code, err.Error())
+		}
+	}
+
+	slog.Error("unhandled request error", "error", err)
+	return problem.New(http.StatusInternalServerError, problem.CodeInternal, "internal server error")
+}
+
+// fieldProblems собирает ошибки полей из err. Поля операции пакета
+// получают префикс operations[i].
+func fieldProblems(err error, prefix string) []problem.FieldError {
+	var batchErr *repository.BatchError
+	if errors.As(err, &batchErr) {
+		return fieldProblems(batchErr.Err, fmt.Sprintf("%soperations[%d].", prefix, batchErr.Index))
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var fields []problem.FieldError
+		for _, err := range joined.Unwrap() {
+			fields = append(fields, fieldProblems(err, prefix)...)
+		}
+		return fields
+	}
+
+	for _, mapping := range fieldErrors {
+		if errors.Is(err, mapping.err) {
+			return []problem.FieldError{{Field: prefix + mapping.field, Code: mapping.code, Detail: err.Error()}}
+		}
+	}
+	return nil
+}
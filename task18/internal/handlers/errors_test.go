@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		fields []string
+	}{
+		{"not found", repository.ErrEventNotFound, http.StatusNotFound, problem.CodeEventNotFound, nil},
+		{"wrapped conflict", fmt.Errorf("create: %w", repository.ErrEventExists), http.StatusConflict, problem.CodeEventExists, nil},
+		{"version mismatch", repository.ErrVersionMismatch, http.StatusPreconditionFailed, problem.CodeVersionMismatch, nil},
+		{"invalid data", fmt.Errorf("%w: end must not be before start", repository.ErrInvalidDataInput), http.StatusUnprocessableEntity, problem.CodeInvalidData, nil},
+		{"field error", event.ErrTitleTooLong, http.StatusUnprocessableEntity, problem.CodeValidationFailed, []string{"title"}},
+		{"joined field errors", errors.Join(event.ErrEmptyTitle, nil, event.ErrInvalidDateTime), http.StatusUnprocessableEntity, problem.CodeValidationFailed, []string{"title", "date"}},
+		{"batch field error", &repository.BatchError{Index: 2, Err: event.ErrInvalidEnd}, http.StatusUnprocessableEntity, problem.CodeValidationFailed, []string{"operations[2].end"}},
+		{"batch storage error", &repository.BatchError{Index: 0, Err: repository.ErrEventNotFound}, http.StatusNotFound, problem.CodeEventNotFound, nil},
+		{"unknown error", errors.New("disk I/O error"), http.StatusInternalServerError, problem.CodeInternal, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := problemFor(tt.err, http.StatusUnprocessableEntity)
+			assert.Equal(t, tt.status, p.Status)
+			assert.Equal(t, tt.code, p.Code)
+			assert.Equal(t, http.StatusText(tt.status), p.Title)
+
+			var fields []string
+			for _, field := range p.Errors {
+				fields = append(fields, field.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+
+	t.Run("internal details are hidden", func(t *testing.T) {
+		p := problemFor(errors.New("disk I/O error"), http.StatusBadRequest)
+		assert.NotContains(t, p.Detail, "disk")
+	})
+
+	t.Run("parameter errors are bad requests", func(t *testing.T) {
+		p := problemFor(event.ErrInvalidLimit, http.StatusBadRequest)
+		assert.Equal(t, http.StatusBadRequest, p.Status)
+		assert.Equal(t, []problem.FieldError{{Field: "limit", Code: problem.FieldOutOfRange, Detail: event.ErrInvalidLimit.Error()}}, p.Errors)
+	})
+}
+
+func TestHandlers_ProblemResponses(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	decodeProblem := func(t *testing.T, rec *httptest.ResponseRecorder) problem.Problem {
+		resp := rec.Result()
+		defer resp.Body.Close()
+		assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"))
+
+		var p problem.Problem
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		return p
+	}
+
+	t.Run("all invalid fields are reported", func(t *testing.T) {
+		body := `{"date": "tomorrow", "title": "` + strings.Repeat("x", 256) + `"}`
+		rec := doRequest(router, http.MethodPost, "/users/1/events", body)
+		require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		p := decodeProblem(t, rec)
+		assert.Equal(t, "about:blank", p.Type)
+		assert.Equal(t, http.StatusUnprocessableEntity, p.Status)
+		assert.Equal(t, problem.CodeValidationFailed, p.Code)
+		assert.Equal(t, []problem.FieldError{
+			{Field: "title", Code: problem.FieldTooLong, Detail: event.ErrTitleTooLong.Error()},
+			{Field: "date", Code: problem.FieldInvalid, Detail: event.ErrInvalidDateTime.Error()},
+		}, p.Errors)
+	})
+
+	t.Run("invalid query parameter", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/1/events", "")
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		p := decodeProblem(t, rec)
+		require.Len(t, p.Errors, 1)
+		assert.Equal(t, "date", p.Errors[0].Field)
+		assert.Equal(t, problem.FieldRequired, p.Errors[0].Code)
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date":`)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, problem.CodeInvalidJSON, decodeProblem(t, rec).Code)
+	})
+
+	t.Run("missing event", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/1/events/999", "")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+
+		p := decodeProblem(t, rec)
+		assert.Equal(t, problem.CodeEventNotFound, p.Code)
+		assert.Equal(t, repository.ErrEventNotFound.Error(), p.Detail)
+	})
+
+	t.Run("foreign calendar", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/2/events/1", "")
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Equal(t, problem.CodeForbidden, decodeProblem(t, rec).Code)
+	})
+}
+
+func TestHandlers_LegacyErrorStatuses(t *testing.T) {
+	h, _ := newTestHandlers(t)
+
+	rec := httptest.NewRecorder()
+	h.DeleteEvent(rec, asUser(httptest.NewRequest(http.MethodPost, "/delete_event", strings.NewReader(`{"event_id": 999}`)), 1))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), problem.CodeEventNotFound)
+}
@@ -0,0 +1,703 @@
+package handlers
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxEventBodySize = 1 << 20
+
+// ListEvents возвращает события пользователя за период
+// @Summary События пользователя за период
+// @Description Возвращает события пользователя за день, неделю (с понедельника) или месяц, содержащие date.
+// @Description Период считается в часовом поясе tz; в ответ попадают события и вхождения серий, пересекающиеся с ним.
+// @Description События собираются из собственных календарей пользователя и открытых ему чужих; calendar_id оставляет один календарь.
+// @Tags events
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param date query string true "Дата в формате YYYY-MM-DD"
+// @Param period query string false "Период" Enums(day, week, month) default(day)
+// @Param tz query string false "Часовой пояс IANA, в котором считаются границы периода (по умолчанию UTC)"
+// @Param calendar_id query int false "ID календаря; без него — события всех видимых пользователю календарей"
+// @Success 200 {object} repository.SuccessResponse{result=repository.EventsResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events [get]
+func (h *Handlers) ListEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	dateStr := query.Get("date")
+	if dateStr == "" {
+		sendParamError(w, event.ErrDateRequired)
+		return
+	}
+
+	date, err := event.ParseAndValidateDateInZone(dateStr, query.Get("tz"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	calendarID, err := event.ValidateCalendarFilter(query.Get("calendar_id"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	var events []repository.Event
+	switch query.Get("period") {
+	case "", "day":
+		events, err = h.serviceCalendar.GetEventsForDay(r.Context(), userID, calendarID, date)
+	case "week":
+		events, err = h.serviceCalendar.GetEventsForWeek(r.Context(), userID, calendarID, date)
+	case "month":
+		events, err = h.serviceCalendar.GetEventsForMonth(r.Context(), userID, calendarID, date)
+	default:
+		sendParamError(w, event.ErrInvalidPeriod)
+		return
+	}
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, repository.EventsResponse{Events: events}, http.StatusOK)
+}
+
+// SearchEvents возвращает события пользователя за произвольный интервал постранично
+// @Summary Поиск событий за интервал
+// @Description Возвращает события и вхождения серий, пересекающиеся с интервалом [from, to), постранично.
+// @Description from и to — даты (YYYY-MM-DD) или даты со временем в часовом поясе tz; дата в to входит в интервал целиком.
+// @Description Интервал не длиннее 366 дней. Для следующей страницы передайте meta.next_cursor в cursor, сохранив остальные параметры.
+// @Tags events
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param from query string true "Начало интервала"
+// @Param to query string true "Конец интервала"
+// @Param tz query string false "Часовой пояс IANA (по умолчанию UTC)"
+// @Param q query string false "Подстрока названия без учета регистра"
+// @Param sort query string false "Поле сортировки" Enums(date, created_at) default(date)
+// @Param order query string false "Порядок сортировки" Enums(asc, desc) default(asc)
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Success 200 {object} repository.SuccessResponse{result=repository.EventsPageResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/search [get]
+func (h *Handlers) SearchEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := event.ParseAndValidateRange(query.Get("from"), query.Get("to"), query.Get("tz"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	limit, err := event.ParseAndValidateLimit(query.Get("limit"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	if err := event.ValidateSort(query.Get("sort"), query.Get("order")); err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	page, err := h.serviceCalendar.SearchEvents(r.Context(), calendar.EventsQuery{
+		UserID:     userID,
+		From:       from,
+		To:         to,
+		Title:      query.Get("q"),
+		SortBy:     query.Get("sort"),
+		Descending: query.Get("order") == "desc",
+		Limit:      limit,
+		Cursor:     query.Get("cursor"),
+	})
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	events := page.Events
+	if events == nil {
+		events = []repository.Event{}
+	}
+
+	sendResponse(w, repository.EventsPageResponse{
+		Events: events,
+		Meta: repository.PageMeta{
+			Total:      page.Total,
+			Limit:      limit,
+			NextCursor: page.NextCursor,
+		},
+	}, http.StatusOK)
+}
+
+// AddEvent создает событие в календаре пользователя
+// @Summary Создать событие
+// @Description Создает событие в календаре пользователя и возвращает его адрес в заголовке Location.
+// @Description Поля задаются так же, как в /create_event.
+// @Tags events
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param event body repository.EventRequest true "Данные события" SchemaExample({"date": "2025-09-01T10:00", "duration": "1h", "timezone": "Europe/Moscow", "title": "example string"})
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 201 {object} repository.SuccessResponse{result=repository.Event}
+// @Header 201 {string} Location "/users/{id}/events/{eventID}"
+// @Header 201 {string} ETag "Версия события"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events [post]
+func (h *Handlers) AddEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.EventRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	newEvent, err := eventFromRequest(req)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	newEvent.UserID = userID
+
+	createdEvent, err := h.serviceCalendar.CreateEvent(r.Context(), newEvent)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Event created in handle",
+		"event_id", createdEvent.ID,
+		"user_id", createdEvent.UserID,
+		"title", createdEvent.Title,
+	)
+
+	w.Header().Set("Location", eventLocation(userID, createdEvent))
+	w.Header().Set("ETag", etag(createdEvent))
+	sendResponse(w, createdEvent, http.StatusCreated)
+}
+
+// GetEvent возвращает событие пользователя
+// @Summary Получить событие
+// @Description Возвращает событие, серию или измененное вхождение серии по ID.
+// @Tags events
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param If-None-Match header string false "ETag имеющейся копии; если версия не изменилась — 304"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Event}
+// @Header 200 {string} ETag "Версия события"
+// @Success 304 "Событие не изменилось"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID} [get]
+func (h *Handlers) GetEvent(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := h.serviceCalendar.GetEvent(r.Context(), eventID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(found))
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" {
+		versions, wildcard := etagVersions(noneMatch, true)
+		if wildcard || slices.Contains(versions, found.Version) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	sendResponse(w, found, http.StatusOK)
+}
+
+// ReplaceEvent заменяет событие целиком
+// @Summary Заменить событие
+// @Description Заменяет событие или серию целиком: не переданные exdates и reminders очищаются.
+// @Description Если указан occurrence_date, заменяется только вхождение серии в этот день; recurrence и exdates для него задавать нельзя.
+// @Tags events
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param occurrence_date query string false "День вхождения серии в формате YYYY-MM-DD"
+// @Param event body repository.EventRequest true "Новые данные события"
+// @Param If-Match header string false "ETag, полученный при чтении события; при несовпадении версии — 412"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Event}
+// @Header 200 {string} ETag "Версия события"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 412 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID} [put]
+func (h *Handlers) ReplaceEvent(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.EventRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.ExDates == nil {
+		req.ExDates = []string{}
+	}
+	if req.Reminders == nil {
+		req.Reminders = []int{}
+	}
+
+	version, ok := h.expectedVersion(w, r, eventID, userID)
+	if !ok {
+		return
+	}
+
+	h.saveEvent(w, r, userID, eventID, req, version)
+}
+
+// PatchEvent изменяет отдельные поля события
+// @Summary Изменить событие
+// @Description Изменяет только переданные поля события или серии; остальные сохраняются.
+// @Description При переносе date длительность события сохраняется, если не передан end или duration.
+// @Description Если указан occurrence_date, изменяется только вхождение серии в этот день.
+// @Tags events
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param occurrence_date query string false "День вхождения серии в формате YYYY-MM-DD"
+// @Param event body repository.PatchEventRequest true "Изменяемые поля" SchemaExample({"title": "new title"})
+// @Param If-Match header string false "ETag, полученный при чтении события; при несовпадении версии — 412"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Event}
+// @Header 200 {string} ETag "Версия события"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 412 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID} [patch]
+func (h *Handlers) PatchEvent(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	var patch repository.PatchEventRequest
+	if !decodeBody(w, r, &patch) {
+		return
+	}
+
+	version, ok := h.expectedVersion(w, r, eventID, userID)
+	if !ok {
+		return
+	}
+
+	var current repository.Event
+	if occurrenceStr := r.URL.Query().Get("occurrence_date"); occurrenceStr != "" {
+		occurrenceDate, err := event.ParseAndValidateDate(occurrenceStr)
+		if err != nil {
+			sendParamError(w, err)
+			return
+		}
+
+		current, err = h.serviceCalendar.GetOccurrence(r.Context(), eventID, userID, occurrenceDate)
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+		current.Recurrence = ""
+		current.ExDates = nil
+	} else {
+		var err error
+		current, err = h.serviceCalendar.GetEvent(r.Context(), eventID, userID)
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+	}
+
+	// Без If-Match изменение всё равно защищено от гонки между чтением и записью.
+	if version == 0 {
+		version = current.Version
+	}
+
+	h.saveEvent(w, r, userID, eventID, applyPatch(current, patch), version)
+}
+
+// RemoveEvent удаляет событие
+// @Summary Удалить событие
+// @Description Удаляет событие или серию вместе с измененными вхождениями.
+// @Description Если указан occurrence_date, удаляется только вхождение серии в этот день.
+// @Tags events
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param occurrence_date query string false "День вхождения серии в формате YYYY-MM-DD"
+// @Param If-Match header string false "ETag, полученный при чтении события; при несовпадении версии — 412"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 204
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 412 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID} [delete]
+func (h *Handlers) RemoveEvent(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := h.expectedVersion(w, r, eventID, userID)
+	if !ok {
+		return
+	}
+
+	var err error
+	if occurrenceStr := r.URL.Query().Get("occurrence_date"); occurrenceStr != "" {
+		occurrenceDate, parseErr := event.ParseAndValidateDate(occurrenceStr)
+		if parseErr != nil {
+			sendParamError(w, parseErr)
+			return
+		}
+		err = h.serviceCalendar.DeleteOccurrence(r.Context(), eventID, userID, occurrenceDate, version)
+	} else {
+		err = h.serviceCalendar.DeleteEvent(r.Context(), eventID, userID, version)
+	}
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Event deleted in handle",
+		"event_id", eventID,
+		"user_id", userID,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveEvent сохраняет событие eventID или, если задан occurrence_date, одно вхождение серии.
+// version — ожидаемая версия события или серии, 0 отключает проверку.
+func (h *Handlers) saveEvent(w http.ResponseWriter, r *http.Request, userID, eventID int, req repository.EventRequest, version int) {
+	changes, err := eventFromRequest(req)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var saved repository.Event
+	if occurrenceStr := r.URL.Query().Get("occurrence_date"); occurrenceStr != "" {
+		occurrenceDate, err := event.ParseAndValidateDate(occurrenceStr)
+		if err != nil {
+			sendParamError(w, err)
+			return
+		}
+		if changes.IsRecurring() || len(changes.ExDates) > 0 {
+			sendError(w, event.ErrOccurrenceRecurrence)
+			return
+		}
+
+		saved, err = h.serviceCalendar.UpdateOccurrence(r.Context(), eventID, userID, occurrenceDate, changes, version)
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+	} else {
+		changes.ID = eventID
+		changes.UserID = userID
+		changes.Version = version
+
+		saved, err = h.serviceCalendar.UpdateEvent(r.Context(), changes)
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+	}
+
+	h.log.Debug("Event updated in handle",
+		"event_id", saved.ID,
+		"user_id", saved.UserID,
+		"title", saved.Title,
+	)
+
+	if saved.ID != eventID {
+		w.Header().Set("Content-Location", eventLocation(userID, saved))
+	}
+	w.Header().Set("ETag", etag(saved))
+	sendResponse(w, saved, http.StatusOK)
+}
+
+// eventFromRequest проверяет тело запроса и собирает из него событие без ID и пользователя.
+// Ошибки всех полей возвращаются вместе.
+func eventFromRequest(req repository.EventRequest) (repository.Event, error) {
+	eventTime, timeErr := event.ParseAndValidateEventTime(req.Date, req.End, req.Duration, req.TimeZone, req.AllDay)
+	exDates, exDatesErr := event.ParseAndValidateDates(req.ExDates)
+
+	err := errors.Join(
+		event.ValidateTitle(req.Title),
+		timeErr,
+		event.ValidateRecurrence(req.Recurrence, req.ExDates),
+		exDatesErr,
+		event.ValidateReminders(req.Reminders),
+		event.ValidateCalendarID(req.CalendarID),
+	)
+	if err != nil {
+		return repository.Event{}, err
+	}
+
+	return repository.Event{
+		CalendarID: req.CalendarID,
+		Date:       eventTime.Start,
+		End:        eventTime.End,
+		AllDay:     eventTime.AllDay,
+		TimeZone:   eventTime.TimeZone,
+		Title:      req.Title,
+		Recurrence: req.Recurrence,
+		ExDates:    exDates,
+		Reminders:  req.Reminders,
+	}, nil
+}
+
+// applyPatch накладывает patch на текущее событие и возвращает полный запрос.
+// Время события переносится как длительность, поэтому новое начало без end
+// и duration сдвигает событие целиком. Исключенные даты и напоминания, которых
+// нет в patch, остаются nil и сохраняются сервисом календаря.
+func applyPatch(current repository.Event, patch repository.PatchEventRequest) repository.EventRequest {
+	req := repository.EventRequest{
+		TimeZone:   current.TimeZone,
+		Title:      current.Title,
+		Recurrence: current.Recurrence,
+	}
+
+	duration := current.EndTime().Sub(current.Date)
+	if current.AllDay {
+		req.Date = current.Date.Format("2006-01-02")
+	} else {
+		req.Date = current.Date.Format("2006-01-02T15:04:05")
+	}
+	if duration > 0 {
+		req.Duration = duration.String()
+	}
+
+	if patch.Date != nil {
+		// Длительность события на весь день не подходит событию со временем и наоборот.
+		if isDateOnly(*patch.Date) != current.AllDay {
+			req.Duration = ""
+		}
+		req.Date = *patch.Date
+	}
+	if patch.AllDay != nil {
+		req.AllDay = *patch.AllDay
+		if req.AllDay && !isDateOnly(req.Date) {
+			req.Date, _, _ = strings.Cut(req.Date, "T")
+			req.Duration = ""
+		}
+	}
+	if patch.End != nil {
+		req.End = *patch.End
+		req.Duration = ""
+	}
+	if patch.Duration != nil {
+		req.Duration = *patch.Duration
+		req.End = ""
+	}
+	if patch.TimeZone != nil {
+		req.TimeZone = *patch.TimeZone
+	}
+	if patch.Title != nil {
+		req.Title = *patch.Title
+	}
+	if patch.Recurrence != nil {
+		req.Recurrence = *patch.Recurrence
+	}
+	if patch.ExDates != nil {
+		req.ExDates = *patch.ExDates
+	}
+	if patch.Reminders != nil {
+		req.Reminders = *patch.Reminders
+		if req.Reminders == nil {
+			req.Reminders = []int{}
+		}
+	}
+
+	return req
+}
+
+func isDateOnly(dateStr string) bool {
+	return !strings.Contains(dateStr, "T")
+}
+
+// authorizePath — authorize для ID пользователя из пути ресурса.
+func authorizePath(w http.ResponseWriter, r *http.Request) (int, bool) {
+	claimedUserID, err := event.ValidateUserIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		sendParamError(w, err)
+		return 0, false
+	}
+
+	return authorize(w, r, claimedUserID)
+}
+
+func eventPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	eventID, err := event.ValidateEventIDParam(chi.URLParam(r, "eventID"))
+	if err != nil {
+		sendParamError(w, err)
+		return 0, 0, false
+	}
+
+	return userID, eventID, true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxEventBodySize)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		sendError(w, errInvalidJSON)
+		return false
+	}
+	return true
+}
+
+// etag — сильный ETag события: его версия.
+func etag(e repository.Event) string {
+	return `"` + strconv.Itoa(e.Version) + `"`
+}
+
+// etagVersions разбирает список ETag из If-Match или If-None-Match. Слабые
+// ETag (W/"...") учитываются только при weak, как требует слабое сравнение.
+// Версии событий начинаются с 1, поэтому ETag с версией 0 и меньше ни с чем
+// не совпадает и отбрасывается: иначе "0" отключил бы проверку версии.
+func etagVersions(header string, weak bool) ([]int, bool) {
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, true
+		}
+
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil || !strings.HasPrefix(tag, `"`) {
+			continue
+		}
+		if version, err := strconv.Atoi(unquoted); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, false
+}
+
+// expectedVersion возвращает версию события из If-Match; 0 — заголовка нет или он равен "*".
+// Если ни один ETag не может совпасть (в том числе "0"), отвечает 412.
+func (h *Handlers) expectedVersion(w http.ResponseWriter, r *http.Request, eventID, userID int) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	versions, wildcard := etagVersions(header, false)
+	switch {
+	case wildcard:
+		return 0, true
+	case len(versions) == 1:
+		return versions[0], true
+	case len(versions) == 0:
+		sendError(w, repository.ErrVersionMismatch)
+		return 0, false
+	}
+
+	current, err := h.serviceCalendar.GetEvent(r.Context(), eventID, userID)
+	if err != nil {
+		sendError(w, err)
+		return 0, false
+	}
+	if !slices.Contains(versions, current.Version) {
+		sendError(w, repository.ErrVersionMismatch)
+		return 0, false
+	}
+	return current.Version, true
+}
+
+// eventLocation — путь события для пользователя userID; событие чужого
+// календаря доступно ему по его собственному пути.
+func eventLocation(userID int, e repository.Event) string {
+	return fmt.Sprintf("/users/%d/events/%d", userID, e.ID)
+}
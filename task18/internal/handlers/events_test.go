@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"bytes"
+	"calendar/internal/calendar"
+	"calendar/internal/changes"
+	"calendar/internal/event/repository"
+	"calendar/internal/metrics"
+	"calendar/internal/middleware"
+	"calendar/internal/tracing"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(t *testing.T, userID int) http.Handler {
+	h, _ := newTestHandlers(t)
+	return testRouter(h, userID)
+}
+
+// testRouter — маршруты REST API, запросы к которым идут от пользователя userID.
+func testRouter(h *Handlers, userID int) http.Handler {
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.WithUserID(r.Context(), userID)))
+		})
+	})
+	router.Route("/users/{id}/events", func(r chi.Router) {
+		r.Get("/", h.ListEvents)
+		r.Post("/", h.AddEvent)
+		r.Get("/search", h.SearchEvents)
+		r.Post("/batch", h.BatchEvents)
+		r.Get("/{eventID}", h.GetEvent)
+		r.Put("/{eventID}", h.ReplaceEvent)
+		r.Patch("/{eventID}", h.PatchEvent)
+		r.Delete("/{eventID}", h.RemoveEvent)
+		r.Get("/{eventID}/history", h.EventHistory)
+		r.Post("/{eventID}/restore", h.RestoreEvent)
+		r.Get("/{eventID}/attendees", h.ListAttendees)
+		r.Put("/{eventID}/attendees/{userID}", h.InviteAttendee)
+		r.Delete("/{eventID}/attendees/{userID}", h.RemoveAttendee)
+		r.Put("/{eventID}/rsvp", h.RespondToInvitation)
+	})
+	router.Get("/users/{id}/history", h.UserHistory)
+	router.Get("/users/{id}/trash", h.ListTrash)
+	router.Post("/users/{id}/trash/{eventID}/restore", h.RestoreTrashedEvent)
+	router.Get("/users/{id}/invitations", h.ListInvitations)
+	router.Route("/users/{id}/calendars", func(r chi.Router) {
+		r.Get("/", h.ListCalendars)
+		r.Post("/", h.AddCalendar)
+		r.Get("/{calendarID}", h.GetCalendar)
+		r.Put("/{calendarID}", h.RenameCalendar)
+		r.Delete("/{calendarID}", h.RemoveCalendar)
+		r.Get("/{calendarID}/shares", h.ListCalendarShares)
+		r.Put("/{calendarID}/shares/{userID}", h.ShareCalendar)
+		r.Delete("/{calendarID}/shares/{userID}", h.UnshareCalendar)
+	})
+	router.Get("/freebusy", h.FreeBusy)
+	router.Route("/users/{id}/webhooks", func(r chi.Router) {
+		r.Get("/", h.ListWebhooks)
+		r.Post("/", h.AddWebhook)
+		r.Get("/dead_letters", h.DeadLetters)
+		r.Post("/dead_letters/{deliveryID}/retry", h.RetryDeadLetter)
+		r.Get("/{webhookID}", h.GetWebhook)
+		r.Delete("/{webhookID}", h.RemoveWebhook)
+		r.Get("/{webhookID}/deliveries", h.WebhookDeliveries)
+	})
+	return router
+}
+
+func doRequest(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func decodeEvent(t *testing.T, rec *httptest.ResponseRecorder) repository.Event {
+	var response struct {
+		Result repository.Event `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+	return response.Result
+}
+
+func TestHandlers_EventResource(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/events",
+		`{"date": "2025-09-01T10:00", "duration": "1h30m", "timezone": "Europe/Moscow", "title": "Standup", "reminders": [15]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	created := decodeEvent(t, rec)
+	location := rec.Header().Get("Location")
+	assert.Equal(t, "/users/1/events/1", location)
+
+	rec = doRequest(router, http.MethodGet, location, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Standup", decodeEvent(t, rec).Title)
+
+	t.Run("patch keeps untouched fields", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPatch, location, `{"date": "2025-09-02T12:00"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		patched := decodeEvent(t, rec)
+		assert.Equal(t, "Standup", patched.Title)
+		assert.Equal(t, "Europe/Moscow", patched.TimeZone)
+		assert.Equal(t, []int{15}, patched.Reminders)
+		assert.Equal(t, "2025-09-02T12:00:00+03:00", patched.Date.Format("2006-01-02T15:04:05Z07:00"))
+		assert.Equal(t, "2025-09-02T13:30:00+03:00", patched.End.Format("2006-01-02T15:04:05Z07:00"))
+	})
+
+	t.Run("put replaces the whole event", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPut, location, `{"date": "2025-09-03", "title": "Day off"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		replaced := decodeEvent(t, rec)
+		assert.Equal(t, created.ID, replaced.ID)
+		assert.True(t, replaced.AllDay)
+		assert.Equal(t, "UTC", replaced.TimeZone)
+		assert.Empty(t, replaced.Reminders)
+	})
+
+	t.Run("list by period", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/1/events?date=2025-09-01&period=week", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Day off")
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events?date=2025-09-01&period=year", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	rec = doRequest(router, http.MethodDelete, location, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = doRequest(router, http.MethodGet, location, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(router, http.MethodDelete, location, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandlers_EventResourceOccurrence(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/events",
+		`{"date": "2025-09-01T10:00", "duration": "1h", "title": "Weekly", "recurrence": "FREQ=WEEKLY;COUNT=4"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	location := rec.Header().Get("Location")
+
+	rec = doRequest(router, http.MethodPatch, location+"?occurrence_date=2025-09-08", `{"title": "Moved"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	override := decodeEvent(t, rec)
+	assert.Equal(t, "Moved", override.Title)
+	assert.Equal(t, "2025-09-08T10:00:00Z", override.Date.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, time.Hour, override.End.Sub(override.Date))
+	assert.Equal(t, eventLocation(1, override), rec.Header().Get("Content-Location"))
+
+	rec = doRequest(router, http.MethodPatch, location+"?occurrence_date=2025-09-15", `{"recurrence": "FREQ=DAILY"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	rec = doRequest(router, http.MethodDelete, location+"?occurrence_date=2025-09-15", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRequest(router, http.MethodDelete, location+"?occurrence_date=2025-09-16", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandlers_EventResourceErrors(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"another user", http.MethodGet, "/users/2/events/1", "", http.StatusForbidden},
+		{"invalid user id", http.MethodGet, "/users/abc/events/1", "", http.StatusBadRequest},
+		{"invalid event id", http.MethodGet, "/users/1/events/0", "", http.StatusBadRequest},
+		{"malformed json", http.MethodPost, "/users/1/events", `{"date":`, http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/users/1/events", `{"date": "2025-09-01", "title": "x", "user_id": 2}`, http.StatusBadRequest},
+		{"empty title", http.MethodPost, "/users/1/events", `{"date": "2025-09-01", "title": ""}`, http.StatusUnprocessableEntity},
+		{"invalid date", http.MethodPost, "/users/1/events", `{"date": "01.09.2025", "title": "x"}`, http.StatusUnprocessableEntity},
+		{"missing event", http.MethodPut, "/users/1/events/42", `{"date": "2025-09-01", "title": "x"}`, http.StatusNotFound},
+		{"patch missing event", http.MethodPatch, "/users/1/events/42", `{"title": "x"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(router, tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestHandlers_EventResourceETag(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T10:00", "title": "Standup"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+
+	withHeader := func(method, body, name, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/users/1/events/1", strings.NewReader(body))
+		req.Header.Set(name, value)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("conditional get", func(t *testing.T) {
+		rec := withHeader(http.MethodGet, "", "If-None-Match", `"1"`)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Body.String())
+
+		rec = withHeader(http.MethodGet, "", "If-None-Match", `W/"1"`)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+
+		rec = withHeader(http.MethodGet, "", "If-None-Match", `"7"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("update with current etag", func(t *testing.T) {
+		rec := withHeader(http.MethodPatch, `{"title": "Daily standup"}`, "If-Match", `"1"`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+		assert.Equal(t, 2, decodeEvent(t, rec).Version)
+	})
+
+	t.Run("stale etag is rejected", func(t *testing.T) {
+		rec := withHeader(http.MethodPut, `{"date": "2025-09-01T10:00", "title": "Lost"}`, "If-Match", `"1"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = withHeader(http.MethodPatch, `{"title": "Lost"}`, "If-Match", `"1", "5"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = withHeader(http.MethodDelete, "", "If-Match", `W/"2"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = withHeader(http.MethodDelete, "", "If-Match", `"1"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events/1", "")
+		assert.Equal(t, "Daily standup", decodeEvent(t, rec).Title)
+	})
+
+	t.Run("zero or negative etag does not skip the check", func(t *testing.T) {
+		rec := withHeader(http.MethodPatch, `{"title": "Lost"}`, "If-Match", `"0"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = withHeader(http.MethodPut, `{"date": "2025-09-01T10:00", "title": "Lost"}`, "If-Match", `"-3"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = withHeader(http.MethodDelete, "", "If-Match", `"0"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events/1", "")
+		assert.Equal(t, 2, decodeEvent(t, rec).Version)
+	})
+
+	t.Run("delete with matching etag", func(t *testing.T) {
+		rec := withHeader(http.MethodDelete, "", "If-Match", `"1", "2"`)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+}
+
+func TestHandlers_SearchEvents(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	for _, day := range []string{"01", "02", "03"} {
+		rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-`+day+`T10:00", "title": "Event `+day+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec := doRequest(router, http.MethodGet, "/users/1/events/search?from=2025-09-01&to=2025-09-02&limit=1", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Result repository.EventsPageResponse `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Result.Meta.Total)
+	assert.Equal(t, 1, response.Result.Meta.Limit)
+	require.Len(t, response.Result.Events, 1)
+	assert.Equal(t, "Event 01", response.Result.Events[0].Title)
+	require.NotEmpty(t, response.Result.Meta.NextCursor)
+
+	rec = doRequest(router, http.MethodGet, "/users/1/events/search?from=2025-09-01&to=2025-09-02&limit=1&cursor="+response.Result.Meta.NextCursor, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	response.Result = repository.EventsPageResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Result.Events, 1)
+	assert.Equal(t, "Event 02", response.Result.Events[0].Title)
+	assert.Empty(t, response.Result.Meta.NextCursor)
+
+	for _, query := range []string{
+		"from=2025-09-02&to=2025-09-01",
+		"from=2025-09-01&to=2025-09-02&limit=1000",
+		"from=2025-09-01&to=2025-09-02&sort=title",
+		"from=2025-09-01&to=2025-09-02&cursor=garbage",
+	} {
+		rec := doRequest(router, http.MethodGet, "/users/1/events/search?"+query, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestHandlers_Tracing(t *testing.T) {
+	var out bytes.Buffer
+	tracer := tracing.NewTracer(tracing.NewJSONExporter(&out))
+
+	repo := repository.Instrument(repository.NewEventRepository(slog.Default()), metrics.NewRegistry())
+	h := NewHandlers(calendar.NewServiceCalendar(repo, slog.Default()), changes.NewBus(0), nil, time.Second, slog.Default())
+
+	router := chi.NewRouter()
+	router.Use(middleware.Tracing(tracer))
+	router.Post("/users/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+		h.AddEvent(w, asUser(r, 1))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1/events", strings.NewReader(`{"date": "2025-09-01T10:00", "title": "Traced"}`))
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	spans := make(map[string]tracing.SpanData)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var span tracing.SpanData
+		require.NoError(t, json.Unmarshal([]byte(line), &span))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID.String())
+		spans[span.Name] = span
+	}
+
+	server, service, storage := spans["HTTP POST /users/{id}/events"], spans["calendar.CreateEvent"], spans["storage.create_event"]
+	require.Len(t, spans, 3, out.String())
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID.String())
+	assert.Equal(t, server.SpanID, service.ParentSpanID)
+	assert.Equal(t, service.SpanID, storage.ParentSpanID)
+}
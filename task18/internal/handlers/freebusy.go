@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"net/http"
+)
+
+// FreeBusy возвращает общую занятость пользователей и свободные окна
+// @Summary Занятость и подбор встречи
+// @Description Объединяет занятость пользователей users за интервал [from, to) и возвращает общие свободные
+// @Description отрезки в рабочие часы work_start–work_end (по часовому поясу tz). Если задан duration, slots содержит
+// @Description самые ранние окна этой длины, подряд внутри свободных отрезков. Занятость задают только события со
+// @Description временем: события на весь день время не занимают. Названия и другие поля событий не раскрываются.
+// @Description В users допустимы только сам запрашивающий и пользователи, открывшие ему хотя бы один календарь;
+// @Description для остальных возвращается 403. В списке не больше 50 пользователей.
+// @Tags freebusy
+// @Security BearerAuth
+// @Produce json
+// @Param users query string true "ID пользователей через запятую" example(1,2,3)
+// @Param from query string true "Начало интервала (YYYY-MM-DD или YYYY-MM-DDTHH:MM)"
+// @Param to query string true "Конец интервала; дата без времени включает весь день"
+// @Param tz query string false "Часовой пояс интервала и рабочих часов (IANA)" default(UTC)
+// @Param work_start query string false "Начало рабочего дня (HH:MM)" default(00:00)
+// @Param work_end query string false "Конец рабочего дня (HH:MM, не позже 24:00)" default(24:00)
+// @Param duration query string false "Длина искомого окна, например 30m или 1h"
+// @Param limit query int false "Наибольшее число окон (1-500)" default(50)
+// @Success 200 {object} repository.SuccessResponse{result=repository.FreeBusyResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /freebusy [get]
+func (h *Handlers) FreeBusy(w http.ResponseWriter, r *http.Request) {
+	requesterID, ok := authorize(w, r, 0)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	userIDs, err := event.ParseUserIDs(query.Get("users"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	from, to, err := event.ParseAndValidateRange(query.Get("from"), query.Get("to"), query.Get("tz"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+	loc, err := event.ParseTimeZone(query.Get("tz"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	workStart, workEnd, err := event.ParseWorkingHours(query.Get("work_start"), query.Get("work_end"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	duration, err := event.ParseSlotDuration(query.Get("duration"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	limit, err := event.ParseAndValidateLimit(query.Get("limit"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	result, err := h.serviceCalendar.FreeBusy(r.Context(), calendar.FreeBusyQuery{
+		RequesterID: requesterID,
+		UserIDs:     userIDs,
+		From:        from,
+		To:          to,
+		Location:    loc,
+		WorkStart:   workStart,
+		WorkEnd:     workEnd,
+		Duration:    duration,
+		Limit:       limit,
+	})
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Free/busy computed in handle",
+		"requester_id", requesterID,
+		"users", len(userIDs),
+		"busy", len(result.Busy),
+		"slots", len(result.Slots),
+	)
+
+	sendResponse(w, repository.FreeBusyResponse{
+		From:  from,
+		To:    to,
+		Busy:  result.Busy,
+		Free:  result.Free,
+		Slots: result.Slots,
+	}, http.StatusOK)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_FreeBusy(t *testing.T) {
+	h, _ := newTestHandlers(t)
+	alice := testRouter(h, 1)
+	bob := testRouter(h, 2)
+	carol := testRouter(h, 3)
+
+	rec := doRequest(bob, http.MethodPost, "/users/2/calendars", `{"name": "work"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var work repository.Calendar
+	decodeResult(t, rec, &work)
+	rec = doRequest(bob, http.MethodPut, "/users/2/calendars/"+strconv.Itoa(work.ID)+"/shares/1", `{"role": "viewer"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = doRequest(alice, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T09:00", "duration": "1h", "title": "Standup"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = doRequest(bob, http.MethodPost, "/users/2/events", `{"date": "2025-09-01T09:30", "duration": "1h", "title": "Review"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	t.Run("returns merged busy time and earliest slots", func(t *testing.T) {
+		rec := doRequest(alice, http.MethodGet,
+			"/freebusy?users=1,2&from=2025-09-01&to=2025-09-01&work_start=09:00&work_end=12:00&duration=30m&limit=2", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var result repository.FreeBusyResponse
+		decodeResult(t, rec, &result)
+		at := func(hour, minute int) time.Time {
+			return time.Date(2025, 9, 1, hour, minute, 0, 0, time.UTC)
+		}
+		require.Len(t, result.Busy, 1)
+		assert.True(t, result.Busy[0].Start.Equal(at(9, 0)))
+		assert.True(t, result.Busy[0].End.Equal(at(10, 30)))
+		require.Len(t, result.Free, 1)
+		assert.True(t, result.Free[0].Start.Equal(at(10, 30)))
+		require.Len(t, result.Slots, 2)
+		assert.True(t, result.Slots[1].Start.Equal(at(11, 0)))
+		assert.True(t, result.Slots[1].End.Equal(at(11, 30)))
+		assert.NotContains(t, rec.Body.String(), "Review")
+	})
+
+	t.Run("users who did not share a calendar are forbidden", func(t *testing.T) {
+		for _, requester := range []http.Handler{bob, carol} {
+			rec := doRequest(requester, http.MethodGet, "/freebusy?users=1&from=2025-09-01&to=2025-09-01", "")
+			require.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+			assert.Equal(t, problem.CodeForbidden, decodeProblem(t, rec).Code)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for target, field := range map[string]string{
+			"/freebusy?users=1,x&from=2025-09-01&to=2025-09-02":                               "users",
+			"/freebusy?users=1&from=2025-09-02&to=2025-09-01":                                 "from",
+			"/freebusy?users=1&from=2025-09-01&to=2025-09-02&work_start=18:00&work_end=09:00": "work_start",
+			"/freebusy?users=1&from=2025-09-01&to=2025-09-02&duration=-1h":                    "duration",
+		} {
+			rec := doRequest(alice, http.MethodGet, target, "")
+			require.Equal(t, http.StatusBadRequest, rec.Code, target)
+			assert.Equal(t, field, decodeProblem(t, rec).Errors[0].Field, target)
+		}
+	})
+}
@@ -1,39 +1,82 @@
 package handlers
 
 import (
+	"bytes"
 	"calendar/internal/calendar"
+	"calendar/internal/changes"
 	"calendar/internal/event"
 	"calendar/internal/event/repository"
+	"calendar/internal/middleware"
+	"calendar/internal/webhook"
+	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "calendar/docs"
 )
 
+const maxImportSize = 10 << 20
+
+// readinessTimeout ограничивает проверку хранилища в Readyz.
+const readinessTimeout = 2 * time.Second
+
 type Handlers struct {
 	serviceCalendar *calendar.ServiceCalendar
+	changes         *changes.Bus
+	webhooks        *webhook.Dispatcher
+	heartbeat       time.Duration
 	log             *slog.Logger
+
+	// streamsDone закрывается CloseStreams и завершает потоки изменений.
+	streamsDone chan struct{}
+	closeOnce   sync.Once
+
+	// draining выставляет Drain в начале остановки сервера.
+	draining atomic.Bool
 }
 
-func NewHandlers(serviceCalendar *calendar.ServiceCalendar, logger *slog.Logger) *Handlers {
+// NewHandlers создаёт обработчики API; потоки изменений читают из bus и раз
+// в heartbeat отправляют комментарий, чтобы соединение не закрылось по простою;
+// подписками на изменения управляет webhooks.
+func NewHandlers(serviceCalendar *calendar.ServiceCalendar, bus *changes.Bus, webhooks *webhook.Dispatcher, heartbeat time.Duration, logger *slog.Logger) *Handlers {
 	return &Handlers{
 		serviceCalendar: serviceCalendar,
+		changes:         bus,
+		webhooks:        webhooks,
+		heartbeat:       heartbeat,
 		log:             logger,
+		streamsDone:     make(chan struct{}),
 	}
 }
 
 // CreateEvent создает новое событие
 // @Summary Создать новое событие
-// @Description Создает новое событие в календаре пользователя
+// @Description Создает новое событие в календаре пользователя.
+// @Description Поле date принимает дату (YYYY-MM-DD — событие на весь день) или дату со временем (YYYY-MM-DDTHH:MM) в часовом поясе timezone.
+// @Description Конец задается полем end или duration (например, 1h30m); для событий на весь день end — последний день включительно.
+// @Description Поле recurrence задает повторение в формате RRULE (FREQ=DAILY|WEEKLY|MONTHLY|YEARLY, INTERVAL, BYDAY, COUNT, UNTIL),
+// @Description exdates — даты вхождений, исключенных из серии.
+// @Description reminders — за сколько минут до начала прислать напоминание (не больше 40320, то есть четырех недель).
 // @Tags events
+// @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param event body repository.CreateEventRequest true "Данные события" SchemaExample({"user_id": 1, "date": "YYYY-MM-DD", "title": "example string"})
+// @Param event body repository.CreateEventRequest true "Данные события" SchemaExample({"user_id": 1, "date": "2025-09-01T10:00", "duration": "1h", "timezone": "Europe/Moscow", "title": "example string", "recurrence": "FREQ=WEEKLY;BYDAY=MO"})
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
 // @Success 200 {object} repository.SuccessResponse{result=repository.Event}
-// @Failure 400 {object} repository.ErrorResponse
-// @Failure 503 {object} repository.ErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Deprecated
 // @Router /create_event [post]
 func (h *Handlers) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
@@ -42,24 +85,55 @@ func (h *Handlers) CreateEvent(w http.ResponseWriter, r *http.Request) {
 
 	var req repository.CreateEventRequest
 	if err := dec.Decode(&req); err != nil {
-		sendError(w, "invalid JSON", http.StatusBadRequest)
+		sendError(w, errInvalidJSON)
+		return
+	}
+
+	userID, ok := authorize(w, r, req.UserID)
+	if !ok {
 		return
 	}
 
-	if err := event.ValidateCreateRequest(req.UserID, req.Date, req.Title); err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+	if err := event.ValidateCreateRequest(userID, req.Date, req.Title); err != nil {
+		sendParamError(w, err)
 		return
 	}
 
-	date, err := event.ParseAndValidateDate(req.Date)
+	if err := event.ValidateRecurrence(req.Recurrence, req.ExDates); err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	if err := event.ValidateReminders(req.Reminders); err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	eventTime, err := event.ParseAndValidateEventTime(req.Date, req.End, req.Duration, req.TimeZone, req.AllDay)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendParamError(w, err)
 		return
 	}
 
-	createdEvent, err := h.serviceCalendar.CreateEvent(req.UserID, date, req.Title)
+	exDates, err := event.ParseAndValidateDates(req.ExDates)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		sendParamError(w, err)
+		return
+	}
+
+	createdEvent, err := h.serviceCalendar.CreateEvent(r.Context(), repository.Event{
+		UserID:     userID,
+		Date:       eventTime.Start,
+		End:        eventTime.End,
+		AllDay:     eventTime.AllDay,
+		TimeZone:   eventTime.TimeZone,
+		Title:      req.Title,
+		Recurrence: req.Recurrence,
+		ExDates:    exDates,
+		Reminders:  req.Reminders,
+	})
+	if err != nil {
+		sendError(w, err)
 		return
 	}
 
@@ -74,39 +148,110 @@ func (h *Handlers) CreateEvent(w http.ResponseWriter, r *http.Request) {
 
 // UpdateEvent обновляет существующее событие
 // @Summary Обновить событие
-// @Description Обновляет существующее событие в календаре пользователя
+// @Description Обновляет существующее событие или серию в календаре пользователя.
+// @Description Поля date, end, duration, all_day, timezone и reminders задаются так же, как при создании события.
+// @Description Если reminders не передан, напоминания события сохраняются; пустой список их отключает.
+// @Description Если указан occurrence_date, изменяется только вхождение серии в этот день, остальная серия не меняется.
 // @Tags events
+// @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param event body repository.UpdateEventRequest true "Данные для обновления события" SchemaExample({"event_id": 1, "user_id": 1, "date": "YYYY-MM-DD", "title": "example string"})
+// @Param event body repository.UpdateEventRequest true "Данные для обновления события" SchemaExample({"event_id": 1, "user_id": 1, "date": "2025-09-01T10:00", "end": "2025-09-01T11:30", "timezone": "Europe/Moscow", "title": "example string"})
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
 // @Success 200 {object} repository.SuccessResponse{result=repository.Event}
-// @Failure 400 {object} repository.ErrorResponse
-// @Failure 503 {object} repository.ErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Deprecated
 // @Router /update_event [post]
 func (h *Handlers) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	var req repository.UpdateEventRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "invalid JSON format", http.StatusBadRequest)
+		sendError(w, errInvalidJSON)
 		return
 	}
 
-	if err := event.ValidateUpdateRequest(req.EventID, req.UserID, req.Date, req.Title); err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+	userID, ok := authorize(w, r, req.UserID)
+	if !ok {
 		return
 	}
 
-	date, err := event.ParseAndValidateDate(req.Date)
-	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+	if err := event.ValidateUpdateRequest(req.EventID, userID, req.Date, req.Title); err != nil {
+		sendParamError(w, err)
+		return
+	}
+
+	if err := event.ValidateReminders(req.Reminders); err != nil {
+		sendParamError(w, err)
 		return
 	}
 
-	updatedEvent, err := h.serviceCalendar.UpdateEvent(req.EventID, req.UserID, date, req.Title)
+	eventTime, err := event.ParseAndValidateEventTime(req.Date, req.End, req.Duration, req.TimeZone, req.AllDay)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		sendParamError(w, err)
 		return
 	}
 
+	var updatedEvent repository.Event
+	if req.OccurrenceDate != "" {
+		if err := event.ValidateOccurrenceRequest(req.OccurrenceDate, req.Recurrence, req.ExDates); err != nil {
+			sendParamError(w, err)
+			return
+		}
+
+		occurrenceDate, err := event.ParseAndValidateDate(req.OccurrenceDate)
+		if err != nil {
+			sendParamError(w, err)
+			return
+		}
+
+		updatedEvent, err = h.serviceCalendar.UpdateOccurrence(r.Context(), req.EventID, userID, occurrenceDate,
+			repository.Event{
+				Date:      eventTime.Start,
+				End:       eventTime.End,
+				AllDay:    eventTime.AllDay,
+				TimeZone:  eventTime.TimeZone,
+				Title:     req.Title,
+				Reminders: req.Reminders,
+			}, 0)
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+	} else {
+		if err := event.ValidateRecurrence(req.Recurrence, req.ExDates); err != nil {
+			sendParamError(w, err)
+			return
+		}
+
+		exDates, err := event.ParseAndValidateDates(req.ExDates)
+		if err != nil {
+			sendParamError(w, err)
+			return
+		}
+
+		updatedEvent, err = h.serviceCalendar.UpdateEvent(r.Context(), repository.Event{
+			ID:         req.EventID,
+			UserID:     userID,
+			Date:       eventTime.Start,
+			End:        eventTime.End,
+			AllDay:     eventTime.AllDay,
+			TimeZone:   eventTime.TimeZone,
+			Title:      req.Title,
+			Recurrence: req.Recurrence,
+			ExDates:    exDates,
+			Reminders:  req.Reminders,
+		})
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+	}
+
 	h.log.Debug("Event updated in handle",
 		"event_id", updatedEvent.ID,
 		"user_id", updatedEvent.UserID,
@@ -118,36 +263,71 @@ func (h *Handlers) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 
 // DeleteEvent удаляет событие
 // @Summary Удалить событие
-// @Description Удаляет событие из календаря пользователя
+// @Description Удаляет событие из календаря пользователя.
+// @Description Если указан occurrence_date, удаляется только вхождение серии в этот день.
 // @Tags events
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param event body repository.DeleteEventRequest true "Данные для удаления события" SchemaExample({"event_id": 1, "user_id": 1})
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
 // @Success 200 {object} repository.SuccessResponse{result=object}
-// @Failure 400 {object} repository.ErrorResponse
-// @Failure 503 {object} repository.ErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Deprecated
 // @Router /delete_event [post]
 func (h *Handlers) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	var req repository.DeleteEventRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendError(w, "invalid JSON format", http.StatusBadRequest)
+		sendError(w, errInvalidJSON)
+		return
+	}
+
+	userID, ok := authorize(w, r, req.UserID)
+	if !ok {
 		return
 	}
 
-	if err := event.ValidateDeleteRequest(req.EventID, req.UserID); err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+	if err := event.ValidateDeleteRequest(req.EventID, userID); err != nil {
+		sendParamError(w, err)
 		return
 	}
 
-	err := h.serviceCalendar.DeleteEvent(req.EventID, req.UserID)
+	if req.OccurrenceDate != "" {
+		occurrenceDate, err := event.ParseAndValidateDate(req.OccurrenceDate)
+		if err != nil {
+			sendParamError(w, err)
+			return
+		}
+
+		if err := h.serviceCalendar.DeleteOccurrence(r.Context(), req.EventID, userID, occurrenceDate, 0); err != nil {
+			sendError(w, err)
+			return
+		}
+
+		h.log.Debug("Event occurrence deleted in handle",
+			"event_id", req.EventID,
+			"user_id", userID,
+			"occurrence_date", req.OccurrenceDate,
+		)
+
+		sendResponse(w, map[string]string{"result": "event occurrence deleted successfully"}, http.StatusOK)
+		return
+	}
+
+	err := h.serviceCalendar.DeleteEvent(r.Context(), req.EventID, userID, 0)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusServiceUnavailable)
+		sendError(w, err)
 		return
 	}
 
 	h.log.Debug("Event deleted in handle",
 		"event_id", req.EventID,
-		"user_id", req.UserID,
+		"user_id", userID,
 	)
 
 	sendResponse(w, map[string]string{"result": "event deleted successfully"}, http.StatusOK)
@@ -155,138 +335,314 @@ func (h *Handlers) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 
 // EventsForDay возвращает события на день
 // @Summary События на день
-// @Description Возвращает все события пользователя на указанный день
+// @Description Возвращает события всех календарей, видимых пользователю, на указанный день
+// @Description Период считается в часовом поясе tz; в ответ попадают события, пересекающиеся с ним.
 // @Tags events
+// @Security BearerAuth
 // @Produce json
-// @Param user_id query int true "ID пользователя"
+// @Param user_id query int false "ID пользователя; если указан, должен совпадать с пользователем токена"
 // @Param date query string true "Дата в формате YYYY-MM-DD"
+// @Param tz query string false "Часовой пояс IANA, в котором считаются границы периода (по умолчанию UTC)"
+// @Param calendar_id query int false "ID календаря; без него — события всех видимых пользователю календарей"
 // @Success 200 {object} repository.SuccessResponse{result=repository.EventsResponse}
-// @Failure 400 {object} repository.ErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Deprecated
 // @Router /events_for_day [get]
 func (h *Handlers) EventsForDay(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
 	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		sendParamError(w, event.ErrDateRequired)
+		return
+	}
 
-	if userIDStr == "" || dateStr == "" {
-		sendError(w, "user_id and date parameters are required", http.StatusBadRequest)
+	userID, ok := authorizeQuery(w, r)
+	if !ok {
 		return
 	}
 
-	userID, err := event.ValidateQueryParams(userIDStr, dateStr)
+	date, err := event.ParseAndValidateDateInZone(dateStr, r.URL.Query().Get("tz"))
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendParamError(w, err)
 		return
 	}
 
-	date, err := event.ParseAndValidateDate(dateStr)
+	calendarID, err := event.ValidateCalendarFilter(r.URL.Query().Get("calendar_id"))
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendParamError(w, err)
+		return
+	}
+
+	events, err := h.serviceCalendar.GetEventsForDay(r.Context(), userID, calendarID, date)
+	if err != nil {
+		sendError(w, err)
 		return
 	}
 
-	events := h.serviceCalendar.GetEventsForDay(userID, date)
 	sendResponse(w, repository.EventsResponse{Events: events}, http.StatusOK)
 }
 
 // EventsForWeek возвращает события на неделю
 // @Summary События на неделю
-// @Description Возвращает все события пользователя на указанную неделю
+// @Description Возвращает события всех календарей, видимых пользователю, на указанную неделю
+// @Description Период считается в часовом поясе tz; в ответ попадают события, пересекающиеся с ним.
 // @Tags events
+// @Security BearerAuth
 // @Produce json
-// @Param user_id query int true "ID пользователя"
+// @Param user_id query int false "ID пользователя; если указан, должен совпадать с пользователем токена"
 // @Param date query string true "Дата в формате YYYY-MM-DD"
+// @Param tz query string false "Часовой пояс IANA, в котором считаются границы периода (по умолчанию UTC)"
+// @Param calendar_id query int false "ID календаря; без него — события всех видимых пользователю календарей"
 // @Success 200 {object} repository.SuccessResponse{result=repository.EventsResponse}
-// @Failure 400 {object} repository.ErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Deprecated
 // @Router /events_for_week [get]
 func (h *Handlers) EventsForWeek(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
 	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		sendParamError(w, event.ErrDateRequired)
+		return
+	}
+
+	userID, ok := authorizeQuery(w, r)
+	if !ok {
+		return
+	}
 
-	if userIDStr == "" || dateStr == "" {
-		sendError(w, "user_id and date parameters are required", http.StatusBadRequest)
+	date, err := event.ParseAndValidateDateInZone(dateStr, r.URL.Query().Get("tz"))
+	if err != nil {
+		sendParamError(w, err)
 		return
 	}
 
-	userID, err := event.ValidateQueryParams(userIDStr, dateStr)
+	calendarID, err := event.ValidateCalendarFilter(r.URL.Query().Get("calendar_id"))
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendParamError(w, err)
 		return
 	}
 
-	date, err := event.ParseAndValidateDate(dateStr)
+	events, err := h.serviceCalendar.GetEventsForWeek(r.Context(), userID, calendarID, date)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendError(w, err)
 		return
 	}
 
-	events := h.serviceCalendar.GetEventsForWeek(userID, date)
 	sendResponse(w, repository.EventsResponse{Events: events}, http.StatusOK)
 }
 
 // EventsForMonth возвращает события на месяц
 // @Summary События на месяц
-// @Description Возвращает все события пользователя на указанный месяц
+// @Description Возвращает события всех календарей, видимых пользователю, на указанный месяц
+// @Description Период считается в часовом поясе tz; в ответ попадают события, пересекающиеся с ним.
 // @Tags events
+// @Security BearerAuth
 // @Produce json
-// @Param user_id query int true "ID пользователя"
+// @Param user_id query int false "ID пользователя; если указан, должен совпадать с пользователем токена"
 // @Param date query string true "Дата в формате YYYY-MM-DD"
+// @Param tz query string false "Часовой пояс IANA, в котором считаются границы периода (по умолчанию UTC)"
+// @Param calendar_id query int false "ID календаря; без него — события всех видимых пользователю календарей"
 // @Success 200 {object} repository.SuccessResponse{result=repository.EventsResponse}
-// @Failure 400 {object} repository.ErrorResponse
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Deprecated
 // @Router /events_for_month [get]
 func (h *Handlers) EventsForMonth(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
 	dateStr := r.URL.Query().Get("date")
+	if dateStr == "" {
+		sendParamError(w, event.ErrDateRequired)
+		return
+	}
 
-	if userIDStr == "" || dateStr == "" {
-		sendError(w, "user_id and date parameters are required", http.StatusBadRequest)
+	userID, ok := authorizeQuery(w, r)
+	if !ok {
+		return
+	}
+
+	date, err := event.ParseAndValidateDateInZone(dateStr, r.URL.Query().Get("tz"))
+	if err != nil {
+		sendParamError(w, err)
 		return
 	}
 
-	userID, err := event.ValidateQueryParams(userIDStr, dateStr)
+	calendarID, err := event.ValidateCalendarFilter(r.URL.Query().Get("calendar_id"))
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendParamError(w, err)
 		return
 	}
 
-	date, err := event.ParseAndValidateDate(dateStr)
+	events, err := h.serviceCalendar.GetEventsForMonth(r.Context(), userID, calendarID, date)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendError(w, err)
 		return
 	}
 
-	events := h.serviceCalendar.GetEventsForMonth(userID, date)
 	sendResponse(w, repository.EventsResponse{Events: events}, http.StatusOK)
 }
 
-func sendResponse(w http.ResponseWriter, data interface{}, statusCode int) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(statusCode)
+// ExportICal выгружает события пользователя в формате iCalendar
+// @Summary Экспорт в iCalendar
+// @Description Возвращает все события пользователя в формате RFC 5545 (.ics) для импорта в Thunderbird, Outlook и другие клиенты
+// @Description Время событий с часовым поясом выгружается с TZID, описание каждого пояса — в VTIMEZONE.
+// @Tags ical
+// @Security BearerAuth
+// @Produce text/calendar
+// @Param user_id query int false "ID пользователя; если указан, должен совпадать с пользователем токена"
+// @Success 200 {string} string "VCALENDAR"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /export.ics [get]
+func (h *Handlers) ExportICal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeQuery(w, r)
+	if !ok {
+		return
+	}
 
-	encoder := json.NewEncoder(w)
-	encoder.SetEscapeHTML(false)
+	var buf bytes.Buffer
+	if err := h.serviceCalendar.ExportICal(r.Context(), userID, &buf); err != nil {
+		sendError(w, err)
+		return
+	}
 
-	response := repository.SuccessResponse{Result: data}
-	if err := encoder.Encode(response); err != nil {
-		http.Error(w, `{"error": "failed to encode response"}`, http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		h.log.Warn("failed to write ics export", "user_id", userID, "error", err)
+	}
+}
+
+// ImportICal загружает события из iCalendar-файла
+// @Summary Импорт из iCalendar
+// @Description Загружает события из .ics файла (multipart поле file или тело запроса text/calendar).
+// @Description События с уже известным UID обновляются, остальные создаются. Для каждого VEVENT возвращается результат.
+// @Description TZID понимается как пояс IANA или название пояса Windows (W. Europe Standard Time); VEVENT с неизвестным TZID или TZID=Local не импортируется и отмечается ошибкой.
+// @Tags ical
+// @Security BearerAuth
+// @Accept mpfd
+// @Accept text/calendar
+// @Produce json
+// @Param user_id query int false "ID пользователя; если указан, должен совпадать с пользователем токена"
+// @Param file formData file false ".ics файл"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 200 {object} repository.SuccessResponse{result=repository.ImportResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /import [post]
+func (h *Handlers) ImportICal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeQuery(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			sendParamError(w, errFileRequired)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	items, err := h.serviceCalendar.ImportICal(r.Context(), userID, body)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	response := repository.ImportResponse{Items: items}
+	for _, item := range items {
+		switch item.Status {
+		case calendar.ImportCreated:
+			response.Created++
+		case calendar.ImportUpdated:
+			response.Updated++
+		default:
+			response.Failed++
+		}
+	}
+
+	h.log.Debug("Events imported in handle",
+		"user_id", userID,
+		"created", response.Created,
+		"updated", response.Updated,
+		"failed", response.Failed,
+	)
+
+	sendResponse(w, response, http.StatusOK)
+}
+
+// authorize возвращает ID пользователя из токена. user_id из запроса
+// необязателен; если он указан, то должен совпадать с пользователем токена.
+func authorize(w http.ResponseWriter, r *http.Request, claimedUserID int) (int, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		sendError(w, errUnauthenticated)
+		return 0, false
+	}
+
+	if claimedUserID != 0 && claimedUserID != userID {
+		sendError(w, errForbidden)
+		return 0, false
 	}
+
+	return userID, true
 }
 
-func sendError(w http.ResponseWriter, message string, statusCode int) {
+// authorizeQuery — authorize для параметра user_id в строке запроса.
+func authorizeQuery(w http.ResponseWriter, r *http.Request) (int, bool) {
+	var claimedUserID int
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		userID, err := event.ValidateUserIDParam(userIDStr)
+		if err != nil {
+			sendParamError(w, err)
+			return 0, false
+		}
+		claimedUserID = userID
+	}
+
+	return authorize(w, r, claimedUserID)
+}
+
+func sendResponse(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(statusCode)
 
 	encoder := json.NewEncoder(w)
 	encoder.SetEscapeHTML(false)
 
-	response := repository.ErrorResponse{Error: message}
+	response := repository.SuccessResponse{Result: data}
 	if err := encoder.Encode(response); err != nil {
-		http.Error(w, `{"error": "failed to encode error response"}`, http.StatusInternalServerError)
+		http.Error(w, `{"error": "failed to encode response"}`, http.StatusInternalServerError)
 	}
 }
 
 // HealthCheck проверка здоровья сервера
 // @Summary Проверка здоровья
-// @Description Проверяет, что сервер работает
+// @Description Проверяет, что сервер работает. Оставлен для совместимости, то же самое делает /livez;
+// @Description готовность с проверкой хранилища — /readyz.
 // @Tags utility
 // @Produce json
 // @Success 200 {object} repository.SuccessResponse{result=repository.SuccessResponse}
@@ -295,6 +651,59 @@ func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, map[string]string{"status": "ok", "timestamp": time.Now().Format(time.RFC3339)}, http.StatusOK)
 }
 
+// Livez проверка живости процесса
+// @Summary Живость
+// @Description Отвечает 200, пока процесс обслуживает запросы; зависимости не проверяются.
+// @Tags utility
+// @Produce json
+// @Success 200 {object} repository.SuccessResponse{result=repository.HealthResponse}
+// @Router /livez [get]
+func (h *Handlers) Livez(w http.ResponseWriter, r *http.Request) {
+	sendResponse(w, repository.HealthResponse{Status: "ok"}, http.StatusOK)
+}
+
+// Readyz проверка готовности принимать запросы
+// @Summary Готовность
+// @Description Отвечает 200, если хранилище событий доступно, и 503 not_ready, если оно недоступно
+// @Description или сервер останавливается и дожидается завершения запросов.
+// @Tags utility
+// @Produce json
+// @Success 200 {object} repository.SuccessResponse{result=repository.HealthResponse}
+// @Failure 503 {object} repository.SuccessResponse{result=repository.HealthResponse}
+// @Router /readyz [get]
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		sendResponse(w, repository.HealthResponse{
+			Status: "not_ready",
+			Checks: map[string]string{"server": "shutting down"},
+		}, http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.serviceCalendar.Ping(ctx); err != nil {
+		h.log.Warn("Readiness check failed", "error", err)
+		sendResponse(w, repository.HealthResponse{
+			Status: "not_ready",
+			Checks: map[string]string{"storage": err.Error()},
+		}, http.StatusServiceUnavailable)
+		return
+	}
+
+	sendResponse(w, repository.HealthResponse{
+		Status: "ready",
+		Checks: map[string]string{"storage": "ok"},
+	}, http.StatusOK)
+}
+
+// Drain переводит Readyz в not_ready: сервер останавливается и новых
+// запросов принимать не должен.
+func (h *Handlers) Drain() {
+	h.draining.Store(true)
+}
+
 func (h *Handlers) NotFound(w http.ResponseWriter, r *http.Request) {
-	sendError(w, "route not found", http.StatusNotFound)
+	sendError(w, errRouteNotFound)
 }
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/changes"
+	"calendar/internal/event/repository"
+	"calendar/internal/middleware"
+	"calendar/internal/webhook"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandlers(t *testing.T) (*Handlers, repository.Storage) {
+	bus := changes.NewBus(100)
+	storage := repository.NewEventRepository(slog.Default())
+	// Получатели в тестах — httptest.Server на loopback.
+	guard := webhook.NewGuard([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")})
+	webhooks := webhook.NewDispatcher(storage, guard, 0, 1, 2, time.Millisecond, nil, slog.Default())
+	webhooks.Start()
+	t.Cleanup(func() { _ = webhooks.Stop(t.Context()) })
+	repo := repository.Publish(storage, bus, webhooks)
+	service := calendar.NewServiceCalendar(repo, slog.Default())
+	return NewHandlers(service, bus, webhooks, time.Second, slog.Default()), repo
+}
+
+func asUser(req *http.Request, userID int) *http.Request {
+	return req.WithContext(middleware.WithUserID(req.Context(), userID))
+}
+
+func TestHandlers_Authorization(t *testing.T) {
+	h, repo := newTestHandlers(t)
+
+	foreign, err := repo.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), Title: "Private"})
+	require.NoError(t, err)
+
+	t.Run("user is taken from token", func(t *testing.T) {
+		body := `{"date": "2025-09-01T12:00", "title": "Mine"}`
+		rec := httptest.NewRecorder()
+		h.CreateEvent(rec, asUser(httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(body)), 1))
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), `"user_id":1`)
+	})
+
+	t.Run("unauthenticated request", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.EventsForDay(rec, httptest.NewRequest(http.MethodGet, "/events_for_day?date=2025-09-01", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("foreign user_id in query is forbidden", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.EventsForDay(rec, asUser(httptest.NewRequest(http.MethodGet, "/events_for_day?user_id=2&date=2025-09-01", nil), 1))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("foreign user_id in body is forbidden", func(t *testing.T) {
+		body := `{"event_id": ` + strconv.Itoa(foreign.ID) + `, "user_id": 2}`
+		rec := httptest.NewRecorder()
+		h.DeleteEvent(rec, asUser(httptest.NewRequest(http.MethodPost, "/delete_event", strings.NewReader(body)), 1))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		_, err := repo.GetEvent(t.Context(), foreign.ID, 2)
+		assert.NoError(t, err)
+	})
+
+	t.Run("other user's events are not visible", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.EventsForDay(rec, asUser(httptest.NewRequest(http.MethodGet, "/events_for_day?user_id=1&date=2025-09-01", nil), 1))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "Private")
+		assert.Contains(t, rec.Body.String(), "Mine")
+	})
+}
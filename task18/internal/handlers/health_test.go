@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event/repository"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_Readiness(t *testing.T) {
+	storage, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "calendar.db"), slog.Default())
+	require.NoError(t, err)
+	h := NewHandlers(calendar.NewServiceCalendar(storage, slog.Default()), nil, nil, time.Second, slog.Default())
+
+	readyz := func() (int, repository.HealthResponse) {
+		rec := httptest.NewRecorder()
+		h.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var health repository.HealthResponse
+		decodeResult(t, rec, &health)
+		return rec.Code, health
+	}
+
+	t.Run("ready with storage", func(t *testing.T) {
+		status, health := readyz()
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ready", health.Status)
+	})
+
+	t.Run("not ready without storage", func(t *testing.T) {
+		require.NoError(t, storage.Close())
+
+		status, health := readyz()
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "not_ready", health.Status)
+		assert.NotEmpty(t, health.Checks["storage"])
+
+		rec := httptest.NewRecorder()
+		h.Livez(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("not ready while draining", func(t *testing.T) {
+		h.Drain()
+
+		status, health := readyz()
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Contains(t, health.Checks, "server")
+	})
+}
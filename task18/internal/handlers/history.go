@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"net/http"
+)
+
+// EventHistory возвращает историю изменений события
+// @Summary История события
+// @Description Возвращает изменения события от новых к старым: действие, состояние до и после, кто и в каком
+// @Description запросе его изменил. История доступна и после удаления события. Для следующей страницы передайте
+// @Description next_cursor в cursor.
+// @Tags history
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Success 200 {object} repository.SuccessResponse{result=repository.HistoryResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID}/history [get]
+func (h *Handlers) EventHistory(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	h.sendHistory(w, r, calendar.HistoryQuery{UserID: userID, EventID: eventID})
+}
+
+// UserHistory возвращает историю изменений событий пользователя
+// @Summary История событий пользователя
+// @Description Возвращает изменения всех событий пользователя от новых к старым, включая изменения,
+// @Description сделанные другими пользователями в общих календарях. Для следующей страницы передайте
+// @Description next_cursor в cursor.
+// @Tags history
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param limit query int false "Размер страницы (1-500)" default(50)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Success 200 {object} repository.SuccessResponse{result=repository.HistoryResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/history [get]
+func (h *Handlers) UserHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	h.sendHistory(w, r, calendar.HistoryQuery{UserID: userID})
+}
+
+// RestoreEvent возвращает событие к прошлой версии
+// @Summary Восстановить событие
+// @Description Возвращает событие к версии из его истории как новое изменение со следующей версией.
+// @Description Без version восстанавливает удалённое событие в состоянии перед удалением, с тем же ID.
+// @Description Если календарь события удалён, событие восстанавливается вне календарей.
+// @Tags history
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Param restore body repository.RestoreRequest false "Версия события"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Event}
+// @Header 200 {string} ETag "Версия события"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 412 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/events/{eventID}/restore [post]
+func (h *Handlers) RestoreEvent(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.RestoreRequest
+	if r.ContentLength != 0 && !decodeBody(w, r, &req) {
+		return
+	}
+
+	restored, err := h.serviceCalendar.RestoreEvent(r.Context(), eventID, userID, req.Version)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Event restored in handle",
+		"event_id", restored.ID,
+		"user_id", userID,
+		"version", restored.Version,
+	)
+
+	w.Header().Set("ETag", etag(restored))
+	sendResponse(w, restored, http.StatusOK)
+}
+
+func (h *Handlers) sendHistory(w http.ResponseWriter, r *http.Request, query calendar.HistoryQuery) {
+	limit, err := event.ParseAndValidateLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		sendParamError(w, err)
+		return
+	}
+	query.Limit = limit
+	query.Cursor = r.URL.Query().Get("cursor")
+
+	page, err := h.serviceCalendar.History(r.Context(), query)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	entries := page.Entries
+	if entries == nil {
+		entries = []repository.HistoryEntry{}
+	}
+
+	sendResponse(w, repository.HistoryResponse{
+		Entries:    entries,
+		NextCursor: page.NextCursor,
+	}, http.StatusOK)
+}
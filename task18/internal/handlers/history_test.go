@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/problem"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_History(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T09:00", "duration": "1h", "title": "Standup"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	created := decodeEvent(t, rec)
+	rec = doRequest(router, http.MethodPatch, "/users/1/events/1", `{"title": "Daily"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	t.Run("event history lists changes", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/1/events/1/history", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var result repository.HistoryResponse
+		decodeResult(t, rec, &result)
+		require.Len(t, result.Entries, 2)
+		assert.Equal(t, repository.HistoryUpdated, result.Entries[0].Action)
+		assert.Equal(t, 1, result.Entries[0].ActorID)
+		assert.Equal(t, "Standup", result.Entries[0].Old.Title)
+		assert.Equal(t, "Daily", result.Entries[0].New.Title)
+	})
+
+	t.Run("user history is paged", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/1/history?limit=1", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var result repository.HistoryResponse
+		decodeResult(t, rec, &result)
+		require.Len(t, result.Entries, 1)
+		require.NotEmpty(t, result.NextCursor)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/history?limit=1&cursor="+result.NextCursor, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		result = repository.HistoryResponse{}
+		decodeResult(t, rec, &result)
+		require.Len(t, result.Entries, 1)
+		assert.Equal(t, repository.HistoryCreated, result.Entries[0].Action)
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("deleted event is restored", func(t *testing.T) {
+		rec := doRequest(router, http.MethodDelete, "/users/1/events/1", "")
+		require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+		rec = doRequest(router, http.MethodPost, "/users/1/events/1/restore", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "Daily", decodeEvent(t, rec).Title)
+		assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+		rec = doRequest(router, http.MethodPost, "/users/1/events/1/restore", `{"version": 1}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		restored := decodeEvent(t, rec)
+		assert.Equal(t, created.Title, restored.Title)
+		assert.Equal(t, 4, restored.Version)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events/1/restore", `{"version": 99}`)
+		require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+		assert.Equal(t, problem.CodeVersionNotFound, decodeProblem(t, rec).Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events/42/history", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/history?cursor=!", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/2/history", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
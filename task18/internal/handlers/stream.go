@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StreamChanges отправляет изменения событий пользователя по мере их появления
+// @Summary Поток изменений событий
+// @Description Server-Sent Events: каждое создание, изменение и удаление события пользователя приходит
+// @Description событием SSE с типом created, updated или deleted, ID изменения и телом repository.Change.
+// @Description Приходят и изменения событий, видимых через общий календарь или приглашение (user_id — владелец);
+// @Description удаление серии приходит вместе с удалением каждой её замены вхождения.
+// @Description После переподключения с заголовком Last-Event-ID сначала приходят пропущенные изменения;
+// @Description если часть из них уже недоступна, приходит событие reset — календарь нужно перечитать целиком.
+// @Description Пока изменений нет, периодически приходит комментарий ": heartbeat".
+// @Tags events
+// @Security BearerAuth
+// @Produce text/event-stream
+// @Param user_id query int false "ID пользователя (по умолчанию из токена)"
+// @Param Last-Event-ID header string false "ID последнего полученного изменения"
+// @Success 200 {object} repository.Change
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Router /events/stream [get]
+func (h *Handlers) StreamChanges(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeQuery(w, r)
+	if !ok {
+		return
+	}
+
+	var lastID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		lastID, err = strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			sendParamError(w, errInvalidLastEventID)
+			return
+		}
+	}
+
+	// Поток живёт дольше WriteTimeout сервера.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		sendError(w, err)
+		return
+	}
+
+	sub, missed, complete := h.changes.Subscribe(userID, lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, change := range missed {
+		writeChange(w, change)
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	h.log.Debug("Change stream opened",
+		"user_id", userID,
+		"last_event_id", lastID,
+		"replayed", len(missed),
+	)
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streamsDone:
+			return
+		case change, ok := <-sub.Changes():
+			if !ok {
+				return
+			}
+			writeChange(w, change)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// CloseStreams завершает открытые потоки изменений, а новые закрываются сразу.
+// Вызывается при остановке сервера, иначе она ждала бы потоки до таймаута.
+func (h *Handlers) CloseStreams() {
+	h.closeOnce.Do(func() { close(h.streamsDone) })
+}
+
+func writeChange(w io.Writer, change repository.Change) {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data)
+}
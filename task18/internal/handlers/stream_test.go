@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bufio"
+	"calendar/internal/event/repository"
+	"calendar/internal/middleware"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// readSSE читает следующее событие потока, пропуская комментарии.
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if e != (sseEvent{}) {
+				return e
+			}
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// streamServer запускает сервер с потоком изменений h от имени пользователя 1.
+func streamServer(t *testing.T, h *Handlers) string {
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.WithUserID(r.Context(), 1)))
+		})
+	})
+	router.Get("/events/stream", h.StreamChanges)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func openStream(t *testing.T, serverURL, lastEventID string) *bufio.Reader {
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, serverURL+"/events/stream", nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+func TestHandlers_StreamChanges(t *testing.T) {
+	h, repo := newTestHandlers(t)
+	serverURL := streamServer(t, h)
+
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("changes are pushed live", func(t *testing.T) {
+		stream := openStream(t, serverURL, "")
+
+		foreign, err := repo.CreateEvent(t.Context(), repository.Event{UserID: 2, Date: date, Title: "Foreign"})
+		require.NoError(t, err)
+		created, err := repo.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: date, Title: "Standup"})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteEvent(t.Context(), foreign.ID, 2, 0))
+		require.NoError(t, repo.DeleteEvent(t.Context(), created.ID, 1, 0))
+
+		e := readSSE(t, stream)
+		assert.Equal(t, "2", e.id)
+		assert.Equal(t, repository.ChangeCreated, e.event)
+
+		var change repository.Change
+		require.NoError(t, json.Unmarshal([]byte(e.data), &change))
+		assert.Equal(t, created.ID, change.EventID)
+		require.NotNil(t, change.Event)
+		assert.Equal(t, "Standup", change.Event.Title)
+
+		e = readSSE(t, stream)
+		assert.Equal(t, "4", e.id)
+		assert.Equal(t, repository.ChangeDeleted, e.event)
+	})
+
+	t.Run("resume from last event id", func(t *testing.T) {
+		stream := openStream(t, serverURL, "2")
+
+		e := readSSE(t, stream)
+		assert.Equal(t, "4", e.id)
+		assert.Equal(t, repository.ChangeDeleted, e.event)
+	})
+
+	t.Run("unknown last event id asks for reset", func(t *testing.T) {
+		stream := openStream(t, serverURL, "100")
+
+		e := readSSE(t, stream)
+		assert.Equal(t, "reset", e.event)
+	})
+
+	t.Run("heartbeat keeps the stream alive", func(t *testing.T) {
+		h := NewHandlers(h.serviceCalendar, h.changes, h.webhooks, 10*time.Millisecond, slog.Default())
+		stream := openStream(t, streamServer(t, h), "")
+
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, ": heartbeat\n", line)
+	})
+
+	t.Run("streams end on shutdown", func(t *testing.T) {
+		stream := openStream(t, serverURL, "")
+
+		h.CloseStreams()
+
+		_, err := stream.ReadString('\n')
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/events/stream", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		h.StreamChanges(rec, asUser(req, 1))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
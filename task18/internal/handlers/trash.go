@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"net/http"
+)
+
+// ListTrash возвращает корзину пользователя
+// @Summary Корзина
+// @Description Возвращает удалённые события пользователя, которые ещё можно восстановить, от недавно удалённых
+// @Description к давним. События хранятся в корзине TRASH_RETENTION после удаления, затем удаляются окончательно.
+// @Description Замены вхождений удалённой серии лежат в корзине отдельно с recurring_event_id серии.
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} repository.SuccessResponse{result=repository.TrashResponse}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/trash [get]
+func (h *Handlers) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := h.serviceCalendar.Trash(r.Context(), userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	if events == nil {
+		events = []repository.TrashedEvent{}
+	}
+
+	sendResponse(w, repository.TrashResponse{Events: events}, http.StatusOK)
+}
+
+// RestoreTrashedEvent возвращает событие из корзины
+// @Summary Восстановить событие из корзины
+// @Description Возвращает удалённое событие с тем же ID и следующей версией; серия восстанавливается вместе
+// @Description с заменами вхождений. Если календарь события удалён, событие восстанавливается вне календарей.
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param eventID path int true "ID события"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Event}
+// @Header 200 {string} ETag "Версия события"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 409 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/trash/{eventID}/restore [post]
+func (h *Handlers) RestoreTrashedEvent(w http.ResponseWriter, r *http.Request) {
+	userID, eventID, ok := eventPath(w, r)
+	if !ok {
+		return
+	}
+
+	restored, err := h.serviceCalendar.RestoreTrashedEvent(r.Context(), eventID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Event restored from trash in handle",
+		"event_id", restored.ID,
+		"user_id", userID,
+	)
+
+	w.Header().Set("ETag", etag(restored))
+	sendResponse(w, restored, http.StatusOK)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_Trash(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T09:00", "duration": "1h", "title": "Standup"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = doRequest(router, http.MethodDelete, "/users/1/events/1", "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	t.Run("deleted event is in trash", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/1/events/1", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events?date=2025-09-01&period=day", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NotContains(t, rec.Body.String(), "Standup")
+
+		rec = doRequest(router, http.MethodGet, "/users/1/trash", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var result repository.TrashResponse
+		decodeResult(t, rec, &result)
+		require.Len(t, result.Events, 1)
+		assert.Equal(t, "Standup", result.Events[0].Title)
+		assert.False(t, result.Events[0].DeletedAt.IsZero())
+	})
+
+	t.Run("event is restored from trash", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/trash/1/restore", "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, 1, decodeEvent(t, rec).ID)
+		assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+		rec = doRequest(router, http.MethodGet, "/users/1/events/1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = doRequest(router, http.MethodPost, "/users/1/trash/1/restore", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("other user's trash is forbidden", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/2/trash", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"calendar/internal/event"
+	"calendar/internal/event/repository"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AddWebhook создает подписку на изменения событий
+// @Summary Создать подписку на изменения
+// @Description Подписывает url на изменения событий пользователя: при создании, изменении и удалении события
+// @Description на url отправляется POST с JSON-телом webhook.Payload. events ограничивает типы изменений, пустой список — все.
+// @Description Тело подписывается HMAC-SHA256 секретом подписки: заголовок X-Webhook-Signature имеет вид t=<unix>,v1=<hex>,
+// @Description где v1 — подпись строки "<unix>.<тело>". Если secret не задан, он генерируется; secret возвращается только в этом ответе.
+// @Description url, разрешающийся в непубличный адрес (loopback, частные и link-local сети), отклоняется с 422.
+// @Description Доставка без ответа 2xx повторяется с экспоненциальной задержкой, после исчерпания попыток попадает в недоставленные.
+// @Tags webhooks
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param webhook body repository.WebhookRequest true "Подписка"
+// @Param Idempotency-Key header string false "Ключ повтора: повторный запрос с тем же ключом и телом получит сохранённый ответ"
+// @Success 201 {object} repository.SuccessResponse{result=repository.Webhook}
+// @Header 201 {string} Location "/users/{id}/webhooks/{webhookID}"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 422 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/webhooks [post]
+func (h *Handlers) AddWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req repository.WebhookRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := event.ValidateWebhook(req.URL, req.Secret, req.Events); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	created, err := h.webhooks.CreateWebhook(r.Context(), repository.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Webhook created in handle",
+		"webhook_id", created.ID,
+		"user_id", created.UserID,
+	)
+
+	w.Header().Set("Location", fmt.Sprintf("/users/%d/webhooks/%d", created.UserID, created.ID))
+	sendResponse(w, created, http.StatusCreated)
+}
+
+// ListWebhooks возвращает подписки пользователя
+// @Summary Список подписок на изменения
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} repository.SuccessResponse{result=[]repository.Webhook}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/webhooks [get]
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	hooks, err := h.webhooks.Webhooks(r.Context(), userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+
+	sendResponse(w, hooks, http.StatusOK)
+}
+
+// GetWebhook возвращает подписку пользователя
+// @Summary Получить подписку на изменения
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param webhookID path int true "ID подписки"
+// @Success 200 {object} repository.SuccessResponse{result=repository.Webhook}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/webhooks/{webhookID} [get]
+func (h *Handlers) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, webhookID, ok := webhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	hook, err := h.webhooks.Webhook(r.Context(), webhookID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	hook.Secret = ""
+
+	sendResponse(w, hook, http.StatusOK)
+}
+
+// RemoveWebhook удаляет подписку пользователя
+// @Summary Удалить подписку на изменения
+// @Description Удаляет подписку вместе с историей доставок; ждущие повтора доставки отменяются.
+// @Tags webhooks
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param webhookID path int true "ID подписки"
+// @Success 204 "Подписка удалена"
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/webhooks/{webhookID} [delete]
+func (h *Handlers) RemoveWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, webhookID, ok := webhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.DeleteWebhook(r.Context(), webhookID, userID); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Webhook deleted in handle",
+		"webhook_id", webhookID,
+		"user_id", userID,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WebhookDeliveries возвращает историю доставок подписки
+// @Summary История доставок подписки
+// @Description Возвращает последние 100 доставок подписки, новые первыми. История хранится в памяти и не переживает перезапуск.
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param webhookID path int true "ID подписки"
+// @Success 200 {object} repository.SuccessResponse{result=[]webhook.Delivery}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/webhooks/{webhookID}/deliveries [get]
+func (h *Handlers) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, webhookID, ok := webhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhooks.Deliveries(r.Context(), webhookID, userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, deliveries, http.StatusOK)
+}
+
+// DeadLetters возвращает недоставленные доставки пользователя
+// @Summary Недоставленные доставки
+// @Description Возвращает последние 100 доставок, исчерпавших попытки, новые первыми.
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} repository.SuccessResponse{result=[]webhook.Delivery}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Router /users/{id}/webhooks/dead_letters [get]
+func (h *Handlers) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	sendResponse(w, h.webhooks.DeadLetters(userID), http.StatusOK)
+}
+
+// RetryDeadLetter повторяет недоставленную доставку
+// @Summary Повторить недоставленную доставку
+// @Description Убирает доставку из недоставленных и отправляет её заново с полным числом попыток.
+// @Tags webhooks
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param deliveryID path string true "ID доставки"
+// @Success 202 {object} repository.SuccessResponse{result=webhook.Delivery}
+// @Failure 400 {object} problem.Problem
+// @Failure 401 {object} problem.Problem
+// @Failure 403 {object} problem.Problem
+// @Failure 404 {object} problem.Problem
+// @Failure 429 {object} problem.Problem
+// @Failure 500 {object} problem.Problem
+// @Router /users/{id}/webhooks/dead_letters/{deliveryID}/retry [post]
+func (h *Handlers) RetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhooks.Redeliver(r.Context(), userID, chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	h.log.Debug("Webhook delivery retried in handle",
+		"delivery_id", delivery.ID,
+		"user_id", userID,
+	)
+
+	sendResponse(w, delivery, http.StatusAccepted)
+}
+
+func webhookPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := authorizePath(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	webhookID, err := event.ValidateWebhookIDParam(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		sendParamError(w, err)
+		return 0, 0, false
+	}
+
+	return userID, webhookID, true
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/webhook"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeResult(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	response := struct {
+		Result any `json:"result"`
+	}{Result: v}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response), rec.Body.String())
+}
+
+func TestHandlers_Webhooks(t *testing.T) {
+	router := newTestRouter(t, 1)
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	payloads := make(chan webhook.Payload, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if _, err := webhook.Verify("s3cr3t", r.Header.Get(webhook.SignatureHeader), body); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var payload webhook.Payload
+		_ = json.Unmarshal(body, &payload)
+		payloads <- payload
+	}))
+	t.Cleanup(receiver.Close)
+
+	rec := doRequest(router, http.MethodPost, "/users/1/webhooks", `{"url": "`+receiver.URL+`", "secret": "s3cr3t", "events": ["created"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var hook repository.Webhook
+	decodeResult(t, rec, &hook)
+	assert.Equal(t, "s3cr3t", hook.Secret)
+	assert.Equal(t, []string{"created"}, hook.Events)
+	hookPath := "/users/1/webhooks/" + strconv.Itoa(hook.ID)
+	assert.Equal(t, hookPath, rec.Header().Get("Location"))
+
+	t.Run("secret is returned only on create", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, hookPath, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+
+		rec = doRequest(router, http.MethodGet, "/users/1/webhooks", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var hooks []repository.Webhook
+		decodeResult(t, rec, &hooks)
+		require.Len(t, hooks, 1)
+		assert.Empty(t, hooks[0].Secret)
+	})
+
+	t.Run("invalid webhook", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/webhooks", `{"url": "not a url"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		rec = doRequest(router, http.MethodPost, "/users/1/webhooks", `{"url": "https://example.com", "events": ["moved"]}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		rec = doRequest(router, http.MethodPost, "/users/1/webhooks", `{"url": "http://169.254.169.254/latest/meta-data"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"field":"url"`)
+
+		rec = doRequest(router, http.MethodGet, "/users/1/webhooks/abc", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("foreign webhooks are forbidden", func(t *testing.T) {
+		rec := doRequest(router, http.MethodGet, "/users/2/webhooks", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("event change is delivered", func(t *testing.T) {
+		rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-01T10:00", "title": "Standup"}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		created := decodeEvent(t, rec)
+
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, hook.ID, payload.WebhookID)
+			assert.Equal(t, repository.ChangeCreated, payload.Type)
+			assert.Equal(t, created.ID, payload.EventID)
+			require.NotNil(t, payload.Event)
+			assert.Equal(t, "Standup", payload.Event.Title)
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not delivered")
+		}
+
+		var deliveries []webhook.Delivery
+		require.Eventually(t, func() bool {
+			rec := doRequest(router, http.MethodGet, hookPath+"/deliveries", "")
+			decodeResult(t, rec, &deliveries)
+			return len(deliveries) == 1 && deliveries[0].Status == webhook.StatusDelivered
+		}, 5*time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("failed delivery goes to dead letters and can be retried", func(t *testing.T) {
+		healthy.Store(false)
+		rec := doRequest(router, http.MethodPost, "/users/1/events", `{"date": "2025-09-02T10:00", "title": "Review"}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var dead []webhook.Delivery
+		require.Eventually(t, func() bool {
+			rec := doRequest(router, http.MethodGet, "/users/1/webhooks/dead_letters", "")
+			decodeResult(t, rec, &dead)
+			return len(dead) == 1
+		}, 5*time.Second, 5*time.Millisecond)
+		assert.Equal(t, webhook.StatusDead, dead[0].Status)
+		assert.Equal(t, http.StatusServiceUnavailable, dead[0].ResponseStatus)
+
+		rec = doRequest(router, http.MethodPost, "/users/1/webhooks/dead_letters/unknown/retry", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		healthy.Store(true)
+		rec = doRequest(router, http.MethodPost, "/users/1/webhooks/dead_letters/"+dead[0].ID+"/retry", "")
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, dead[0].ID, payload.DeliveryID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not redelivered")
+		}
+
+		rec = doRequest(router, http.MethodGet, "/users/1/webhooks/dead_letters", "")
+		assert.JSONEq(t, `{"result": []}`, rec.Body.String())
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+		rec := doRequest(router, http.MethodDelete, hookPath, "")
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		rec = doRequest(router, http.MethodGet, hookPath, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		rec = doRequest(router, http.MethodGet, hookPath+"/deliveries", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,383 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	utcLayout      = "20060102T150405Z"
+
+	maxLineOctets = 75
+)
+
+var (
+	ErrInvalidCalendar = errors.New("invalid iCalendar data")
+	ErrInvalidEvent    = errors.New("invalid VEVENT")
+)
+
+// VEvent — поля VEVENT, которые календарь умеет хранить.
+type VEvent struct {
+	UID          string
+	Summary      string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Property — одна развёрнутая строка контента: имя, параметры и значение.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component — компонент VEVENT с исходными свойствами и номером строки BEGIN.
+type Component struct {
+	Line       int
+	Properties []Property
+}
+
+// Encode записывает события в w как VCALENDAR по RFC 5545.
+func Encode(w io.Writer, prodID string, events []VEvent) error {
+	lw := &lineWriter{w: w}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + escapeText(prodID))
+	lw.line("CALSCALE:GREGORIAN")
+	writeTimezones(lw, events)
+
+	stamp := time.Now().UTC().Format(utcLayout)
+	for _, event := range events {
+		lw.line("BEGIN:VEVENT")
+		lw.line("UID:" + escapeText(event.UID))
+		lw.line("DTSTAMP:" + stamp)
+		lw.line(formatDateProperty("DTSTART", event.Start, event.AllDay))
+		if !event.End.IsZero() && !event.End.Equal(event.Start) {
+			lw.line(formatDateProperty("DTEND", event.End, event.AllDay))
+		}
+		if event.RecurrenceID != nil {
+			lw.line(formatDateProperty("RECURRENCE-ID", *event.RecurrenceID, event.AllDay))
+		}
+		lw.line("SUMMARY:" + escapeText(event.Summary))
+		if event.RRule != "" {
+			lw.line("RRULE:" + event.RRule)
+		}
+		for _, exDate := range event.ExDates {
+			lw.line(formatDateProperty("EXDATE", exDate, event.AllDay))
+		}
+		if !event.Created.IsZero() {
+			lw.line("CREATED:" + event.Created.UTC().Format(utcLayout))
+		}
+		if !event.LastModified.IsZero() {
+			lw.line("LAST-MODIFIED:" + event.LastModified.UTC().Format(utcLayout))
+		}
+		lw.line("END:VEVENT")
+	}
+
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+// Decode разбирает VCALENDAR и возвращает его компоненты VEVENT.
+// Ошибка возвращается только для нарушенной структуры файла; содержимое
+// отдельных событий проверяет ParseEvent.
+func Decode(r io.Reader) ([]Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		components []Component
+		current    *Component
+		depth      int
+		inCalendar bool
+		seenCal    bool
+	)
+
+	for _, l := range lines {
+		prop, err := parseProperty(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, l.number, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			value := strings.ToUpper(prop.Value)
+			switch {
+			case !inCalendar:
+				if value != "VCALENDAR" {
+					return nil, fmt.Errorf("%w: line %d: expected BEGIN:VCALENDAR", ErrInvalidCalendar, l.number)
+				}
+				inCalendar, seenCal = true, true
+			case current == nil && value == "VEVENT":
+				current = &Component{Line: l.number}
+			default:
+				// Вложенные компоненты (VALARM, VTIMEZONE и т.п.) пропускаются.
+				depth++
+			}
+		case "END":
+			value := strings.ToUpper(prop.Value)
+			switch {
+			case depth > 0:
+				depth--
+			case current != nil && value == "VEVENT":
+				components = append(components, *current)
+				current = nil
+			case current == nil && value == "VCALENDAR" && inCalendar:
+				inCalendar = false
+			default:
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrInvalidCalendar, l.number, prop.Value)
+			}
+		default:
+			if current != nil && depth == 0 {
+				current.Properties = append(current.Properties, prop)
+			}
+		}
+	}
+
+	if !seenCal || inCalendar || current != nil {
+		return nil, fmt.Errorf("%w: unterminated VCALENDAR", ErrInvalidCalendar)
+	}
+
+	return components, nil
+}
+
+// ParseEvent переводит компонент VEVENT в VEvent.
+func ParseEvent(c Component) (VEvent, error) {
+	var (
+		event    VEvent
+		hasStart bool
+	)
+
+	for _, prop := range c.Properties {
+		var err error
+		switch prop.Name {
+		case "UID":
+			event.UID = unescapeText(prop.Value)
+		case "SUMMARY":
+			event.Summary = unescapeText(prop.Value)
+		case "DTSTART":
+			event.Start, event.AllDay, err = parseDateValue(prop)
+			hasStart = true
+		case "DTEND":
+			event.End, _, err = parseDateValue(prop)
+		case "RRULE":
+			event.RRule = prop.Value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.Value, ",") {
+				var exDate time.Time
+				exDate, _, err = parseDateValue(Property{Name: prop.Name, Params: prop.Params, Value: value})
+				if err != nil {
+					break
+				}
+				event.ExDates = append(event.ExDates, exDate)
+			}
+		case "RECURRENCE-ID":
+			var recurrenceID time.Time
+			recurrenceID, _, err = parseDateValue(prop)
+			event.RecurrenceID = &recurrenceID
+		case "CREATED":
+			event.Created, _, err = parseDateValue(prop)
+		case "LAST-MODIFIED":
+			event.LastModified, _, err = parseDateValue(prop)
+		}
+		if err != nil {
+			return VEvent{}, fmt.Errorf("%w: %s: %v", ErrInvalidEvent, prop.Name, err)
+		}
+	}
+
+	if !hasStart {
+		return VEvent{}, fmt.Errorf("%w: DTSTART is required", ErrInvalidEvent)
+	}
+
+	return event, nil
+}
+
+// formatDateProperty выводит время в UTC, а время с именованным часовым поясом —
+// с параметром TZID, чтобы клиенты разворачивали RRULE по местному времени.
+// Описание пояса для TZID пишет writeTimezones.
+func formatDateProperty(name string, t time.Time, allDay bool) string {
+	if allDay {
+		return name + ";VALUE=DATE:" + t.Format(dateLayout)
+	}
+
+	loc := t.Location()
+	if loc == time.UTC || loc == time.Local {
+		return name + ":" + t.UTC().Format(utcLayout)
+	}
+	return name + ";TZID=" + loc.String() + ":" + t.Format(dateTimeLayout)
+}
+
+func parseDateValue(prop Property) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.Value)
+
+	if strings.EqualFold(prop.Params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+
+	loc, err := loadLocation(prop.Params["TZID"])
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	return t, false, err
+}
+
+type contentLine struct {
+	number int
+	text   string
+}
+
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var lines []contentLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+
+		lines = append(lines, contentLine{number: number, text: text})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+
+	return lines, nil
+}
+
+func parseProperty(line string) (Property, error) {
+	prop := Property{Params: make(map[string]string)}
+
+	nameEnd := strings.IndexAny(line, ";:")
+	if nameEnd <= 0 {
+		return Property{}, fmt.Errorf("malformed content line %q", line)
+	}
+	prop.Name = strings.ToUpper(line[:nameEnd])
+
+	rest := line[nameEnd:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return Property{}, fmt.Errorf("malformed parameter in %q", line)
+		}
+		key := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return Property{}, fmt.Errorf("unterminated quoted parameter in %q", line)
+			}
+			value = rest[1 : end+1]
+			rest = rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return Property{}, fmt.Errorf("malformed parameter in %q", line)
+			}
+			value = rest[:end]
+			rest = rest[end:]
+		}
+		prop.Params[key] = value
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return Property{}, fmt.Errorf("missing value in %q", line)
+	}
+	prop.Value = rest[1:]
+
+	return prop, nil
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+func unescapeText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+// line пишет строку контента, сворачивая её по 75 октетов без разрыва UTF-8 символов.
+func (lw *lineWriter) line(text string) {
+	if lw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(text[cut]) {
+			cut--
+		}
+		b.WriteString(text[:cut])
+		b.WriteString("\r\n ")
+		text = text[cut:]
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(text)
+	b.WriteString("\r\n")
+
+	_, lw.err = io.WriteString(lw.w, b.String())
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
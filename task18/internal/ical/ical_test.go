@@ -0,0 +1,229 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	recurrenceID := time.Date(2025, 9, 8, 9, 0, 0, 0, time.UTC)
+	events := []VEvent{
+		{
+			UID:          "series-1",
+			Summary:      "Standup; daily, with \\ and\nnewline",
+			Start:        time.Date(2025, 9, 1, 9, 0, 0, 0, time.UTC),
+			End:          time.Date(2025, 9, 1, 9, 15, 0, 0, time.UTC),
+			RRule:        "FREQ=WEEKLY;BYDAY=MO,WE",
+			ExDates:      []time.Time{time.Date(2025, 9, 3, 9, 0, 0, 0, time.UTC)},
+			Created:      time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC),
+			LastModified: time.Date(2025, 8, 2, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			UID:          "series-1",
+			Summary:      "Moved standup",
+			Start:        time.Date(2025, 9, 9, 14, 0, 0, 0, time.UTC),
+			RecurrenceID: &recurrenceID,
+		},
+		{
+			UID:     "long-title",
+			Summary: strings.Repeat("Очень длинное название встречи ", 10),
+			Start:   time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			AllDay:  true,
+		},
+		{
+			UID:     "local",
+			Summary: "Lunch",
+			Start:   time.Date(2025, 9, 2, 13, 0, 0, 0, moscow),
+			End:     time.Date(2025, 9, 2, 14, 0, 0, 0, moscow),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, "-//test//EN", events))
+
+	assert.Contains(t, buf.String(), "DTSTART;TZID=Europe/Moscow:20250902T130000")
+	assert.Contains(t, buf.String(), "BEGIN:VTIMEZONE\r\nTZID:Europe/Moscow\r\n"+
+		"BEGIN:STANDARD\r\nDTSTART:20250101T000000\r\nTZOFFSETFROM:+0300\r\nTZOFFSETTO:+0300\r\nTZNAME:MSK\r\nEND:STANDARD\r\n"+
+		"END:VTIMEZONE\r\n")
+	assert.Contains(t, buf.String(), "DTEND;VALUE=DATE:20260101")
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+
+	components, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, components, len(events))
+
+	for i, component := range components {
+		parsed, err := ParseEvent(component)
+		require.NoError(t, err)
+
+		assert.Equal(t, events[i].UID, parsed.UID)
+		assert.Equal(t, events[i].Summary, parsed.Summary)
+		assert.True(t, events[i].Start.Equal(parsed.Start))
+		assert.True(t, events[i].End.Equal(parsed.End))
+		assert.Equal(t, events[i].AllDay, parsed.AllDay)
+		assert.Equal(t, events[i].RRule, parsed.RRule)
+		assert.Equal(t, len(events[i].ExDates), len(parsed.ExDates))
+		assert.Equal(t, events[i].RecurrenceID != nil, parsed.RecurrenceID != nil)
+		assert.True(t, events[i].Created.Equal(parsed.Created))
+	}
+}
+
+func TestDecode_ForeignCalendar(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//Mozilla.org/NONSGML Mozilla Calendar V1.1//EN\r\n" +
+		"BEGIN:VTIMEZONE\r\n" +
+		"TZID:Europe/Moscow\r\n" +
+		"END:VTIMEZONE\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:abc@example.com\r\n" +
+		"SUMMARY:Planning\r\n" +
+		"DTSTART;TZID=\"Europe/Moscow\":20250901T100000\r\n" +
+		"EXDATE;TZID=Europe/Moscow:20250908T100000,20250915T100000\r\n" +
+		"RRULE:FREQ=WEEKLY\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"ACTION:DISPLAY\r\n" +
+		"END:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:holiday@example.com\r\n" +
+		"SUMMARY:Holi\r\n" +
+		" day\r\n" +
+		"DTSTART;VALUE=DATE:20251231\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	components, err := Decode(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+
+	planning, err := ParseEvent(components[0])
+	require.NoError(t, err)
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	assert.True(t, time.Date(2025, 9, 1, 10, 0, 0, 0, moscow).Equal(planning.Start))
+	assert.Equal(t, "Europe/Moscow", planning.Start.Location().String())
+	assert.Len(t, planning.ExDates, 2)
+	assert.Equal(t, "FREQ=WEEKLY", planning.RRule)
+
+	holiday, err := ParseEvent(components[1])
+	require.NoError(t, err)
+	assert.Equal(t, "Holiday", holiday.Summary)
+	assert.True(t, holiday.AllDay)
+	assert.Equal(t, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), holiday.Start)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not a calendar", "BEGIN:VEVENT\r\nEND:VEVENT\r\n"},
+		{"unterminated", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n"},
+		{"malformed line", "BEGIN:VCALENDAR\r\ngarbage\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.data))
+			assert.ErrorIs(t, err, ErrInvalidCalendar)
+		})
+	}
+}
+
+func TestParseEvent_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		props []Property
+	}{
+		{"missing dtstart", []Property{{Name: "UID", Value: "x"}}},
+		{"bad dtstart", []Property{{Name: "DTSTART", Value: "tomorrow"}}},
+		{"unknown tzid", []Property{{Name: "DTSTART", Params: map[string]string{"TZID": "Mars/Olympus"}, Value: "20250901T100000"}}},
+		{"custom tzid", []Property{{Name: "DTSTART", Params: map[string]string{"TZID": "Customized Time Zone"}, Value: "20250901T100000"}}},
+		{"server local tzid", []Property{{Name: "DTSTART", Params: map[string]string{"TZID": "Local"}, Value: "20250901T100000"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseEvent(Component{Properties: tt.props})
+			assert.ErrorIs(t, err, ErrInvalidEvent)
+		})
+	}
+}
+
+func TestEncode_Timezones(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, "-//test//EN", []VEvent{
+		{UID: "weekly", Summary: "Sync", Start: time.Date(2025, 9, 1, 10, 0, 0, 0, berlin), RRule: "FREQ=WEEKLY"},
+		{UID: "call", Summary: "Call", Start: time.Date(2025, 9, 1, 10, 0, 0, 0, newYork)},
+		{UID: "utc", Summary: "UTC", Start: time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)},
+	}))
+	out := buf.String()
+
+	assert.Equal(t, 2, strings.Count(out, "BEGIN:VTIMEZONE"))
+	assert.Less(t, strings.Index(out, "TZID:America/New_York"), strings.Index(out, "TZID:Europe/Berlin"))
+
+	t.Run("transitions of event years are listed", func(t *testing.T) {
+		assert.Contains(t, out, "BEGIN:DAYLIGHT\r\nDTSTART:20250330T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\nEND:DAYLIGHT")
+		assert.Contains(t, out, "BEGIN:STANDARD\r\nDTSTART:20251026T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD")
+	})
+
+	t.Run("later years follow yearly rules", func(t *testing.T) {
+		assert.Contains(t, out, "DTSTART:20260329T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU")
+		assert.Contains(t, out, "DTSTART:20261025T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU")
+		assert.Contains(t, out, "TZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU")
+		assert.Contains(t, out, "TZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU")
+	})
+
+	components, err := Decode(&buf)
+	require.NoError(t, err)
+	assert.Len(t, components, 3)
+}
+
+func TestParseEvent_TZID(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		tzid string
+		want time.Time
+	}{
+		{"Europe/Berlin", time.Date(2025, 9, 1, 10, 0, 0, 0, berlin)},
+		{"W. Europe Standard Time", time.Date(2025, 9, 1, 10, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tzid, func(t *testing.T) {
+			event, err := ParseEvent(Component{Properties: []Property{
+				{Name: "DTSTART", Params: map[string]string{"TZID": tt.tzid}, Value: "20250901T100000"},
+			}})
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(event.Start), event.Start)
+			assert.Equal(t, tt.want.Location().String(), event.Start.Location().String())
+		})
+	}
+}
+
+func TestWindowsZones(t *testing.T) {
+	for windows, iana := range windowsZones {
+		_, err := time.LoadLocation(iana)
+		assert.NoError(t, err, windows)
+	}
+}
@@ -0,0 +1,202 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ruleYears — сколько лет после последнего события проверяется, что переходы
+// повторяются по годовому правилу. За восемь лет день месяца перехода успевает
+// пройти все семь дней недели, поэтому правило «последнее воскресенье» не
+// спутать с «четвёртым».
+const ruleYears = 8
+
+// zoneRange — часовой пояс, на который ссылаются события, и годы этих событий.
+type zoneRange struct {
+	loc      *time.Location
+	from, to int
+}
+
+// observance — STANDARD или DAYLIGHT компонента VTIMEZONE: смещение, которое
+// действует с момента start, и годовое правило его повторения, если оно есть.
+type observance struct {
+	start      time.Time
+	offsetFrom int
+	offsetTo   int
+	name       string
+	dst        bool
+	rrule      string
+}
+
+// writeTimezones пишет VTIMEZONE для каждого TZID, который появится в событиях.
+// Переходы за годы событий перечисляются явно, а последующие, если они
+// повторяются каждый год, задаются правилом RRULE, чтобы клиенты верно
+// разворачивали бессрочные серии.
+func writeTimezones(lw *lineWriter, events []VEvent) {
+	zones := make(map[string]*zoneRange)
+	add := func(t time.Time) {
+		loc := t.Location()
+		if loc == time.UTC || loc == time.Local {
+			return
+		}
+		zone, ok := zones[loc.String()]
+		if !ok {
+			zones[loc.String()] = &zoneRange{loc: loc, from: t.Year(), to: t.Year()}
+			return
+		}
+		zone.from = min(zone.from, t.Year())
+		zone.to = max(zone.to, t.Year())
+	}
+
+	for _, event := range events {
+		if event.AllDay {
+			continue
+		}
+		add(event.Start)
+		if !event.End.IsZero() {
+			add(event.End)
+		}
+		if event.RecurrenceID != nil {
+			add(*event.RecurrenceID)
+		}
+		for _, exDate := range event.ExDates {
+			add(exDate)
+		}
+	}
+
+	names := make([]string, 0, len(zones))
+	for name := range zones {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		lw.line("BEGIN:VTIMEZONE")
+		lw.line("TZID:" + name)
+		for _, o := range observances(zones[name]) {
+			o.write(lw)
+		}
+		lw.line("END:VTIMEZONE")
+	}
+}
+
+func observances(zone *zoneRange) []observance {
+	start := time.Date(zone.from, time.January, 1, 0, 0, 0, 0, zone.loc)
+	name, offset := start.Zone()
+	result := []observance{{start: start, offsetFrom: offset, offsetTo: offset, name: name, dst: start.IsDST()}}
+
+	end := time.Date(zone.to+1, time.January, 1, 0, 0, 0, 0, zone.loc)
+	result = append(result, transitions(start, end)...)
+
+	tail := transitions(end, end.AddDate(ruleYears, 0, 0))
+	if rules, ok := yearlyRules(tail); ok {
+		return append(result, rules...)
+	}
+	return append(result, tail...)
+}
+
+// transitions возвращает смены смещения или названия пояса в [from, to).
+func transitions(from, to time.Time) []observance {
+	var result []observance
+	for t := from; ; {
+		_, next := t.ZoneBounds()
+		if next.IsZero() || !next.Before(to) {
+			return result
+		}
+
+		_, offsetFrom := t.Zone()
+		name, offsetTo := next.Zone()
+		result = append(result, observance{start: next, offsetFrom: offsetFrom, offsetTo: offsetTo, name: name, dst: next.IsDST()})
+		t = next
+	}
+}
+
+// yearlyRules сворачивает переходы за ruleYears лет в правила вида
+// FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU. ok ложно, если переходы не повторяются
+// одинаково каждый год.
+func yearlyRules(tail []observance) ([]observance, bool) {
+	if len(tail) == 0 || len(tail)%ruleYears != 0 {
+		return nil, false
+	}
+	perYear := len(tail) / ruleYears
+
+	rules := make([]observance, perYear)
+	for i := range perYear {
+		first := tail[i]
+		rule, last := byDay(first.local(), false), true
+		for year := range ruleYears {
+			o := tail[year*perYear+i]
+			local := o.local()
+			if local.Year() != first.local().Year()+year || o.offsetFrom != first.offsetFrom ||
+				o.offsetTo != first.offsetTo || o.name != first.name || o.dst != first.dst ||
+				local.Month() != first.local().Month() || local.Weekday() != first.local().Weekday() ||
+				local.Format("150405") != first.local().Format("150405") {
+				return nil, false
+			}
+			last = last && local.AddDate(0, 0, 7).Month() != local.Month()
+			if byDay(local, false) != rule {
+				rule = ""
+			}
+		}
+
+		switch {
+		case last:
+			rule = byDay(first.local(), true)
+		case rule == "":
+			return nil, false
+		}
+
+		first.rrule = fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%s", first.local().Month(), rule)
+		rules[i] = first
+	}
+
+	return rules, true
+}
+
+// byDay возвращает день недели месяца в формате BYDAY: 2SU или -1SU для
+// последнего воскресенья.
+func byDay(t time.Time, last bool) string {
+	day := strings.ToUpper(t.Weekday().String()[:2])
+	if last {
+		return "-1" + day
+	}
+	return fmt.Sprintf("%d%s", (t.Day()-1)/7+1, day)
+}
+
+// local — момент перехода по местному времени до него, как того требует
+// DTSTART в VTIMEZONE.
+func (o observance) local() time.Time {
+	return o.start.UTC().Add(time.Duration(o.offsetFrom) * time.Second)
+}
+
+func (o observance) write(lw *lineWriter) {
+	kind := "STANDARD"
+	if o.dst {
+		kind = "DAYLIGHT"
+	}
+
+	lw.line("BEGIN:" + kind)
+	lw.line("DTSTART:" + o.local().Format(dateTimeLayout))
+	lw.line("TZOFFSETFROM:" + formatOffset(o.offsetFrom))
+	lw.line("TZOFFSETTO:" + formatOffset(o.offsetTo))
+	if o.rrule != "" {
+		lw.line("RRULE:" + o.rrule)
+	}
+	lw.line("TZNAME:" + escapeText(o.name))
+	lw.line("END:" + kind)
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.NewCounterVec("requests_total", "Total requests.", "route", "status")
+	requests.Inc("/b", "200")
+	requests.Inc("/a", "200")
+	requests.Add(2, "/a", "200")
+	requests.Inc(`/q"uo\te`+"\n", "500")
+
+	latency := reg.NewHistogramVec("latency_seconds", "Latency\nin seconds.", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.1, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(3, "/a")
+
+	inFlight := reg.NewGauge("in_flight", "In flight.")
+	inFlight.Inc()
+	inFlight.Inc()
+	inFlight.Dec()
+
+	reg.NewGaugeFunc("stored", "Stored.", func() float64 { return math.NaN() })
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+
+	assert.Equal(t, `# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{route="/a",status="200"} 3
+requests_total{route="/b",status="200"} 1
+requests_total{route="/q\"uo\\te\n",status="500"} 1
+# HELP latency_seconds Latency\nin seconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 2
+latency_seconds_bucket{route="/a",le="1"} 3
+latency_seconds_bucket{route="/a",le="+Inf"} 4
+latency_seconds_sum{route="/a"} 3.65
+latency_seconds_count{route="/a"} 4
+# HELP in_flight In flight.
+# TYPE in_flight gauge
+in_flight 1
+# HELP stored Stored.
+# TYPE stored gauge
+stored NaN
+`, out.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewGauge("up", "Up.").Set(1)
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "\nup 1\n")
+}
+
+func TestCounterVec_WrongLabelCount(t *testing.T) {
+	counter := NewRegistry().NewCounterVec("c", "C.", "a", "b")
+	assert.Panics(t, func() { counter.Inc("only-one") })
+}
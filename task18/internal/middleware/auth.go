@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"calendar/internal/problem"
+	"calendar/internal/requestctx"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid bearer token")
+)
+
+// Auth проверяет JWT из заголовка Authorization: Bearer <token>, подписанный
+// HMAC-SHA256 ключом secret. Токен должен содержать exp и sub с ID пользователя;
+// ID попадает в контекст запроса и доступен через GetUserID.
+func Auth(secret []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := authenticate(r, secret)
+			if err != nil {
+				unauthorized(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(requestctx.WithUserID(r.Context(), userID)))
+		})
+	}
+}
+
+// GetUserID возвращает ID пользователя, прошедшего аутентификацию.
+func GetUserID(ctx context.Context) (int, bool) {
+	return requestctx.UserID(ctx)
+}
+
+// WithUserID возвращает контекст с ID пользователя, как после успешной аутентификации.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return requestctx.WithUserID(ctx, userID)
+}
+
+// NewToken выпускает токен пользователя userID, действующий ttl.
+func NewToken(secret []byte, userID int, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
+
+func authenticate(r *http.Request, secret []byte) (int, error) {
+	header := r.Header.Get("Authorization")
+	scheme, tokenString, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(tokenString) == "" {
+		return 0, ErrMissingToken
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(strings.TrimSpace(tokenString), &claims,
+		func(*jwt.Token) (any, error) { return secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidToken
+	}
+
+	return userID, nil
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	challenge, code := `Bearer`, problem.CodeUnauthenticated
+	if errors.Is(err, ErrInvalidToken) {
+		challenge, code = `Bearer error="invalid_token"`, problem.CodeInvalidToken
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	problem.Send(w, problem.New(http.StatusUnauthorized, code, err.Error()))
+}
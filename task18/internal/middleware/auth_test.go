@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("test-secret")
+
+func TestAuth(t *testing.T) {
+	handler := Auth(testSecret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserID(r.Context())
+		require.True(t, ok)
+		_, _ = w.Write([]byte(strconv.Itoa(userID)))
+	}))
+
+	valid, err := NewToken(testSecret, 42, time.Hour)
+	require.NoError(t, err)
+	expired, err := NewToken(testSecret, 42, -time.Minute)
+	require.NoError(t, err)
+	foreign, err := NewToken([]byte("other-secret"), 42, time.Hour)
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, key any, claims jwt.Claims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	exp := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"valid token", "Bearer " + valid, http.StatusOK},
+		{"lowercase scheme", "bearer " + valid, http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"basic auth", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"expired", "Bearer " + expired, http.StatusUnauthorized},
+		{"wrong key", "Bearer " + foreign, http.StatusUnauthorized},
+		{"garbage", "Bearer not.a.token", http.StatusUnauthorized},
+		{"alg none", "Bearer " + sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType,
+			jwt.RegisteredClaims{Subject: "42", ExpiresAt: exp}), http.StatusUnauthorized},
+		{"other hmac alg", "Bearer " + sign(jwt.SigningMethodHS512, testSecret,
+			jwt.RegisteredClaims{Subject: "42", ExpiresAt: exp}), http.StatusUnauthorized},
+		{"without exp", "Bearer " + sign(jwt.SigningMethodHS256, testSecret,
+			jwt.RegisteredClaims{Subject: "42"}), http.StatusUnauthorized},
+		{"non-numeric sub", "Bearer " + sign(jwt.SigningMethodHS256, testSecret,
+			jwt.RegisteredClaims{Subject: "alice", ExpiresAt: exp}), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/events_for_day", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "42", rec.Body.String())
+			} else {
+				assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
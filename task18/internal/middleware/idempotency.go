@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"bytes"
+	"calendar/internal/problem"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyEvictionPeriod = time.Minute
+
+	// maxIdempotentBodySize — наибольшее тело среди маршрутов (импорт iCal):
+	// тело запроса с ключом читается в память целиком до обработчика.
+	maxIdempotentBodySize = 10 << 20
+)
+
+// replayedHeaders — заголовки ответа, которые сохраняются вместе с ним. Остальные
+// (X-Request-ID, traceparent, X-RateLimit-*) относятся к конкретному запросу.
+var replayedHeaders = []string{"Content-Type", "Location", "Content-Location", "ETag"}
+
+// IdempotencyCache запоминает первый ответ на изменяющий запрос с заголовком
+// Idempotency-Key и на ttl повторяет его для запросов того же пользователя с
+// тем же ключом. Ключ, повторно использованный с другим запросом, отклоняется
+// с 422, а повтор, пришедший до окончания первого запроса, — с 409.
+// Ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+type IdempotencyCache struct {
+	ttl         time.Duration
+	maxBodySize int64
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	completed   bool
+	expires     time.Time
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+func NewIdempotencyCache(ttl time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		ttl:         ttl,
+		maxBodySize: maxIdempotentBodySize,
+		now:         time.Now,
+		entries:     make(map[string]*idempotentResponse),
+	}
+}
+
+func (ic *IdempotencyCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		userID, authenticated := GetUserID(r.Context())
+		if key == "" || !authenticated || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			idempotencyError(w, http.StatusBadRequest, problem.CodeIdempotencyKeyTooLong, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ic.maxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			idempotencyError(w, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge, "request body is too large")
+			return
+		}
+		if err != nil {
+			idempotencyError(w, http.StatusBadRequest, problem.CodeBadRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		cacheKey := strconv.Itoa(userID) + ":" + key
+		fingerprint := requestFingerprint(r, body)
+
+		ic.mu.Lock()
+		if cached, ok := ic.entries[cacheKey]; ok && (!cached.completed || ic.now().Before(cached.expires)) {
+			// Первый запрос заполняет ответ под ic.mu, поэтому решение принимается
+			// по копии, снятой под той же блокировкой.
+			resp := *cached
+			ic.mu.Unlock()
+
+			switch {
+			case resp.fingerprint != fingerprint:
+				idempotencyError(w, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+			case !resp.completed:
+				idempotencyError(w, http.StatusConflict, problem.CodeIdempotencyInProgress, "request with this Idempotency-Key is still in progress")
+			default:
+				resp.replay(w)
+			}
+			return
+		}
+		pending := &idempotentResponse{fingerprint: fingerprint}
+		ic.entries[cacheKey] = pending
+		ic.mu.Unlock()
+
+		rec := &recordingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			ic.mu.Lock()
+			defer ic.mu.Unlock()
+
+			// Паника обработчика или ответ 5xx не фиксируют результат.
+			if !rec.finished || rec.status >= http.StatusInternalServerError {
+				delete(ic.entries, cacheKey)
+				return
+			}
+
+			pending.completed = true
+			pending.expires = ic.now().Add(ic.ttl)
+			pending.status = rec.status
+			pending.header = make(http.Header)
+			for _, name := range replayedHeaders {
+				if value := w.Header().Values(name); len(value) > 0 {
+					pending.header[name] = value
+				}
+			}
+			pending.body = rec.body.Bytes()
+		}()
+
+		next.ServeHTTP(rec, r)
+		rec.finished = true
+	})
+}
+
+func (resp *idempotentResponse) replay(w http.ResponseWriter) {
+	for name, values := range resp.header {
+		w.Header()[name] = slices.Clone(values)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
+
+// Start запускает фоновое удаление просроченных ответов.
+func (ic *IdempotencyCache) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ic.cancel = cancel
+	ic.done = make(chan struct{})
+
+	go func() {
+		defer close(ic.done)
+
+		ticker := time.NewTicker(idempotencyEvictionPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ic.evictExpired()
+			}
+		}
+	}()
+}
+
+// Stop останавливает удаление ответов и ждёт его завершения или отмены ctx.
+func (ic *IdempotencyCache) Stop(ctx context.Context) error {
+	if ic.cancel == nil {
+		return nil
+	}
+	ic.cancel()
+
+	select {
+	case <-ic.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ic *IdempotencyCache) evictExpired() {
+	now := ic.now()
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	for key, entry := range ic.entries {
+		if entry.completed && !now.Before(entry.expires) {
+			delete(ic.entries, key)
+		}
+	}
+}
+
+// requestFingerprint отличает запросы, отправленные с одним ключом: учитываются
+// метод, путь с параметрами и тело.
+func requestFingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	return sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+}
+
+func isMutating(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut ||
+		method == http.MethodPatch || method == http.MethodDelete
+}
+
+func idempotencyError(w http.ResponseWriter, status int, code, message string) {
+	problem.Send(w, problem.New(status, code, message))
+}
+
+// recordingResponseWriter передаёт ответ клиенту и запоминает его копию.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	finished bool
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	now := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	cache := NewIdempotencyCache(time.Hour)
+	cache.now = func() time.Time { return now }
+
+	calls := 0
+	status := http.StatusCreated
+	handler := cache.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Location", "/users/1/events/"+strconv.Itoa(calls))
+		w.Header().Set("X-Request-ID", "request-"+strconv.Itoa(calls))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"call": ` + strconv.Itoa(calls) + `, "body": ` + string(body) + `}`))
+	}))
+
+	send := func(method string, userID int, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/users/1/events", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(WithUserID(req.Context(), userID)))
+		return rec
+	}
+
+	first := send(http.MethodPost, 1, "create-1", `{"title": "A"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Equal(t, 1, calls)
+
+	t.Run("repeat is replayed", func(t *testing.T) {
+		rec := send(http.MethodPost, 1, "create-1", `{"title": "A"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, first.Body.String(), rec.Body.String())
+		assert.Equal(t, "/users/1/events/1", rec.Header().Get("Location"))
+		assert.Empty(t, rec.Header().Get("X-Request-ID"))
+		assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("different payload is rejected", func(t *testing.T) {
+		rec := send(http.MethodPost, 1, "create-1", `{"title": "B"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		rec = send(http.MethodDelete, 1, "create-1", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("keys are scoped by user", func(t *testing.T) {
+		rec := send(http.MethodPost, 2, "create-1", `{"title": "B"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("requests without key or reads are not cached", func(t *testing.T) {
+		send(http.MethodPost, 1, "", `{"title": "A"}`)
+		send(http.MethodPost, 1, "", `{"title": "A"}`)
+		send(http.MethodGet, 1, "read-1", "")
+		send(http.MethodGet, 1, "read-1", "")
+		assert.Equal(t, 6, calls)
+	})
+
+	t.Run("server errors are not stored", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		send(http.MethodPost, 1, "retry-1", `{}`)
+		status = http.StatusCreated
+
+		rec := send(http.MethodPost, 1, "retry-1", `{}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 8, calls)
+	})
+
+	t.Run("responses expire after ttl", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+		cache.evictExpired()
+
+		cache.mu.Lock()
+		assert.Empty(t, cache.entries)
+		cache.mu.Unlock()
+
+		rec := send(http.MethodPost, 1, "create-1", `{"title": "B"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 9, calls)
+	})
+
+	t.Run("too long key", func(t *testing.T) {
+		rec := send(http.MethodPost, 1, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("too large body is rejected", func(t *testing.T) {
+		cache.maxBodySize = 16
+		defer func() { cache.maxBodySize = maxIdempotentBodySize }()
+
+		rec := send(http.MethodPost, 1, "large", `{"title": "`+strings.Repeat("A", 16)+`"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, rec.Body.String(), "payload_too_large")
+
+		assert.Equal(t, 9, calls)
+
+		rec = send(http.MethodPost, 1, "large", `{}`)
+		assert.Equal(t, http.StatusCreated, rec.Code, "rejected request does not take the key")
+		assert.Equal(t, 10, calls)
+	})
+}
+
+func TestIdempotencyCache_InProgress(t *testing.T) {
+	cache := NewIdempotencyCache(time.Hour)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := cache.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/users/1/events", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "slow")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(WithUserID(context.Background(), 1)))
+		return rec.Code
+	}
+
+	first := make(chan int)
+	go func() { first <- send() }()
+	<-started
+
+	assert.Equal(t, http.StatusConflict, send())
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, <-first)
+	assert.Equal(t, http.StatusCreated, send())
+}
+
+func TestIdempotencyCache_ConcurrentRetries(t *testing.T) {
+	cache := NewIdempotencyCache(time.Hour)
+	handler := cache.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Повторы должны прийти и пока запрос выполняется, и пока сохраняется ответ.
+		time.Sleep(time.Millisecond)
+		w.Header().Set("Location", "/users/1/events/1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/users/1/events", strings.NewReader(`{}`))
+			req.Header.Set(IdempotencyKeyHeader, "concurrent")
+			rec := httptest.NewRecorder()
+			<-start
+			handler.ServeHTTP(rec, req.WithContext(WithUserID(context.Background(), 1)))
+
+			if rec.Code == http.StatusConflict {
+				return
+			}
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "/users/1/events/1", rec.Header().Get("Location"))
+			assert.JSONEq(t, `{"id": 1}`, rec.Body.String())
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
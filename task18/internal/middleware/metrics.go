@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"calendar/internal/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// unmatchedRoute заменяет шаблон маршрута у запросов, не попавших ни в один маршрут,
+// чтобы произвольные пути не порождали новые ряды метрик.
+const unmatchedRoute = "unmatched"
+
+// Metrics считает запросы и их длительность по шаблону маршрута chi и коду ответа,
+// а также число запросов, обрабатываемых в данный момент.
+func Metrics(reg *metrics.Registry) func(http.Handler) http.Handler {
+	requests := reg.NewCounterVec("http_requests_total",
+		"Total number of HTTP requests.", "method", "route", "status")
+	duration := reg.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds.", metrics.DefaultBuckets, "method", "route", "status")
+	inFlight := reg.NewGauge("http_requests_in_flight",
+		"Number of HTTP requests currently being served.")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			lw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(lw, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := strconv.Itoa(lw.statusCode)
+
+			requests.Inc(r.Method, route, status)
+			duration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"calendar/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	router := chi.NewRouter()
+	router.Use(Metrics(reg))
+	router.Route("/users/{id}/events", func(r chi.Router) {
+		r.Get("/{eventID}", func(w http.ResponseWriter, r *http.Request) {
+			if chi.URLParam(r, "eventID") == "404" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+	})
+
+	for _, target := range []string{"/users/1/events/10", "/users/2/events/20", "/users/1/events/404", "/unknown/path"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	var out strings.Builder
+	require.NoError(t, reg.Write(&out))
+
+	assert.Contains(t, out.String(), `http_requests_total{method="GET",route="/users/{id}/events/{eventID}",status="200"} 2`)
+	assert.Contains(t, out.String(), `http_requests_total{method="GET",route="/users/{id}/events/{eventID}",status="404"} 1`)
+	assert.Contains(t, out.String(), `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, out.String(), `http_request_duration_seconds_count{method="GET",route="/users/{id}/events/{eventID}",status="200"} 2`)
+	assert.Contains(t, out.String(), "\nhttp_requests_in_flight 0\n")
+}
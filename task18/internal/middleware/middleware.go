@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"calendar/internal/requestctx"
 	"calendar/logger"
 	"context"
 	"github.com/google/uuid"
@@ -8,31 +9,32 @@ import (
 	"time"
 )
 
-const RequestIDKey ctxKey = "requestID"
+const (
+	RequestIDHeader = "X-Request-ID"
 
-type ctxKey string
+	maxRequestIDLength = 128
+)
 
 type loggingResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
 }
 
+// RequestIDMiddleware берёт ID запроса из X-Request-ID вызывающего сервиса,
+// а если его нет или он некорректен, создаёт новый.
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := uuid.New().String()
-		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
-		w.Header().Set("X-Request-ID", requestID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(requestctx.WithRequestID(r.Context(), requestID)))
 	})
 }
 
 func GetRequestID(ctx context.Context) string {
-	if v := ctx.Value(RequestIDKey); v != nil {
-		if s, ok := v.(string); ok {
-			return s
-		}
-	}
-	return ""
+	return requestctx.RequestID(ctx)
 }
 
 func CharsetMiddleware(next http.Handler) http.Handler {
@@ -55,6 +57,7 @@ func RequestLogger(next http.Handler) http.Handler {
 
 		log := logger.RequestLogger.With(
 			"request_id", requestID,
+			"trace_id", GetTraceID(r.Context()),
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status_code", lw.statusCode,
@@ -66,7 +69,27 @@ func RequestLogger(next http.Handler) http.Handler {
 	})
 }
 
+// validRequestID допускает только печатные ASCII-символы без пробелов, чтобы
+// чужой ID не мог испортить заголовки и строки лога.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 func (lw *loggingResponseWriter) WriteHeader(code int) {
 	lw.statusCode = code
 	lw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter,
+// например чтобы отправить клиенту поток изменений.
+func (lw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
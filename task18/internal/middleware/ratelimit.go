@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"calendar/internal/problem"
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limit — параметры корзины токенов: Rate токенов в секунду, не больше Burst
+// накопленных. Нулевой Rate снимает ограничение.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter ограничивает частоту запросов каждого клиента отдельными корзинами
+// для чтения (GET, HEAD, OPTIONS) и записи. Клиент — пользователь из токена или,
+// при byIP и для запросов без пользователя, IP-адрес.
+type RateLimiter struct {
+	read    Limit
+	write   Limit
+	byIP    bool
+	idleTTL time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+func NewRateLimiter(read, write Limit, byIP bool, idleTTL time.Duration) *RateLimiter {
+	return &RateLimiter{
+		read:    read,
+		write:   write,
+		byIP:    byIP,
+		idleTTL: idleTTL,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Middleware отвечает 429 с Retry-After, когда корзина клиента пуста. Каждый
+// ответ с ограничением содержит заголовки X-RateLimit-Limit, X-RateLimit-Remaining
+// и X-RateLimit-Reset — секунды до полного восстановления корзины.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, write := rl.limits()
+		class, limit := "write", write
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			class, limit = "read", read
+		}
+		if limit.Rate <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, retryAfter := rl.take(class+":"+rl.clientKey(r), limit)
+
+		reset := (float64(limit.Burst) - remaining) / limit.Rate
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset))))
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			problem.Send(w, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rl *RateLimiter) limits() (read, write Limit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	return rl.read, rl.write
+}
+
+// SetLimits меняет лимиты на ходу. Накопленные токены корзин сохраняются и
+// при следующем запросе урезаются до нового Burst.
+func (rl *RateLimiter) SetLimits(read, write Limit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.read, rl.write = read, write
+}
+
+// take списывает токен из корзины key. Возвращает, разрешён ли запрос, сколько
+// токенов осталось и, если запрос отклонён, через сколько появится следующий токен.
+func (rl *RateLimiter) take(key string, limit Limit) (bool, float64, time.Duration) {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), lastSeen: now}
+		rl.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+		return false, b.tokens, wait
+	}
+
+	b.tokens--
+	return true, b.tokens, 0
+}
+
+func (rl *RateLimiter) clientKey(r *http.Request) string {
+	if !rl.byIP {
+		if userID, ok := GetUserID(r.Context()); ok {
+			return "user:" + strconv.Itoa(userID)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Start запускает фоновое удаление корзин, к которым не обращались дольше idleTTL.
+func (rl *RateLimiter) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	rl.cancel = cancel
+	rl.done = make(chan struct{})
+
+	go func() {
+		defer close(rl.done)
+
+		ticker := time.NewTicker(rl.idleTTL / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rl.evictIdle()
+			}
+		}
+	}()
+}
+
+// Stop останавливает удаление корзин и ждёт его завершения или отмены ctx.
+func (rl *RateLimiter) Stop(ctx context.Context) error {
+	if rl.cancel == nil {
+		return nil
+	}
+	rl.cancel()
+
+	select {
+	case <-rl.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (rl *RateLimiter) evictIdle() {
+	cutoff := rl.now().Add(-rl.idleTTL)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for key, b := range rl.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(rl.buckets, key)
+		}
+	}
+}
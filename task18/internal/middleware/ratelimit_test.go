@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"calendar/internal/problem"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(Limit{Rate: 10, Burst: 3}, Limit{Rate: 1, Burst: 2}, false, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	send := func(method string, userID int, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/users/1/events", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != 0 {
+			req = req.WithContext(WithUserID(req.Context(), userID))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("writes are limited separately from reads", func(t *testing.T) {
+		rec := send(http.MethodPost, 1, "10.0.0.1:1000")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, 1, "10.0.0.1:1000").Code)
+
+		rec = send(http.MethodPost, 1, "10.0.0.1:1000")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type": "about:blank", "title": "Too Many Requests", "status": 429,
+			"detail": "rate limit exceeded", "code": "rate_limited"}`, rec.Body.String())
+
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, 1, "10.0.0.1:1000").Code)
+	})
+
+	t.Run("clients are keyed by user", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, 2, "10.0.0.1:1000").Code)
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, 0, "10.0.0.1:1000").Code)
+	})
+
+	t.Run("tokens are refilled over time", func(t *testing.T) {
+		now = now.Add(500 * time.Millisecond)
+		assert.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, 1, "10.0.0.1:1000").Code)
+
+		now = now.Add(500 * time.Millisecond)
+		assert.Equal(t, http.StatusOK, send(http.MethodPost, 1, "10.0.0.1:1000").Code)
+	})
+
+	t.Run("idle buckets are evicted", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		send(http.MethodGet, 3, "10.0.0.2:1000")
+		limiter.evictIdle()
+
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		assert.Len(t, limiter.buckets, 1)
+		assert.Contains(t, limiter.buckets, "read:user:3")
+	})
+
+	t.Run("limits change at runtime", func(t *testing.T) {
+		limiter.SetLimits(Limit{Rate: 10, Burst: 3}, Limit{})
+		for range 5 {
+			assert.Equal(t, http.StatusOK, send(http.MethodPost, 4, "10.0.0.1:1000").Code)
+		}
+
+		limiter.SetLimits(Limit{Rate: 10, Burst: 3}, Limit{Rate: 1, Burst: 1})
+		rec := send(http.MethodPost, 4, "10.0.0.1:1000")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, http.StatusTooManyRequests, send(http.MethodPost, 4, "10.0.0.1:1000").Code)
+	})
+}
+
+func TestRateLimiter_ByIP(t *testing.T) {
+	limiter := NewRateLimiter(Limit{Rate: 1, Burst: 1}, Limit{}, true, time.Minute)
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(userID int, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(WithUserID(req.Context(), userID)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(1, "10.0.0.1:1000"))
+	assert.Equal(t, http.StatusTooManyRequests, send(2, "10.0.0.1:2000"))
+	assert.Equal(t, http.StatusOK, send(1, "10.0.0.2:1000"))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimiter_StartStop(t *testing.T) {
+	limiter := NewRateLimiter(Limit{}, Limit{}, false, 10*time.Millisecond)
+	limiter.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, limiter.Stop(ctx))
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"calendar/internal/tracing"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Tracing начинает span запроса. Если вызывающий сервис передал traceparent и
+// tracestate, span продолжает его трассу. В ответ возвращается traceparent
+// этого span, чтобы клиент мог найти запрос в трассах.
+func Tracing(tracer *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.WithTracer(r.Context(), tracer)
+			if parent, ok := tracing.Extract(r.Header); ok {
+				ctx = tracing.WithRemoteParent(ctx, parent)
+			}
+
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method)
+			defer span.End()
+
+			sc := span.SpanContext()
+			w.Header().Set(tracing.TraceparentHeader, sc.Traceparent())
+			if sc.TraceState != "" {
+				w.Header().Set(tracing.TracestateHeader, sc.TraceState)
+			}
+
+			lw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(lw, r.WithContext(ctx))
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			span.SetName("HTTP " + r.Method + " " + route)
+			span.SetAttr("http.method", r.Method)
+			span.SetAttr("http.route", route)
+			span.SetAttr("http.status_code", lw.statusCode)
+			if id := GetRequestID(r.Context()); id != "" {
+				span.SetAttr("request_id", id)
+			}
+			if lw.statusCode >= http.StatusInternalServerError {
+				span.RecordError(errors.New(http.StatusText(lw.statusCode)))
+			}
+		})
+	}
+}
+
+// GetTraceID возвращает ID трассы текущего запроса или пустую строку.
+func GetTraceID(ctx context.Context) string {
+	span := tracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	return span.SpanContext().TraceID.String()
+}
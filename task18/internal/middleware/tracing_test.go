@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"calendar/internal/tracing"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	var out bytes.Buffer
+	tracer := tracing.NewTracer(tracing.NewJSONExporter(&out))
+
+	router := chi.NewRouter()
+	router.Use(RequestIDMiddleware)
+	router.Use(Tracing(tracer))
+	router.Get("/users/{id}/events/{eventID}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "calendar.GetEvent")
+		span.End()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1/events/7", nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(RequestIDHeader, "upstream-42")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "upstream-42", rec.Header().Get(RequestIDHeader))
+	response, err := tracing.ParseTraceparent(rec.Header().Get(tracing.TraceparentHeader))
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", response.TraceID.String())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var inner, server tracing.SpanData
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &inner))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &server))
+
+	assert.Equal(t, "HTTP GET /users/{id}/events/{eventID}", server.Name)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID.String())
+	assert.Equal(t, response.SpanID, server.SpanID)
+	assert.Equal(t, tracing.StatusError, server.Status)
+	assert.Equal(t, "upstream-42", server.Attributes["request_id"])
+	assert.Equal(t, float64(http.StatusServiceUnavailable), server.Attributes["http.status_code"])
+
+	assert.Equal(t, "calendar.GetEvent", inner.Name)
+	assert.Equal(t, server.SpanID, inner.ParentSpanID)
+	assert.Equal(t, server.TraceID, inner.TraceID)
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(GetRequestID(r.Context())))
+	}))
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("x", maxRequestIDLength+1), "line\nbreak"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header[RequestIDHeader] = []string{incoming}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.NotEqual(t, incoming, rec.Body.String())
+		assert.Len(t, rec.Body.String(), 36, "incoming %q", incoming)
+	}
+}
@@ -0,0 +1,44 @@
+package problem
+
+// Коды ошибок ответа (Problem.Code). Коды стабильны: клиенты могут на них
+// полагаться, а текст Detail может меняться.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidJSON      = "invalid_json"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthenticated  = "unauthenticated"
+	CodeInvalidToken     = "invalid_token"
+	CodeForbidden        = "forbidden"
+	CodeRouteNotFound    = "route_not_found"
+	CodeRateLimited      = "rate_limited"
+	CodeTimeout          = "timeout"
+	CodeInternal         = "internal_error"
+
+	CodeIdempotencyKeyTooLong = "idempotency_key_too_long"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+
+	CodeEventNotFound    = "event_not_found"
+	CodeEventExists      = "event_exists"
+	CodeVersionMismatch  = "version_mismatch"
+	CodeInvalidData      = "invalid_data"
+	CodeInvalidCursor    = "invalid_cursor"
+	CodeInvalidCalendar  = "invalid_calendar"
+	CodeWebhookNotFound  = "webhook_not_found"
+	CodeDeliveryNotFound = "delivery_not_found"
+	CodeCalendarNotFound = "calendar_not_found"
+	CodeShareNotFound    = "share_not_found"
+	CodePermissionDenied = "permission_denied"
+	CodeVersionNotFound  = "version_not_found"
+	CodeAttendeeNotFound = "attendee_not_found"
+)
+
+// Коды ошибок полей (FieldError.Code).
+const (
+	FieldRequired   = "required"
+	FieldInvalid    = "invalid"
+	FieldTooLong    = "too_long"
+	FieldOutOfRange = "out_of_range"
+	FieldConflict   = "conflict"
+)
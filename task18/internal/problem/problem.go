@@ -0,0 +1,54 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType — тип ответа с ошибкой по RFC 7807.
+const ContentType = "application/problem+json"
+
+// Problem — ответ с ошибкой по RFC 7807. Type всегда about:blank, Title —
+// текст статуса; машиночитаемый код ошибки — в Code, ошибки отдельных полей
+// запроса — в Errors.
+type Problem struct {
+	Type   string       `json:"type" example:"about:blank"`
+	Title  string       `json:"title" example:"Unprocessable Entity"`
+	Status int          `json:"status" example:"422"`
+	Detail string       `json:"detail,omitempty" example:"title too long (max 255 characters)"`
+	Code   string       `json:"code" example:"validation_failed"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError — ошибка одного поля или параметра запроса.
+type FieldError struct {
+	Field  string `json:"field" example:"title"`
+	Code   string `json:"code" example:"title_too_long"`
+	Detail string `json:"detail" example:"title too long (max 255 characters)"`
+}
+
+func New(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Write отправляет body со статусом status как application/problem+json.
+// body — Problem или структура, встраивающая его.
+func Write(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(body)
+}
+
+// Send отправляет p со статусом p.Status.
+func Send(w http.ResponseWriter, p Problem) {
+	Write(w, p.Status, p)
+}
@@ -0,0 +1,403 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum — элемент BYDAY: день недели и необязательный порядковый номер
+// внутри месяца (1 — первый, -1 — последний, 0 — любой).
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule — подмножество RRULE из RFC 5545: FREQ, INTERVAL, BYDAY, COUNT и UNTIL.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+
+	untilIsDate bool
+}
+
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, found := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !found || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			rule.Freq, err = parseFrequency(val)
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(key, val)
+		case "COUNT":
+			rule.Count, err = parsePositive(key, val)
+		case "UNTIL":
+			rule.Until, rule.untilIsDate, err = parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "WKST":
+			if val != "MO" {
+				err = fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRule)
+			}
+		default:
+			err = fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+
+	for _, day := range r.ByDay {
+		if day.N == 0 {
+			continue
+		}
+		if r.Freq != Monthly {
+			return fmt.Errorf("%w: ordinal BYDAY is only supported with FREQ=MONTHLY", ErrInvalidRule)
+		}
+		if day.N < -5 || day.N > 5 {
+			return fmt.Errorf("%w: BYDAY ordinal out of range", ErrInvalidRule)
+		}
+	}
+
+	if r.Freq == Yearly && len(r.ByDay) > 0 {
+		return fmt.Errorf("%w: BYDAY is not supported with FREQ=YEARLY", ErrInvalidRule)
+	}
+
+	return nil
+}
+
+// String возвращает правило в каноническом виде RRULE без префикса.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			code := weekdayCode(day.Weekday)
+			if day.N != 0 {
+				code = strconv.Itoa(day.N) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if !r.Until.IsZero() {
+		if r.untilIsDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Between возвращает вхождения серии, начавшейся в start, попадающие в [from, to).
+// Время суток и часовой пояс вхождений берутся из start.
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	var result []time.Time
+
+	until, hasUntil := r.until(start)
+	count := 0
+
+	first := 0
+	if r.Count == 0 {
+		// Без COUNT номер вхождения не важен, поэтому периоды до from можно пропустить.
+		first = r.periodsBefore(start, from)
+	}
+
+	for n := first; ; n++ {
+		periodStart := r.periodStart(start, n)
+		if !periodStart.Before(to) || (hasUntil && periodStart.After(until)) {
+			return result
+		}
+
+		for _, occurrence := range r.candidates(start, periodStart) {
+			if occurrence.Before(start) {
+				continue
+			}
+			if hasUntil && occurrence.After(until) {
+				return result
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return result
+			}
+
+			if !occurrence.Before(from) && occurrence.Before(to) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+}
+
+func (r *Rule) until(start time.Time) (time.Time, bool) {
+	if r.Until.IsZero() {
+		return time.Time{}, false
+	}
+
+	if r.untilIsDate {
+		year, month, day := r.Until.Date()
+		return time.Date(year, month, day, 23, 59, 59, int(time.Second-1), start.Location()), true
+	}
+
+	return r.Until, true
+}
+
+func (r *Rule) periodStart(start time.Time, n int) time.Time {
+	year, month, day := start.Date()
+	loc := start.Location()
+	step := n * r.Interval
+
+	switch r.Freq {
+	case Daily:
+		return time.Date(year, month, day+step, 0, 0, 0, 0, loc)
+	case Weekly:
+		offset := (int(start.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset+7*step, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(year, month+time.Month(step), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year+step, time.January, 1, 0, 0, 0, 0, loc)
+	}
+}
+
+func (r *Rule) periodsBefore(start, from time.Time) int {
+	if !from.After(start) {
+		return 0
+	}
+
+	from = from.In(start.Location())
+
+	var elapsed int
+	switch r.Freq {
+	case Daily:
+		elapsed = daysBetween(start, from)
+	case Weekly:
+		elapsed = (daysBetween(start, from) + (int(start.Weekday())+6)%7) / 7
+	case Monthly:
+		elapsed = (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+	default:
+		elapsed = from.Year() - start.Year()
+	}
+
+	if periods := elapsed/r.Interval - 1; periods > 0 {
+		return periods
+	}
+	return 0
+}
+
+func (r *Rule) candidates(start, periodStart time.Time) []time.Time {
+	year, month, day := periodStart.Date()
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		days = append(days, periodStart)
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			offset := (int(start.Weekday()) + 6) % 7
+			days = append(days, time.Date(year, month, day+offset, 0, 0, 0, 0, periodStart.Location()))
+		} else {
+			for i := 0; i < 7; i++ {
+				days = append(days, time.Date(year, month, day+i, 0, 0, 0, 0, periodStart.Location()))
+			}
+		}
+	case Monthly:
+		if len(r.ByDay) == 0 {
+			if start.Day() <= daysIn(year, month) {
+				days = append(days, time.Date(year, month, start.Day(), 0, 0, 0, 0, periodStart.Location()))
+			}
+		} else {
+			for d := 1; d <= daysIn(year, month); d++ {
+				days = append(days, time.Date(year, month, d, 0, 0, 0, 0, periodStart.Location()))
+			}
+		}
+	case Yearly:
+		if start.Day() <= daysIn(year, start.Month()) {
+			days = append(days, time.Date(year, start.Month(), start.Day(), 0, 0, 0, 0, periodStart.Location()))
+		}
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		if len(r.ByDay) > 0 && !r.matchesByDay(d) {
+			continue
+		}
+		result = append(result, atClock(d, start))
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+func (r *Rule) matchesByDay(day time.Time) bool {
+	for _, byDay := range r.ByDay {
+		if day.Weekday() != byDay.Weekday {
+			continue
+		}
+
+		switch {
+		case byDay.N == 0:
+			return true
+		case byDay.N > 0 && (day.Day()-1)/7+1 == byDay.N:
+			return true
+		case byDay.N < 0 && (daysIn(day.Year(), day.Month())-day.Day())/7+1 == -byDay.N:
+			return true
+		}
+	}
+	return false
+}
+
+func parseFrequency(value string) (Frequency, error) {
+	switch freq := Frequency(value); freq {
+	case Daily, Weekly, Monthly, Yearly:
+		return freq, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRule, value)
+	}
+}
+
+func parsePositive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidRule, key)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t, true, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ", ErrInvalidRule)
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, item)
+		}
+
+		weekday, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, item)
+		}
+
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			if n, err = strconv.Atoi(prefix); err != nil || n == 0 {
+				return nil, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, item)
+			}
+		}
+
+		days = append(days, WeekdayNum{Weekday: weekday, N: n})
+	}
+	return days, nil
+}
+
+func weekdayCode(weekday time.Weekday) string {
+	for code, day := range weekdayCodes {
+		if day == weekday {
+			return code
+		}
+	}
+	return ""
+}
+
+func atClock(day, clock time.Time) time.Time {
+	year, month, d := day.Date()
+	return time.Date(year, month, d, clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), clock.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysBetween(from, to time.Time) int {
+	fy, fm, fd := from.Date()
+	ty, tm, td := to.Date()
+	start := time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)
+	end := time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
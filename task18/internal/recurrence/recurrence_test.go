@@ -0,0 +1,164 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func days(times []time.Time) []string {
+	result := make([]string, 0, len(times))
+	for _, t := range times {
+		result = append(result, t.Format("2006-01-02"))
+	}
+	return result
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		canonical string
+		shouldErr bool
+	}{
+		{"daily", "FREQ=DAILY", "FREQ=DAILY", false},
+		{"with prefix and lowercase", "RRULE:freq=weekly;byday=mo,we", "FREQ=WEEKLY;BYDAY=MO,WE", false},
+		{"interval and count", "FREQ=WEEKLY;INTERVAL=2;COUNT=5", "FREQ=WEEKLY;INTERVAL=2;COUNT=5", false},
+		{"until date", "FREQ=DAILY;UNTIL=20250930", "FREQ=DAILY;UNTIL=20250930", false},
+		{"until datetime", "FREQ=DAILY;UNTIL=20250930T120000Z", "FREQ=DAILY;UNTIL=20250930T120000Z", false},
+		{"monthly ordinal", "FREQ=MONTHLY;BYDAY=-1FR", "FREQ=MONTHLY;BYDAY=-1FR", false},
+		{"empty", "", "", true},
+		{"missing freq", "INTERVAL=2", "", true},
+		{"unknown freq", "FREQ=HOURLY", "", true},
+		{"count and until", "FREQ=DAILY;COUNT=3;UNTIL=20250930", "", true},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0", "", true},
+		{"bad weekday", "FREQ=WEEKLY;BYDAY=XX", "", true},
+		{"ordinal with weekly", "FREQ=WEEKLY;BYDAY=1MO", "", true},
+		{"byday with yearly", "FREQ=YEARLY;BYDAY=MO", "", true},
+		{"unsupported part", "FREQ=DAILY;BYHOUR=10", "", true},
+		{"duplicate part", "FREQ=DAILY;FREQ=WEEKLY", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.shouldErr {
+				assert.ErrorIs(t, err, ErrInvalidRule)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.canonical, rule.String())
+		})
+	}
+}
+
+func TestRule_Between(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		from, to time.Time
+		expected []string
+	}{
+		{
+			name:     "daily with interval",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			start:    date(2025, 9, 1),
+			from:     date(2025, 9, 1),
+			to:       date(2025, 9, 8),
+			expected: []string{"2025-09-01", "2025-09-03", "2025-09-05", "2025-09-07"},
+		},
+		{
+			name:     "weekly standup on weekdays",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			start:    date(2025, 9, 3),
+			from:     date(2025, 9, 1),
+			to:       date(2025, 9, 15),
+			expected: []string{"2025-09-03", "2025-09-05", "2025-09-08", "2025-09-10", "2025-09-12"},
+		},
+		{
+			name:     "biweekly without byday uses start weekday",
+			rule:     "FREQ=WEEKLY;INTERVAL=2",
+			start:    date(2025, 9, 2),
+			from:     date(2025, 9, 1),
+			to:       date(2025, 10, 1),
+			expected: []string{"2025-09-02", "2025-09-16", "2025-09-30"},
+		},
+		{
+			name:     "monthly skips short months",
+			rule:     "FREQ=MONTHLY",
+			start:    date(2025, 1, 31),
+			from:     date(2025, 1, 1),
+			to:       date(2025, 6, 1),
+			expected: []string{"2025-01-31", "2025-03-31", "2025-05-31"},
+		},
+		{
+			name:     "monthly last friday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			start:    date(2025, 9, 1),
+			from:     date(2025, 9, 1),
+			to:       date(2025, 12, 1),
+			expected: []string{"2025-09-26", "2025-10-31", "2025-11-28"},
+		},
+		{
+			name:     "yearly on leap day",
+			rule:     "FREQ=YEARLY",
+			start:    date(2024, 2, 29),
+			from:     date(2024, 1, 1),
+			to:       date(2029, 1, 1),
+			expected: []string{"2024-02-29", "2028-02-29"},
+		},
+		{
+			name:     "count limits occurrences before window",
+			rule:     "FREQ=DAILY;COUNT=5",
+			start:    date(2025, 9, 1),
+			from:     date(2025, 9, 4),
+			to:       date(2025, 9, 30),
+			expected: []string{"2025-09-04", "2025-09-05"},
+		},
+		{
+			name:     "until date is inclusive",
+			rule:     "FREQ=DAILY;UNTIL=20250903",
+			start:    date(2025, 9, 1),
+			from:     date(2025, 9, 1),
+			to:       date(2025, 9, 30),
+			expected: []string{"2025-09-01", "2025-09-02", "2025-09-03"},
+		},
+		{
+			name:     "window far after start",
+			rule:     "FREQ=WEEKLY;BYDAY=TU",
+			start:    date(2020, 1, 7),
+			from:     date(2025, 9, 1),
+			to:       date(2025, 9, 8),
+			expected: []string{"2025-09-02"},
+		},
+		{
+			name:     "window before start",
+			rule:     "FREQ=DAILY",
+			start:    date(2025, 9, 1),
+			from:     date(2025, 8, 1),
+			to:       date(2025, 9, 1),
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			require.NoError(t, err)
+
+			occurrences := rule.Between(tt.start, tt.from, tt.to)
+			assert.Equal(t, tt.expected, days(occurrences))
+			for _, occurrence := range occurrences {
+				assert.Equal(t, 9, occurrence.Hour())
+				assert.Equal(t, 30, occurrence.Minute())
+			}
+		})
+	}
+}
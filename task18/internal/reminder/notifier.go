@@ -0,0 +1,112 @@
+package reminder
+
+import (
+	"bytes"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Notification — напоминание, передаваемое получателю.
+type Notification struct {
+	EventID       int       `json:"event_id"`
+	UserID        int       `json:"user_id"`
+	Title         string    `json:"title"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	AllDay        bool      `json:"all_day"`
+	MinutesBefore int       `json:"minutes_before"`
+	FireAt        time.Time `json:"fire_at"`
+}
+
+// Notifier доставляет напоминания. Ошибка означает, что напоминание не
+// доставлено и планировщик попробует отправить его снова.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+func newNotification(due repository.DueReminder) Notification {
+	return Notification{
+		EventID:       due.EventID,
+		UserID:        due.Event.UserID,
+		Title:         due.Event.Title,
+		Start:         due.Event.Date,
+		End:           due.Event.EndTime(),
+		AllDay:        due.Event.AllDay,
+		MinutesBefore: due.MinutesBefore,
+		FireAt:        due.FireAt,
+	}
+}
+
+// LogNotifier пишет напоминания в лог.
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: logger}
+}
+
+func (ln *LogNotifier) Notify(_ context.Context, notification Notification) error {
+	ln.log.Info("Reminder",
+		"event_id", notification.EventID,
+		"user_id", notification.UserID,
+		"title", notification.Title,
+		"start", notification.Start.Format(time.RFC3339),
+		"minutes_before", notification.MinutesBefore,
+	)
+	return nil
+}
+
+// WebhookNotifier отправляет напоминания POST-запросом с JSON-телом Notification.
+// Любой ответ, кроме 2xx, считается ошибкой доставки.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+
+	return &WebhookNotifier{
+		url:    url,
+		client: client,
+	}
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	notification := Notification{
+		EventID:       7,
+		UserID:        1,
+		Title:         "Call",
+		Start:         time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC),
+		MinutesBefore: 15,
+		FireAt:        time.Date(2025, 9, 1, 9, 45, 0, 0, time.UTC),
+	}
+
+	t.Run("posts notification as JSON", func(t *testing.T) {
+		received := make(chan Notification, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Contains(t, r.Header.Get("Content-Type"), "application/json")
+
+			var got Notification
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			received <- got
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewWebhookNotifier(server.URL, server.Client()).Notify(context.Background(), notification))
+
+		got := <-received
+		assert.Equal(t, notification.EventID, got.EventID)
+		assert.Equal(t, notification.Title, got.Title)
+		assert.True(t, notification.Start.Equal(got.Start))
+		assert.Equal(t, notification.MinutesBefore, got.MinutesBefore)
+	})
+
+	t.Run("non-2xx response is an error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL, nil).Notify(context.Background(), notification)
+		assert.ErrorContains(t, err, "500")
+	})
+
+	t.Run("unreachable receiver is an error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		assert.Error(t, NewWebhookNotifier(server.URL, nil).Notify(context.Background(), notification))
+	})
+}
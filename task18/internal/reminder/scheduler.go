@@ -0,0 +1,155 @@
+package reminder
+
+import (
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const notifyTimeout = 15 * time.Second
+
+// Store — источник наступивших напоминаний и журнал отправленных.
+type Store interface {
+	DueReminders(ctx context.Context, from, to time.Time) ([]repository.DueReminder, error)
+	ClaimReminder(ctx context.Context, key repository.ReminderKey) (bool, error)
+	ReleaseReminder(ctx context.Context, key repository.ReminderKey) error
+}
+
+// Scheduler раз в interval отправляет напоминания, сработавшие за последние
+// lookback. Перед отправкой напоминание отмечается в Store, поэтому при
+// постоянном хранилище перезапуск не приводит к повторной отправке, а
+// пропущенные за время простоя напоминания уходят с опозданием.
+type Scheduler struct {
+	store    Store
+	notifier Notifier
+	interval time.Duration
+	lookback time.Duration
+	now      func() time.Time
+	tracer   *tracing.Tracer
+	log      *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewScheduler создаёт планировщик; tracer может быть nil, тогда проходы не трассируются.
+func NewScheduler(store Store, notifier Notifier, interval, lookback time.Duration, tracer *tracing.Tracer, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		notifier: notifier,
+		interval: interval,
+		lookback: lookback,
+		now:      time.Now,
+		tracer:   tracer,
+		log:      logger,
+	}
+}
+
+// Start запускает планировщик в отдельной горутине.
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(tracing.WithTracer(context.Background(), s.tracer))
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+
+	s.log.Info("Reminder scheduler started",
+		"interval", s.interval.String(),
+		"lookback", s.lookback.String(),
+	)
+}
+
+// Stop останавливает планировщик и ждёт завершения текущего прохода или отмены ctx.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		s.log.Info("Reminder scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "reminder.tick")
+	defer span.End()
+
+	now := s.now()
+
+	due, err := s.store.DueReminders(ctx, now.Add(-s.lookback), now)
+	if err != nil {
+		span.RecordError(err)
+		s.log.Error("failed to load due reminders", "error", err)
+		return
+	}
+	span.SetAttr("due", len(due))
+
+	for _, reminder := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, reminder)
+	}
+}
+
+func (s *Scheduler) deliver(ctx context.Context, reminder repository.DueReminder) {
+	ctx, span := tracing.Start(ctx, "reminder.deliver")
+	defer span.End()
+	span.SetAttr("event_id", reminder.EventID)
+
+	claimed, err := s.store.ClaimReminder(ctx, reminder.ReminderKey)
+	if err != nil {
+		s.log.Error("failed to claim reminder", "event_id", reminder.EventID, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	if err := s.notifier.Notify(notifyCtx, newNotification(reminder)); err != nil {
+		span.RecordError(err)
+		s.log.Warn("failed to deliver reminder, will retry",
+			"event_id", reminder.EventID,
+			"occurrence", reminder.Occurrence.Format(time.RFC3339),
+			"error", err,
+		)
+		if err := s.store.ReleaseReminder(ctx, reminder.ReminderKey); err != nil {
+			s.log.Error("failed to release reminder", "event_id", reminder.EventID, "error", err)
+		}
+		return
+	}
+
+	s.log.Debug("Reminder delivered",
+		"event_id", reminder.EventID,
+		"user_id", reminder.Event.UserID,
+		"occurrence", reminder.Occurrence.Format(time.RFC3339),
+		"minutes_before", reminder.MinutesBefore,
+	)
+}
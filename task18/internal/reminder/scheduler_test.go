@@ -0,0 +1,173 @@
+package reminder
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var eventStart = time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []Notification
+	failures      int
+	sent          chan struct{}
+}
+
+func newRecordingNotifier() *recordingNotifier {
+	return &recordingNotifier{sent: make(chan struct{}, 10)}
+}
+
+func (rn *recordingNotifier) Notify(_ context.Context, notification Notification) error {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	if rn.failures > 0 {
+		rn.failures--
+		return errors.New("receiver is down")
+	}
+
+	rn.notifications = append(rn.notifications, notification)
+	rn.sent <- struct{}{}
+	return nil
+}
+
+func (rn *recordingNotifier) count() int {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	return len(rn.notifications)
+}
+
+func newTestScheduler(storage repository.Storage, notifier Notifier, now time.Time) *Scheduler {
+	service := calendar.NewServiceCalendar(storage, slog.Default())
+	scheduler := NewScheduler(service, notifier, time.Hour, time.Hour, nil, slog.Default())
+	scheduler.now = func() time.Time { return now }
+	return scheduler
+}
+
+func createEventWithReminder(t *testing.T, storage repository.Storage) repository.Event {
+	service := calendar.NewServiceCalendar(storage, slog.Default())
+	e, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: eventStart, Title: "Call", Reminders: []int{15}})
+	require.NoError(t, err)
+	return e
+}
+
+func TestScheduler_DeliversOnce(t *testing.T) {
+	storage := repository.NewEventRepository(slog.Default())
+	created := createEventWithReminder(t, storage)
+	notifier := newRecordingNotifier()
+
+	scheduler := newTestScheduler(storage, notifier, eventStart.Add(-10*time.Minute))
+	scheduler.tick(context.Background())
+	scheduler.tick(context.Background())
+
+	require.Equal(t, 1, notifier.count())
+	notification := notifier.notifications[0]
+	assert.Equal(t, created.ID, notification.EventID)
+	assert.Equal(t, "Call", notification.Title)
+	assert.Equal(t, 15, notification.MinutesBefore)
+	assert.Equal(t, eventStart.Add(-15*time.Minute), notification.FireAt)
+}
+
+func TestScheduler_NotYetDue(t *testing.T) {
+	storage := repository.NewEventRepository(slog.Default())
+	createEventWithReminder(t, storage)
+	notifier := newRecordingNotifier()
+
+	newTestScheduler(storage, notifier, eventStart.Add(-20*time.Minute)).tick(context.Background())
+	assert.Zero(t, notifier.count())
+}
+
+func TestScheduler_RetriesFailedDelivery(t *testing.T) {
+	storage := repository.NewEventRepository(slog.Default())
+	createEventWithReminder(t, storage)
+	notifier := newRecordingNotifier()
+	notifier.failures = 1
+
+	scheduler := newTestScheduler(storage, notifier, eventStart.Add(-10*time.Minute))
+	scheduler.tick(context.Background())
+	assert.Zero(t, notifier.count())
+
+	scheduler.tick(context.Background())
+	assert.Equal(t, 1, notifier.count())
+}
+
+func TestScheduler_NoDuplicateAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	now := eventStart.Add(-10 * time.Minute)
+	notifier := newRecordingNotifier()
+
+	storage, err := repository.NewSQLiteRepository(path, slog.Default())
+	require.NoError(t, err)
+	createEventWithReminder(t, storage)
+	newTestScheduler(storage, notifier, now).tick(context.Background())
+	require.NoError(t, storage.Close())
+	require.Equal(t, 1, notifier.count())
+
+	restarted, err := repository.NewSQLiteRepository(path, slog.Default())
+	require.NoError(t, err)
+	defer restarted.Close()
+	newTestScheduler(restarted, notifier, now.Add(time.Minute)).tick(context.Background())
+
+	assert.Equal(t, 1, notifier.count())
+}
+
+func TestScheduler_NoDuplicateAfterRestore(t *testing.T) {
+	sqlite, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "events.db"), slog.Default())
+	require.NoError(t, err)
+	defer sqlite.Close()
+
+	storages := map[string]repository.Storage{
+		"memory": repository.NewEventRepository(slog.Default()),
+		"sqlite": sqlite,
+	}
+	for name, storage := range storages {
+		t.Run(name, func(t *testing.T) {
+			created := createEventWithReminder(t, storage)
+			notifier := newRecordingNotifier()
+			scheduler := newTestScheduler(storage, notifier, eventStart.Add(-10*time.Minute))
+
+			scheduler.tick(context.Background())
+			require.Equal(t, 1, notifier.count())
+
+			require.NoError(t, storage.DeleteEvent(t.Context(), created.ID, created.UserID, 0))
+			trashed, err := storage.GetTrashedEvent(t.Context(), created.ID, created.UserID)
+			require.NoError(t, err)
+			_, err = storage.RestoreEvent(t.Context(), trashed.Event)
+			require.NoError(t, err)
+
+			scheduler.tick(context.Background())
+			assert.Equal(t, 1, notifier.count(), "restored event must not be reminded again")
+		})
+	}
+}
+
+func TestScheduler_StartStop(t *testing.T) {
+	storage := repository.NewEventRepository(slog.Default())
+	createEventWithReminder(t, storage)
+	notifier := newRecordingNotifier()
+
+	scheduler := newTestScheduler(storage, notifier, eventStart.Add(-10*time.Minute))
+	scheduler.Start()
+
+	select {
+	case <-notifier.sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reminder was not delivered")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, scheduler.Stop(ctx))
+	assert.NoError(t, NewScheduler(nil, nil, time.Second, time.Second, nil, slog.Default()).Stop(ctx))
+}
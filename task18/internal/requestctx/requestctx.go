@@ -0,0 +1,34 @@
+package requestctx
+
+import "context"
+
+// Ключи контекста запроса. Их заполняют middleware, а читают и обработчики,
+// и хранилище, которое записывает в историю, кто и каким запросом изменил событие.
+type ctxKey int
+
+const (
+	userIDKey ctxKey = iota
+	requestIDKey
+)
+
+// WithUserID возвращает контекст с ID пользователя, прошедшего аутентификацию.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID возвращает ID пользователя, прошедшего аутентификацию.
+func UserID(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(userIDKey).(int)
+	return userID, ok
+}
+
+// WithRequestID возвращает контекст с ID запроса.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID возвращает ID запроса или пустую строку.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
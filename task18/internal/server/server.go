@@ -3,13 +3,18 @@ package server
 import (
 	"calendar/internal/config"
 	"calendar/internal/handlers"
+	"calendar/internal/metrics"
 	mymiddleware "calendar/internal/middleware"
+	"calendar/internal/tracing"
 	"calendar/logger"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -18,35 +23,121 @@ import (
 )
 
 type Server struct {
-	httpServer *http.Server
-	handlers   *handlers.Handlers
-	config     *config.Config
-	log        *slog.Logger
+	httpServer    *http.Server
+	rateLimiter   *mymiddleware.RateLimiter
+	ipRateLimiter *mymiddleware.RateLimiter
+	idempotency   *mymiddleware.IdempotencyCache
+	handlers      *handlers.Handlers
+	config        *config.Config
+	loadConfig    func() (*config.Config, error)
+	log           *slog.Logger
+	shutdownHooks []func(ctx context.Context) error
+	listener      net.Listener
 }
 
-func NewServer(handlers *handlers.Handlers, cfg *config.Config, logger *slog.Logger) *Server {
+func NewServer(handlers *handlers.Handlers, cfg *config.Config, registry *metrics.Registry, tracer *tracing.Tracer, logger *slog.Logger) *Server {
 	router := chi.NewRouter()
 
 	router.Use(mymiddleware.RequestIDMiddleware)
+	router.Use(mymiddleware.Tracing(tracer))
+	router.Use(mymiddleware.Metrics(registry))
 	router.Use(mymiddleware.RequestLogger)
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(cfg.WriteTimeOut))
 	router.Use(mymiddleware.CharsetMiddleware)
 
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 
-	router.Post("/create_event", handlers.CreateEvent)
-	router.Post("/update_event", handlers.UpdateEvent)
-	router.Post("/delete_event", handlers.DeleteEvent)
-	router.Get("/events_for_day", handlers.EventsForDay)
-	router.Get("/events_for_week", handlers.EventsForWeek)
-	router.Get("/events_for_month", handlers.EventsForMonth)
+	rateLimiter := mymiddleware.NewRateLimiter(
+		mymiddleware.Limit{Rate: cfg.RateLimitReadRPS, Burst: cfg.RateLimitReadBurst},
+		mymiddleware.Limit{Rate: cfg.RateLimitWriteRPS, Burst: cfg.RateLimitWriteBurst},
+		cfg.RateLimitBy == config.RateLimitByIP, cfg.RateLimitIdleTTL,
+	)
+	// Лимит по IP стоит до аутентификации: запросы с неверным токеном тоже
+	// расходуют корзину, и перебор токенов упирается в 429, а не в 401.
+	ipLimit := mymiddleware.Limit{Rate: cfg.RateLimitIPRPS, Burst: cfg.RateLimitIPBurst}
+	ipRateLimiter := mymiddleware.NewRateLimiter(ipLimit, ipLimit, true, cfg.RateLimitIdleTTL)
+	idempotency := mymiddleware.NewIdempotencyCache(cfg.IdempotencyTTL)
+
+	router.Group(func(r chi.Router) {
+		r.Use(ipRateLimiter.Middleware)
+		r.Use(mymiddleware.Auth([]byte(cfg.JWTSecret)))
+		r.Use(rateLimiter.Middleware)
+
+		// Поток изменений открыт дольше WriteTimeOut, поэтому таймаут на него не распространяется.
+		r.Get("/events/stream", handlers.StreamChanges)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Timeout(cfg.WriteTimeOut))
+			r.Use(idempotency.Middleware)
+
+			r.Route("/users/{id}/events", func(r chi.Router) {
+				r.Get("/", handlers.ListEvents)
+				r.Post("/", handlers.AddEvent)
+				r.Get("/search", handlers.SearchEvents)
+				r.Post("/batch", handlers.BatchEvents)
+				r.Get("/{eventID}", handlers.GetEvent)
+				r.Put("/{eventID}", handlers.ReplaceEvent)
+				r.Patch("/{eventID}", handlers.PatchEvent)
+				r.Delete("/{eventID}", handlers.RemoveEvent)
+				r.Get("/{eventID}/history", handlers.EventHistory)
+				r.Post("/{eventID}/restore", handlers.RestoreEvent)
+				r.Get("/{eventID}/attendees", handlers.ListAttendees)
+				r.Put("/{eventID}/attendees/{userID}", handlers.InviteAttendee)
+				r.Delete("/{eventID}/attendees/{userID}", handlers.RemoveAttendee)
+				r.Put("/{eventID}/rsvp", handlers.RespondToInvitation)
+			})
+
+			r.Get("/users/{id}/history", handlers.UserHistory)
+			r.Get("/users/{id}/trash", handlers.ListTrash)
+			r.Post("/users/{id}/trash/{eventID}/restore", handlers.RestoreTrashedEvent)
+			r.Get("/users/{id}/invitations", handlers.ListInvitations)
+
+			r.Route("/users/{id}/calendars", func(r chi.Router) {
+				r.Get("/", handlers.ListCalendars)
+				r.Post("/", handlers.AddCalendar)
+				r.Get("/{calendarID}", handlers.GetCalendar)
+				r.Put("/{calendarID}", handlers.RenameCalendar)
+				r.Delete("/{calendarID}", handlers.RemoveCalendar)
+				r.Get("/{calendarID}/shares", handlers.ListCalendarShares)
+				r.Put("/{calendarID}/shares/{userID}", handlers.ShareCalendar)
+				r.Delete("/{calendarID}/shares/{userID}", handlers.UnshareCalendar)
+			})
+
+			r.Get("/freebusy", handlers.FreeBusy)
+
+			r.Route("/users/{id}/webhooks", func(r chi.Router) {
+				r.Get("/", handlers.ListWebhooks)
+				r.Post("/", handlers.AddWebhook)
+				r.Get("/dead_letters", handlers.DeadLetters)
+				r.Post("/dead_letters/{deliveryID}/retry", handlers.RetryDeadLetter)
+				r.Get("/{webhookID}", handlers.GetWebhook)
+				r.Delete("/{webhookID}", handlers.RemoveWebhook)
+				r.Get("/{webhookID}/deliveries", handlers.WebhookDeliveries)
+			})
+
+			// Маршруты в стиле RPC оставлены на время перехода клиентов на REST.
+			if cfg.LegacyRoutes {
+				r.Post("/create_event", handlers.CreateEvent)
+				r.Post("/update_event", handlers.UpdateEvent)
+				r.Post("/delete_event", handlers.DeleteEvent)
+				r.Get("/events_for_day", handlers.EventsForDay)
+				r.Get("/events_for_week", handlers.EventsForWeek)
+				r.Get("/events_for_month", handlers.EventsForMonth)
+			}
+
+			r.Get("/export.ics", handlers.ExportICal)
+			r.Post("/import", handlers.ImportICal)
+		})
+	})
 
 	router.Get("/health", handlers.HealthCheck)
+	router.Get("/livez", handlers.Livez)
+	router.Get("/readyz", handlers.Readyz)
+	router.Method(http.MethodGet, "/metrics", registry.Handler())
 	router.NotFound(handlers.NotFound)
 
-	return &Server{
+	s := &Server{
 		httpServer: &http.Server{
 			Addr:         ":" + cfg.Port,
 			Handler:      router,
@@ -54,36 +145,161 @@ func NewServer(handlers *handlers.Handlers, cfg *config.Config, logger *slog.Log
 			WriteTimeout: cfg.WriteTimeOut,
 			IdleTimeout:  cfg.IdleTimeOut,
 		},
-		handlers: handlers,
-		config:   cfg,
-		log:      logger,
+		rateLimiter:   rateLimiter,
+		ipRateLimiter: ipRateLimiter,
+		idempotency:   idempotency,
+		handlers:      handlers,
+		config:        cfg,
+		log:           logger,
+	}
+	// Shutdown ждёт завершения запросов, поэтому потоки изменений закрываются в его начале.
+	s.httpServer.RegisterOnShutdown(handlers.CloseStreams)
+	s.OnShutdown(rateLimiter.Stop)
+	s.OnShutdown(ipRateLimiter.Stop)
+	s.OnShutdown(idempotency.Stop)
+
+	return s
+}
+
+// OnShutdown регистрирует функцию, которая останавливает фоновую работу
+// после остановки HTTP-сервера и до закрытия логгера.
+func (s *Server) OnShutdown(hook func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+// OnReload задаёт загрузку конфигурации, которую сервер перечитывает по SIGHUP.
+func (s *Server) OnReload(load func() (*config.Config, error)) {
+	s.loadConfig = load
+}
+
+// Listen занимает порт сервера. Start вызывает его сам; вызов заранее
+// сообщает об ошибке и адресе до начала обслуживания запросов.
+func (s *Server) Listen() error {
+	if s.listener != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.httpServer.Addr, err)
+	}
+	s.listener = listener
+	return nil
+}
+
+// Addr возвращает адрес, на котором слушает сервер, или nil до Listen.
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Start обслуживает запросы, пока не отменён ctx, и по SIGHUP перечитывает
+// конфигурацию. При остановке /readyz отвечает not_ready, а через
+// ShutdownDrainDelay сервер закрывает порт и ждёт завершения запросов
+// и функций OnShutdown не дольше ShutdownTimeout.
+// Ошибка запуска или работы сервера тоже останавливает фоновую работу
+// и возвращается вместе с ошибками остановки; если порт занять не удалось,
+// ShutdownDrainDelay не выжидается.
+func (s *Server) Start(ctx context.Context) error {
+	err := s.Listen()
+	listening := err == nil
+	if listening {
+		err = s.serve(ctx)
+	}
+
+	s.log.Info("Shutting down server gracefully")
+	if shutdownErr := s.shutdown(listening); shutdownErr != nil {
+		s.log.Error("Server forced to shutdown", "error", shutdownErr)
+		return errors.Join(err, shutdownErr)
+	}
+	if err != nil {
+		return err
 	}
+
+	s.log.Info("Server terminated without incident")
+	return nil
 }
 
-func (s *Server) Start() error {
-	notify := make(chan os.Signal, 1)
-	signal.Notify(notify, syscall.SIGINT, syscall.SIGTERM)
+// serve обслуживает запросы, пока не отменён ctx или сервер не вернул ошибку.
+func (s *Server) serve(ctx context.Context) error {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
 
+	s.rateLimiter.Start()
+	s.ipRateLimiter.Start()
+	s.idempotency.Start()
+
+	serveErr := make(chan error, 1)
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.log.Error("Server failed to start", "error", err)
-			os.Exit(1)
-		}
+		serveErr <- s.httpServer.Serve(s.listener)
 	}()
 
-	<-notify
-	s.log.Info("Shutting down server gracefully")
+	for {
+		select {
+		case <-reload:
+			s.reload()
+		case <-ctx.Done():
+			return nil
+		case err := <-serveErr:
+			return fmt.Errorf("serve: %w", err)
+		}
+	}
+}
+
+// shutdown переводит /readyz в not_ready и через ShutdownDrainDelay закрывает
+// порт, дожидается завершения запросов и вызывает функции OnShutdown. Если порт
+// не был занят (listening == false), запросов не было и ждать нечего.
+func (s *Server) shutdown(listening bool) error {
+	s.handlers.Drain()
+	if delay := s.config.ShutdownDrainDelay; listening && delay > 0 {
+		s.log.Info("Waiting for load balancers to stop sending requests", "delay", delay)
+		time.Sleep(delay)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
 	defer cancel()
 
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		s.log.Error("Server forced to shutdown", "error", err)
-		return err
+	err := s.httpServer.Shutdown(ctx)
+
+	for _, hook := range s.shutdownHooks {
+		if hookErr := hook(ctx); hookErr != nil {
+			s.log.Error("Shutdown hook failed", "error", hookErr)
+		}
 	}
 
-	logger.Close()
+	return err
+}
 
-	s.log.Info("Server terminated without incident")
-	return nil
+// reload перечитывает конфигурацию и применяет то, что можно менять на ходу:
+// уровень логирования и лимиты запросов. При ошибке настройки не меняются.
+func (s *Server) reload() {
+	if s.loadConfig == nil {
+		s.log.Warn("Configuration reload is not supported")
+		return
+	}
+
+	next, err := s.loadConfig()
+	if err != nil {
+		s.log.Error("Configuration reload failed, keeping current settings", "error", err)
+		return
+	}
+
+	cfg, changed, ignored := s.config.Reload(next)
+	if len(ignored) > 0 {
+		s.log.Warn("Changed settings take effect after restart", "keys", ignored)
+	}
+
+	logger.SetLevel(cfg.Level)
+	s.rateLimiter.SetLimits(
+		mymiddleware.Limit{Rate: cfg.RateLimitReadRPS, Burst: cfg.RateLimitReadBurst},
+		mymiddleware.Limit{Rate: cfg.RateLimitWriteRPS, Burst: cfg.RateLimitWriteBurst},
+	)
+	ipLimit := mymiddleware.Limit{Rate: cfg.RateLimitIPRPS, Burst: cfg.RateLimitIPBurst}
+	s.ipRateLimiter.SetLimits(ipLimit, ipLimit)
+	s.config = cfg
+
+	s.log.Info("Configuration reloaded", "changed", changed)
 }
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/changes"
+	"calendar/internal/config"
+	"calendar/internal/event/repository"
+	"calendar/internal/handlers"
+	"calendar/internal/metrics"
+	"calendar/internal/middleware"
+	"calendar/internal/tracing"
+	"calendar/internal/webhook"
+	"calendar/logger"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+// newTestServer собирает сервер так же, как cmd, на SQLite во временном каталоге.
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	logger.InitLogger("test", false, "")
+
+	storage, err := repository.NewSQLiteRepository(filepath.Join(t.TempDir(), "calendar.db"), logger.AppLogger)
+	require.NoError(t, err)
+	t.Cleanup(func() { storage.Close() })
+
+	registry := metrics.NewRegistry()
+	tracer := tracing.NewTracer(nil)
+	bus := changes.NewBus(cfg.StreamReplaySize)
+	dispatcher := webhook.NewDispatcher(storage, webhook.NewGuard(nil), cfg.WebhookTimeout, 1, 1, time.Second, tracer, logger.AppLogger)
+	dispatcher.Start()
+
+	repo := repository.Publish(repository.Instrument(storage, registry), bus, dispatcher)
+	service := calendar.NewServiceCalendar(repo, logger.AppLogger)
+	handler := handlers.NewHandlers(service, bus, dispatcher, cfg.StreamHeartbeat, logger.AppLogger)
+
+	serv := NewServer(handler, cfg, registry, tracer, logger.AppLogger)
+	serv.OnShutdown(dispatcher.Stop)
+	return serv
+}
+
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Port = "0"
+	cfg.JWTSecret = testSecret
+	// Больше 5s, которые Shutdown ждёт соединения без запроса.
+	cfg.ShutdownTimeout = 10 * time.Second
+	return cfg
+}
+
+// newTestClient возвращает клиента без keep-alive: транспорт не держит открытых
+// соединений, которых пришлось бы дожидаться Shutdown.
+func newTestClient(t *testing.T) *http.Client {
+	transport := &http.Transport{DisableKeepAlives: true}
+	t.Cleanup(transport.CloseIdleConnections)
+	return &http.Client{Transport: transport}
+}
+
+func getHealth(t *testing.T, client *http.Client, url string) (int, repository.HealthResponse) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Result repository.HealthResponse `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body.Result
+}
+
+func TestServer_StartAndShutdown(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownDrainDelay = time.Second
+	serv := newTestServer(t, cfg)
+	client := newTestClient(t)
+	var hookCalled atomic.Bool
+	serv.OnShutdown(func(ctx context.Context) error {
+		hookCalled.Store(true)
+		return nil
+	})
+
+	require.NoError(t, serv.Listen())
+	base := "http://" + serv.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- serv.Start(ctx) }()
+
+	t.Run("live and ready", func(t *testing.T) {
+		status, health := getHealth(t, client, base+"/livez")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", health.Status)
+
+		status, health = getHealth(t, client, base+"/readyz")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ready", health.Status)
+		assert.Equal(t, "ok", health.Checks["storage"])
+	})
+
+	token, err := middleware.NewToken([]byte(testSecret), 1, time.Hour)
+	require.NoError(t, err)
+
+	t.Run("serves API", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, base+"/users/1/events",
+			strings.NewReader(`{"date": "2025-09-01T10:00", "title": "Standup"}`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	req, err := http.NewRequest(http.MethodGet, base+"/events/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	stream, err := client.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+
+	client.CloseIdleConnections()
+	cancel()
+
+	t.Run("not ready but live while draining", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			status, _ := getHealth(t, client, base+"/readyz")
+			return status == http.StatusServiceUnavailable
+		}, cfg.ShutdownDrainDelay/2, 10*time.Millisecond)
+
+		status, health := getHealth(t, client, base+"/livez")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", health.Status)
+		assert.False(t, hookCalled.Load(), "background work runs until the port is closed")
+	})
+
+	t.Run("shutdown closes streams and returns", func(t *testing.T) {
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(cfg.ShutdownDrainDelay + cfg.ShutdownTimeout + 5*time.Second):
+			t.Fatal("server did not stop")
+		}
+
+		_, err := io.Copy(io.Discard, stream.Body)
+		assert.NoError(t, err)
+		assert.True(t, hookCalled.Load())
+
+		_, err = client.Get(base + "/livez")
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_ListenError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	cfg := testConfig()
+	_, cfg.Port, err = net.SplitHostPort(busy.Addr().String())
+	require.NoError(t, err)
+	cfg.ShutdownDrainDelay = time.Minute
+
+	serv := newTestServer(t, cfg)
+	var hookCalled atomic.Bool
+	serv.OnShutdown(func(ctx context.Context) error {
+		hookCalled.Store(true)
+		return nil
+	})
+
+	started := time.Now()
+	err = serv.Start(context.Background())
+	assert.ErrorContains(t, err, "listen on")
+	assert.True(t, hookCalled.Load(), "background work is stopped on failed start")
+	assert.Less(t, time.Since(started), time.Second, "drain delay is skipped when the port was never taken")
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownTimeout = 100 * time.Millisecond
+	serv := newTestServer(t, cfg)
+
+	var deadline time.Time
+	serv.OnShutdown(func(ctx context.Context) error {
+		deadline, _ = ctx.Deadline()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	started := time.Now()
+	require.NoError(t, serv.Start(ctx))
+	assert.WithinDuration(t, started.Add(cfg.ShutdownTimeout), deadline, time.Second)
+	assert.Less(t, time.Since(started), 2*time.Second)
+}
+
+func TestServer_RateLimitBeforeAuth(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimitIPRPS = 1
+	cfg.RateLimitIPBurst = 2
+	serv := newTestServer(t, cfg)
+
+	send := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/users/1/events", nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		serv.httpServer.Handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.1:1000"))
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.1:1000"))
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.1:1000"), "invalid tokens spend the IP bucket")
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.2:1000"))
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SpanData — завершённый span в том виде, в каком его получает Exporter.
+type SpanData struct {
+	Name         string         `json:"name"`
+	TraceID      TraceID        `json:"trace_id"`
+	SpanID       SpanID         `json:"span_id"`
+	ParentSpanID SpanID         `json:"parent_span_id,omitzero"`
+	TraceState   string         `json:"trace_state,omitempty"`
+	Sampled      bool           `json:"-"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// Exporter получает завершённые span. ExportSpan вызывается из горутины,
+// завершившей span, и не должен надолго её задерживать.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// JSONExporter пишет каждый span отдельной строкой JSON.
+type JSONExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+func NewStdoutExporter() *JSONExporter {
+	return NewJSONExporter(os.Stdout)
+}
+
+func (je *JSONExporter) ExportSpan(span SpanData) {
+	data, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+
+	je.mu.Lock()
+	defer je.mu.Unlock()
+	_, _ = je.w.Write(append(data, '\n'))
+}
@@ -0,0 +1,304 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+type ctxKey string
+
+const (
+	tracerKey ctxKey = "tracer"
+	spanKey   ctxKey = "span"
+	remoteKey ctxKey = "remoteSpan"
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+
+func (id TraceID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+func (id *TraceID) UnmarshalText(text []byte) error { return decodeID(id[:], text) }
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+func (id SpanID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+func (id *SpanID) UnmarshalText(text []byte) error { return decodeID(id[:], text) }
+
+func decodeID(dst, text []byte) error {
+	if hex.DecodedLen(len(text)) != len(dst) {
+		return fmt.Errorf("invalid id length %d", len(text))
+	}
+	_, err := hex.Decode(dst, text)
+	return err
+}
+
+// SpanContext — часть span, которая передаётся между сервисами в заголовках
+// traceparent и tracestate (W3C Trace Context).
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent возвращает значение заголовка traceparent версии 00.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent разбирает заголовок traceparent. Версии новее 00 принимаются,
+// если их начало совпадает с форматом 00, как требует спецификация.
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || !isLowerHex(version) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 ||
+		!isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+	_, _ = hex.Decode(sc.TraceID[:], []byte(traceID))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(spanID))
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var flagBits [1]byte
+	_, _ = hex.Decode(flagBits[:], []byte(flags))
+	sc.Sampled = flagBits[0]&1 == 1
+
+	return sc, nil
+}
+
+// Extract читает контекст вызывающего сервиса из заголовков запроса.
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.TraceState = header.Get(TracestateHeader)
+	return sc, true
+}
+
+// Inject записывает в заголовки исходящего запроса текущий span из ctx.
+func Inject(ctx context.Context, header http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+
+	sc := span.SpanContext()
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// Tracer создаёт span и передаёт завершённые exporter. Без exporter span
+// только связывают вызовы общими идентификаторами.
+type Tracer struct {
+	exporter Exporter
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// WithTracer кладёт tracer в контекст: от него Start создаёт корневые span.
+func WithTracer(ctx context.Context, tracer *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey, tracer)
+}
+
+// WithRemoteParent делает sc родителем следующего span, созданного из ctx.
+func WithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey, sc)
+}
+
+// SpanFromContext возвращает текущий span или nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// Start начинает span name, дочерний к текущему span из ctx. Если в ctx нет
+// ни span, ни tracer, возвращается nil: методы Span допускают nil.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	if parent := SpanFromContext(ctx); parent != nil {
+		return parent.tracer.Start(ctx, name)
+	}
+	if tracer, ok := ctx.Value(tracerKey).(*Tracer); ok && tracer != nil {
+		return tracer.Start(ctx, name)
+	}
+	return ctx, nil
+}
+
+// Start начинает span name. Родителем становится текущий span из ctx, затем
+// контекст из WithRemoteParent; иначе span начинает новую трассу.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{tracer: t}
+	span.data.Name = name
+	span.data.Start = time.Now()
+	span.data.SpanID = newSpanID()
+
+	if parent := SpanFromContext(ctx); parent != nil {
+		parentContext := parent.SpanContext()
+		span.data.TraceID = parentContext.TraceID
+		span.data.Sampled = parentContext.Sampled
+		span.data.TraceState = parentContext.TraceState
+		span.data.ParentSpanID = parentContext.SpanID
+	} else if remote, ok := ctx.Value(remoteKey).(SpanContext); ok && remote.IsValid() {
+		span.data.TraceID = remote.TraceID
+		span.data.Sampled = remote.Sampled
+		span.data.TraceState = remote.TraceState
+		span.data.ParentSpanID = remote.SpanID
+	} else {
+		span.data.TraceID = newTraceID()
+		span.data.Sampled = true
+	}
+
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// Span — одна операция трассы. Методы безопасны для nil и для вызова из разных горутин.
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SpanContext{
+		TraceID:    s.data.TraceID,
+		SpanID:     s.data.SpanID,
+		Sampled:    s.data.Sampled,
+		TraceState: s.data.TraceState,
+	}
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+	s.data.Attributes[key] = value
+}
+
+// RecordError отмечает span как завершившийся ошибкой err; nil игнорируется.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = StatusError
+	s.data.Error = err.Error()
+}
+
+// End завершает span и передаёт его exporter. Повторные вызовы ничего не делают.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	if s.data.Status == "" {
+		s.data.Status = StatusOK
+	}
+	data := s.data
+	s.mu.Unlock()
+
+	if s.tracer.exporter != nil && data.Sampled {
+		s.tracer.exporter.ExportSpan(data)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
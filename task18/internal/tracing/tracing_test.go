@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra field", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false, false},
+		{"short trace id", "00-4bf92f3577b34da6-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.value)
+			if !tt.valid {
+				assert.ErrorIs(t, err, ErrInvalidTraceparent)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+			assert.Equal(t, tt.sampled, sc.Sampled)
+		})
+	}
+}
+
+func TestTracer(t *testing.T) {
+	var out bytes.Buffer
+	tracer := NewTracer(NewJSONExporter(&out))
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(TracestateHeader, "vendor=value")
+	remote, ok := Extract(header)
+	require.True(t, ok)
+
+	ctx := WithRemoteParent(WithTracer(context.Background(), tracer), remote)
+	ctx, root := Start(ctx, "root")
+	childCtx, child := Start(ctx, "child")
+	child.SetAttr("user_id", 1)
+	child.RecordError(errors.New("boom"))
+
+	outgoing := http.Header{}
+	Inject(childCtx, outgoing)
+	assert.Equal(t, child.SpanContext().Traceparent(), outgoing.Get(TraceparentHeader))
+	assert.Equal(t, "vendor=value", outgoing.Get(TracestateHeader))
+
+	child.End()
+	child.End()
+	root.End()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var spans []map[string]any
+	for _, line := range lines {
+		var span map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &span))
+		spans = append(spans, span)
+	}
+
+	assert.Equal(t, "child", spans[0]["name"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0]["trace_id"])
+	assert.Equal(t, spans[1]["span_id"], spans[0]["parent_span_id"])
+	assert.Equal(t, map[string]any{"user_id": float64(1)}, spans[0]["attributes"])
+	assert.Equal(t, StatusError, spans[0]["status"])
+	assert.Equal(t, "boom", spans[0]["error"])
+
+	assert.Equal(t, "root", spans[1]["name"])
+	assert.Equal(t, "00f067aa0ba902b7", spans[1]["parent_span_id"])
+	assert.Equal(t, "vendor=value", spans[1]["trace_state"])
+	assert.Equal(t, StatusOK, spans[1]["status"])
+}
+
+func TestTracer_NotSampledParent(t *testing.T) {
+	var out bytes.Buffer
+	tracer := NewTracer(NewJSONExporter(&out))
+
+	remote, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+
+	_, span := tracer.Start(WithRemoteParent(context.Background(), remote), "root")
+	span.End()
+
+	assert.Empty(t, out.String())
+	assert.True(t, strings.HasSuffix(span.SpanContext().Traceparent(), "-00"))
+}
+
+func TestStart_WithoutTracer(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	span.SetAttr("key", "value")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.Empty(t, header)
+}
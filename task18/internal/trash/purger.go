@@ -0,0 +1,105 @@
+package trash
+
+import (
+	"calendar/internal/tracing"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Store окончательно удаляет события, удалённые в корзину раньше before.
+type Store interface {
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
+}
+
+// Purger раз в interval окончательно удаляет события, пролежавшие в корзине
+// дольше retention.
+type Purger struct {
+	store     Store
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+	tracer    *tracing.Tracer
+	log       *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPurger создаёт очистку корзины; tracer может быть nil, тогда проходы не трассируются.
+func NewPurger(store Store, retention, interval time.Duration, tracer *tracing.Tracer, logger *slog.Logger) *Purger {
+	return &Purger{
+		store:     store,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+		tracer:    tracer,
+		log:       logger,
+	}
+}
+
+// Start запускает очистку в отдельной горутине.
+func (p *Purger) Start() {
+	ctx, cancel := context.WithCancel(tracing.WithTracer(context.Background(), p.tracer))
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.run(ctx)
+
+	p.log.Info("Trash purger started",
+		"retention", p.retention.String(),
+		"interval", p.interval.String(),
+	)
+}
+
+// Stop останавливает очистку и ждёт завершения текущего прохода или отмены ctx.
+func (p *Purger) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	select {
+	case <-p.done:
+		p.log.Info("Trash purger stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Purger) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.tick(ctx)
+		}
+	}
+}
+
+func (p *Purger) tick(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "trash.purge")
+	defer span.End()
+
+	purged, err := p.store.PurgeTrash(ctx, p.now().Add(-p.retention))
+	if err != nil {
+		span.RecordError(err)
+		p.log.Error("failed to purge trash", "error", err)
+		return
+	}
+	span.SetAttr("purged", purged)
+
+	if purged > 0 {
+		p.log.Debug("Trash purged",
+			"events", purged,
+		)
+	}
+}
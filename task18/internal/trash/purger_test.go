@@ -0,0 +1,45 @@
+package trash
+
+import (
+	"calendar/internal/calendar"
+	"calendar/internal/event/repository"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurger(t *testing.T) {
+	service := calendar.NewServiceCalendar(repository.NewEventRepository(slog.Default()), slog.Default())
+	date := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	created, err := service.CreateEvent(t.Context(), repository.Event{UserID: 1, Date: date, Title: "Call"})
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteEvent(t.Context(), created.ID, 1, 0))
+
+	purger := NewPurger(service, 24*time.Hour, time.Hour, nil, slog.Default())
+
+	t.Run("recent events are kept", func(t *testing.T) {
+		purger.now = func() time.Time { return time.Now().Add(23 * time.Hour) }
+		purger.tick(t.Context())
+
+		trash, err := service.Trash(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Len(t, trash, 1)
+	})
+
+	t.Run("expired events are purged", func(t *testing.T) {
+		purger.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+		purger.Start()
+		require.NoError(t, purger.Stop(t.Context()))
+
+		trash, err := service.Trash(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+
+		_, err = service.RestoreTrashedEvent(t.Context(), created.ID, 1)
+		assert.ErrorIs(t, err, repository.ErrEventNotFound)
+	})
+}
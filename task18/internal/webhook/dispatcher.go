@@ -0,0 +1,548 @@
+package webhook
+
+import (
+	"bytes"
+	"calendar/internal/event/repository"
+	"calendar/internal/tracing"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+	StatusCanceled  = "canceled"
+
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	IDHeader        = "X-Webhook-ID"
+
+	// queueSize — сколько изменений может ждать раздачи по подпискам.
+	// Изменения сверх него отбрасываются с записью в лог.
+	queueSize = 1024
+	// historySize — сколько последних доставок хранится по каждой подписке.
+	historySize = 100
+	// deadLetterSize — сколько недоставленных доставок хранится по пользователю.
+	deadLetterSize = 100
+	maxBackoff     = time.Hour
+)
+
+var ErrDeliveryNotFound = errors.New("delivery not found")
+
+var _ repository.ChangeSink = (*Dispatcher)(nil)
+
+// Store — хранилище подписок.
+type Store interface {
+	CreateWebhook(ctx context.Context, hook repository.Webhook) (repository.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID, userID int) (repository.Webhook, error)
+	ListWebhooks(ctx context.Context, userID int) ([]repository.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID, userID int) error
+}
+
+// Payload — тело доставки.
+type Payload struct {
+	DeliveryID string            `json:"delivery_id"`
+	WebhookID  int               `json:"webhook_id"`
+	Type       string            `json:"type" enums:"created,updated,deleted"`
+	Time       time.Time         `json:"time"`
+	UserID     int               `json:"user_id"`
+	EventID    int               `json:"event_id"`
+	Event      *repository.Event `json:"event,omitempty"`
+}
+
+// Delivery — доставка одного изменения одной подписке. NextAttemptAt задано,
+// пока доставка ждёт повторной попытки.
+type Delivery struct {
+	ID             string     `json:"id"`
+	WebhookID      int        `json:"webhook_id"`
+	UserID         int        `json:"user_id"`
+	Type           string     `json:"type" enums:"created,updated,deleted"`
+	EventID        int        `json:"event_id"`
+	Status         string     `json:"status" enums:"pending,delivered,dead,canceled"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+
+	body []byte
+}
+
+// Dispatcher рассылает изменения событий по подпискам пользователя. Каждая
+// доставка — POST с JSON-телом Payload, подписанным секретом подписки
+// (см. Sign). Неудачная доставка повторяется с экспоненциальной задержкой
+// retryBase, 2*retryBase, ... до maxAttempts попыток, после чего попадает
+// в список недоставленных, откуда её можно отправить заново.
+//
+// История доставок и недоставленные хранятся в памяти и теряются при
+// перезапуске, как и ждущие повтора доставки.
+type Dispatcher struct {
+	store       Store
+	guard       *Guard
+	client      *http.Client
+	workers     int
+	maxAttempts int
+	retryBase   time.Duration
+	now         func() time.Time
+	tracer      *tracing.Tracer
+	log         *slog.Logger
+
+	queue chan repository.Change
+	jobs  chan *Delivery
+
+	mu      sync.Mutex
+	history map[int][]*Delivery
+	dead    map[int][]*Delivery
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher создаёт рассылку с workers параллельными отправками и
+// таймаутом timeout на запрос; адреса получателей проверяет guard. tracer
+// может быть nil, тогда доставки не трассируются.
+func NewDispatcher(store Store, guard *Guard, timeout time.Duration, workers, maxAttempts int, retryBase time.Duration, tracer *tracing.Tracer, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		guard:       guard,
+		client:      guard.Client(timeout),
+		workers:     max(workers, 1),
+		maxAttempts: max(maxAttempts, 1),
+		retryBase:   retryBase,
+		now:         time.Now,
+		tracer:      tracer,
+		log:         logger,
+		queue:       make(chan repository.Change, queueSize),
+		jobs:        make(chan *Delivery),
+		history:     make(map[int][]*Delivery),
+		dead:        make(map[int][]*Delivery),
+	}
+}
+
+// Start запускает раздачу изменений и отправителей в отдельных горутинах.
+func (d *Dispatcher) Start() {
+	d.ctx, d.cancel = context.WithCancel(tracing.WithTracer(context.Background(), d.tracer))
+
+	d.wg.Add(1)
+	go d.fanOut(d.ctx)
+	for range d.workers {
+		d.wg.Add(1)
+		go d.work(d.ctx)
+	}
+
+	d.log.Info("Webhook dispatcher started",
+		"workers", d.workers,
+		"max_attempts", d.maxAttempts,
+		"retry_base", d.retryBase.String(),
+	)
+}
+
+// Stop останавливает рассылку и ждёт завершения текущих отправок или отмены ctx.
+// Доставки, ждущие повтора, не отправляются.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.log.Info("Webhook dispatcher stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Publish ставит изменение в очередь рассылки, не дожидаясь её.
+func (d *Dispatcher) Publish(change repository.Change) {
+	select {
+	case d.queue <- change:
+	default:
+		d.log.Warn("webhook queue is full, change dropped",
+			"user_id", change.UserID,
+			"event_id", change.EventID,
+			"type", change.Type,
+		)
+	}
+}
+
+// CreateWebhook сохраняет подписку; пустой Secret заменяется случайным.
+// Подписка на непубличный адрес отклоняется с ErrForbiddenAddress.
+func (d *Dispatcher) CreateWebhook(ctx context.Context, hook repository.Webhook) (repository.Webhook, error) {
+	if err := d.guard.CheckURL(ctx, hook.URL); err != nil {
+		return repository.Webhook{}, err
+	}
+
+	if hook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return repository.Webhook{}, err
+		}
+		hook.Secret = secret
+	}
+
+	events := []string{}
+	for _, eventType := range hook.Events {
+		if !slices.Contains(events, eventType) {
+			events = append(events, eventType)
+		}
+	}
+	hook.Events = events
+
+	return d.store.CreateWebhook(ctx, hook)
+}
+
+func (d *Dispatcher) Webhooks(ctx context.Context, userID int) ([]repository.Webhook, error) {
+	return d.store.ListWebhooks(ctx, userID)
+}
+
+func (d *Dispatcher) Webhook(ctx context.Context, webhookID, userID int) (repository.Webhook, error) {
+	return d.store.GetWebhook(ctx, webhookID, userID)
+}
+
+// DeleteWebhook удаляет подписку и её историю; ждущие повтора доставки отменяются.
+func (d *Dispatcher) DeleteWebhook(ctx context.Context, webhookID, userID int) error {
+	if err := d.store.DeleteWebhook(ctx, webhookID, userID); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	delete(d.history, webhookID)
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Deliveries возвращает последние доставки подписки, новые первыми.
+func (d *Dispatcher) Deliveries(ctx context.Context, webhookID, userID int) ([]Delivery, error) {
+	if _, err := d.store.GetWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return snapshot(d.history[webhookID]), nil
+}
+
+// DeadLetters возвращает недоставленные доставки пользователя, новые первыми.
+func (d *Dispatcher) DeadLetters(userID int) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return snapshot(d.dead[userID])
+}
+
+// Redeliver убирает доставку из недоставленных и отправляет её заново с
+// полным числом попыток.
+func (d *Dispatcher) Redeliver(ctx context.Context, userID int, deliveryID string) (Delivery, error) {
+	d.mu.Lock()
+	i := slices.IndexFunc(d.dead[userID], func(delivery *Delivery) bool {
+		return delivery.ID == deliveryID
+	})
+	if i < 0 {
+		d.mu.Unlock()
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	delivery := d.dead[userID][i]
+	d.mu.Unlock()
+
+	if _, err := d.store.GetWebhook(ctx, delivery.WebhookID, userID); err != nil {
+		return Delivery{}, err
+	}
+
+	d.mu.Lock()
+	d.dead[userID] = slices.DeleteFunc(d.dead[userID], func(dead *Delivery) bool {
+		return dead == delivery
+	})
+	delivery.Status = StatusPending
+	delivery.Attempts = 0
+	delivery.UpdatedAt = d.now()
+	d.remember(delivery)
+	result := *delivery
+	d.mu.Unlock()
+
+	d.schedule(delivery, 0)
+
+	return result, nil
+}
+
+func (d *Dispatcher) fanOut(ctx context.Context) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-d.queue:
+			d.dispatch(ctx, change)
+		}
+	}
+}
+
+// dispatch создаёт по доставке для каждой подписки владельца, ждущей
+// изменения такого типа.
+func (d *Dispatcher) dispatch(ctx context.Context, change repository.Change) {
+	hooks, err := d.store.ListWebhooks(ctx, change.UserID)
+	if err != nil {
+		d.log.Error("failed to load webhooks", "user_id", change.UserID, "error", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, change.Type) {
+			continue
+		}
+
+		delivery, err := d.newDelivery(hook, change)
+		if err != nil {
+			d.log.Error("failed to build webhook delivery", "webhook_id", hook.ID, "error", err)
+			continue
+		}
+
+		d.mu.Lock()
+		d.remember(delivery)
+		d.mu.Unlock()
+
+		select {
+		case d.jobs <- delivery:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) newDelivery(hook repository.Webhook, change repository.Change) (*Delivery, error) {
+	now := d.now()
+	delivery := &Delivery{
+		ID:        uuid.New().String(),
+		WebhookID: hook.ID,
+		UserID:    change.UserID,
+		Type:      change.Type,
+		EventID:   change.EventID,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	body, err := json.Marshal(Payload{
+		DeliveryID: delivery.ID,
+		WebhookID:  hook.ID,
+		Type:       change.Type,
+		Time:       change.Time,
+		UserID:     change.UserID,
+		EventID:    change.EventID,
+		Event:      change.Event,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode payload: %w", err)
+	}
+	delivery.body = body
+
+	return delivery, nil
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case delivery := <-d.jobs:
+			d.attempt(ctx, delivery)
+		}
+	}
+}
+
+// attempt отправляет доставку по текущему состоянию подписки: удалённой
+// подписке доставка не отправляется, изменённый секрет сразу учитывается.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) {
+	ctx, span := tracing.Start(ctx, "webhook.deliver")
+	defer span.End()
+	span.SetAttr("webhook_id", delivery.WebhookID)
+	span.SetAttr("delivery_id", delivery.ID)
+
+	hook, err := d.store.GetWebhook(ctx, delivery.WebhookID, delivery.UserID)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		d.mu.Lock()
+		delivery.Status = StatusCanceled
+		delivery.NextAttemptAt = nil
+		delivery.UpdatedAt = d.now()
+		d.mu.Unlock()
+		return
+	}
+
+	var status int
+	if err == nil {
+		status, err = d.send(ctx, hook, delivery)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.UpdatedAt = d.now()
+	delivery.NextAttemptAt = nil
+	span.SetAttr("attempt", delivery.Attempts)
+
+	if err == nil {
+		delivery.Status = StatusDelivered
+		delivery.Error = ""
+		d.log.Debug("Webhook delivered",
+			"webhook_id", delivery.WebhookID,
+			"delivery_id", delivery.ID,
+			"attempts", delivery.Attempts,
+		)
+		return
+	}
+
+	span.RecordError(err)
+	delivery.Error = err.Error()
+
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = StatusDead
+		d.bury(delivery)
+		d.log.Warn("webhook delivery failed, moved to dead letters",
+			"webhook_id", delivery.WebhookID,
+			"delivery_id", delivery.ID,
+			"attempts", delivery.Attempts,
+			"error", err,
+		)
+		return
+	}
+
+	delay := d.backoff(delivery.Attempts)
+	next := delivery.UpdatedAt.Add(delay)
+	delivery.NextAttemptAt = &next
+	d.log.Warn("failed to deliver webhook, will retry",
+		"webhook_id", delivery.WebhookID,
+		"delivery_id", delivery.ID,
+		"attempts", delivery.Attempts,
+		"retry_in", delay.String(),
+		"error", err,
+	)
+
+	d.schedule(delivery, delay)
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook repository.Webhook, delivery *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return 0, fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(IDHeader, fmt.Sprint(hook.ID))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(EventHeader, delivery.Type)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, d.now(), delivery.body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// schedule передаёт доставку отправителям через delay; после Stop доставка
+// не отправляется.
+func (d *Dispatcher) schedule(delivery *Delivery, delay time.Duration) {
+	ctx := d.ctx
+	if ctx == nil {
+		return
+	}
+
+	enqueue := func() {
+		select {
+		case d.jobs <- delivery:
+		case <-ctx.Done():
+		}
+	}
+
+	if delay <= 0 {
+		go enqueue()
+		return
+	}
+	time.AfterFunc(delay, enqueue)
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryBase
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// remember добавляет доставку в историю подписки; вызывается под d.mu.
+func (d *Dispatcher) remember(delivery *Delivery) {
+	history := d.history[delivery.WebhookID]
+	if slices.Contains(history, delivery) {
+		return
+	}
+	if len(history) == historySize {
+		history = history[1:]
+	}
+	d.history[delivery.WebhookID] = append(history, delivery)
+}
+
+// bury добавляет доставку в недоставленные пользователя; вызывается под d.mu.
+func (d *Dispatcher) bury(delivery *Delivery) {
+	dead := d.dead[delivery.UserID]
+	if len(dead) == deadLetterSize {
+		dead = dead[1:]
+	}
+	d.dead[delivery.UserID] = append(dead, delivery)
+}
+
+// snapshot копирует доставки в обратном порядке, новые первыми; вызывается под d.mu.
+func snapshot(deliveries []*Delivery) []Delivery {
+	result := make([]Delivery, 0, len(deliveries))
+	for _, delivery := range slices.Backward(deliveries) {
+		result = append(result, *delivery)
+	}
+	return result
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
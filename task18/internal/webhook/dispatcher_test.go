@@ -0,0 +1,254 @@
+package webhook
+
+import (
+	"calendar/internal/event/repository"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver — тестовый получатель вебхуков: первые failures запросов
+// получают 500, остальные проверяются и записываются.
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	failures int
+	calls    int
+	payloads []Payload
+	received chan struct{}
+}
+
+func newReceiver(t *testing.T, secret string, failures int) (*receiver, *httptest.Server) {
+	rc := &receiver{secret: secret, failures: failures, received: make(chan struct{}, 100)}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+	return rc, server
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer func() {
+		rc.mu.Unlock()
+		rc.received <- struct{}{}
+	}()
+
+	rc.calls++
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	if _, err := Verify(rc.secret, r.Header.Get(SignatureHeader), body); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil || r.Header.Get(DeliveryHeader) != payload.DeliveryID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rc.payloads = append(rc.payloads, payload)
+}
+
+func (rc *receiver) wait(t *testing.T, calls int) {
+	t.Helper()
+	for range calls {
+		select {
+		case <-rc.received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not received")
+		}
+	}
+}
+
+func (rc *receiver) delivered() []Payload {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]Payload(nil), rc.payloads...)
+}
+
+// testGuard пропускает адреса httptest.Server.
+var testGuard = NewGuard([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")})
+
+func newTestDispatcher(t *testing.T, maxAttempts int) (*Dispatcher, repository.Storage) {
+	storage := repository.NewEventRepository(slog.Default())
+	d := NewDispatcher(storage, testGuard, 0, 2, maxAttempts, time.Millisecond, nil, slog.Default())
+	d.Start()
+	t.Cleanup(func() { _ = d.Stop(t.Context()) })
+	return d, storage
+}
+
+func change(changeType string, userID, eventID int) repository.Change {
+	return repository.Change{
+		Type:    changeType,
+		UserID:  userID,
+		EventID: eventID,
+		Time:    time.Now(),
+		Event:   &repository.Event{ID: eventID, UserID: userID, Title: "Call", Version: 1},
+	}
+}
+
+// waitStatus ждёт, пока последняя доставка подписки получит статус status.
+func waitStatus(t *testing.T, d *Dispatcher, hook repository.Webhook, status string) Delivery {
+	t.Helper()
+	var last Delivery
+	require.Eventually(t, func() bool {
+		deliveries, err := d.Deliveries(t.Context(), hook.ID, hook.UserID)
+		require.NoError(t, err)
+		if len(deliveries) == 0 {
+			return false
+		}
+		last = deliveries[0]
+		return last.Status == status
+	}, 5*time.Second, 5*time.Millisecond)
+	return last
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	d, _ := newTestDispatcher(t, 3)
+	rc, server := newReceiver(t, "secret", 0)
+
+	hook, err := d.CreateWebhook(t.Context(), repository.Webhook{UserID: 1, URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, hook.Events)
+
+	d.Publish(change(repository.ChangeCreated, 1, 7))
+	d.Publish(change(repository.ChangeCreated, 2, 8))
+	rc.wait(t, 1)
+
+	payloads := rc.delivered()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, hook.ID, payloads[0].WebhookID)
+	assert.Equal(t, repository.ChangeCreated, payloads[0].Type)
+	assert.Equal(t, 7, payloads[0].EventID)
+	assert.Equal(t, "Call", payloads[0].Event.Title)
+
+	delivery := waitStatus(t, d, hook, StatusDelivered)
+	assert.Equal(t, payloads[0].DeliveryID, delivery.ID)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+}
+
+func TestDispatcher_FiltersEventTypes(t *testing.T) {
+	d, _ := newTestDispatcher(t, 3)
+	rc, server := newReceiver(t, "secret", 0)
+
+	_, err := d.CreateWebhook(t.Context(), repository.Webhook{
+		UserID: 1, URL: server.URL, Secret: "secret",
+		Events: []string{repository.ChangeDeleted, repository.ChangeDeleted},
+	})
+	require.NoError(t, err)
+
+	d.Publish(change(repository.ChangeCreated, 1, 7))
+	d.Publish(change(repository.ChangeDeleted, 1, 7))
+	rc.wait(t, 1)
+
+	payloads := rc.delivered()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, repository.ChangeDeleted, payloads[0].Type)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	d, _ := newTestDispatcher(t, 5)
+	rc, server := newReceiver(t, "secret", 2)
+
+	hook, err := d.CreateWebhook(t.Context(), repository.Webhook{UserID: 1, URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	d.Publish(change(repository.ChangeUpdated, 1, 7))
+	rc.wait(t, 3)
+
+	delivery := waitStatus(t, d, hook, StatusDelivered)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Empty(t, delivery.Error)
+	assert.Len(t, rc.delivered(), 1)
+	assert.Empty(t, d.DeadLetters(1))
+}
+
+func TestDispatcher_DeadLetters(t *testing.T) {
+	d, _ := newTestDispatcher(t, 2)
+	rc, server := newReceiver(t, "secret", 2)
+
+	hook, err := d.CreateWebhook(t.Context(), repository.Webhook{UserID: 1, URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	d.Publish(change(repository.ChangeUpdated, 1, 7))
+	rc.wait(t, 2)
+
+	delivery := waitStatus(t, d, hook, StatusDead)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.Contains(t, delivery.Error, "500")
+
+	dead := d.DeadLetters(1)
+	require.Len(t, dead, 1)
+	assert.Equal(t, delivery.ID, dead[0].ID)
+	assert.Empty(t, d.DeadLetters(2))
+
+	_, err = d.Redeliver(t.Context(), 2, delivery.ID)
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+
+	redelivered, err := d.Redeliver(t.Context(), 1, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, redelivered.Status)
+	rc.wait(t, 1)
+
+	delivery = waitStatus(t, d, hook, StatusDelivered)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Empty(t, d.DeadLetters(1))
+	assert.Len(t, rc.delivered(), 1)
+}
+
+func TestDispatcher_DeletedWebhookCancelsRetries(t *testing.T) {
+	storage := repository.NewEventRepository(slog.Default())
+	d := NewDispatcher(storage, testGuard, 0, 1, 5, time.Hour, nil, slog.Default())
+	d.Start()
+	t.Cleanup(func() { _ = d.Stop(t.Context()) })
+	rc, server := newReceiver(t, "secret", 1)
+
+	hook, err := d.CreateWebhook(t.Context(), repository.Webhook{UserID: 1, URL: server.URL, Secret: "secret"})
+	require.NoError(t, err)
+
+	d.Publish(change(repository.ChangeUpdated, 1, 7))
+	rc.wait(t, 1)
+
+	var delivery *Delivery
+	require.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if history := d.history[hook.ID]; len(history) == 1 && history[0].Attempts == 1 {
+			delivery = history[0]
+		}
+		return delivery != nil
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.NotNil(t, delivery.NextAttemptAt)
+
+	require.NoError(t, d.DeleteWebhook(t.Context(), hook.ID, 1))
+	_, err = d.Deliveries(t.Context(), hook.ID, 1)
+	assert.ErrorIs(t, err, repository.ErrWebhookNotFound)
+
+	d.attempt(t.Context(), delivery)
+	assert.Equal(t, StatusCanceled, delivery.Status)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(nil, testGuard, 0, 1, 10, 5*time.Second, nil, slog.Default())
+
+	assert.Equal(t, 5*time.Second, d.backoff(1))
+	assert.Equal(t, 10*time.Second, d.backoff(2))
+	assert.Equal(t, 40*time.Second, d.backoff(4))
+	assert.Equal(t, maxBackoff, d.backoff(20))
+}
@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// reservedNetworks — адреса вне интернета, которые не распознаются методами
+// netip.Addr: разделяемые адреса провайдеров (в некоторых облаках там сервис
+// метаданных), служебные IETF, сети для тестов производительности и резерв.
+var reservedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Guard не даёт вебхукам обращаться к внутренней сети: адреса loopback,
+// частных и link-local сетей (включая сервис метаданных 169.254.169.254)
+// запрещены, если не входят в allowed. Адрес проверяется при создании
+// подписки и при каждом соединении, уже после разрешения имени, поэтому
+// имя, которое позже начнёт указывать на внутренний адрес, тоже не пройдёт.
+type Guard struct {
+	allowed  []netip.Prefix
+	resolver *net.Resolver
+}
+
+func NewGuard(allowed []netip.Prefix) *Guard {
+	return &Guard{
+		allowed:  allowed,
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Check возвращает ErrForbiddenAddress для непубличного адреса вне allowed.
+func (g *Guard) Check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	for _, prefix := range reservedNetworks {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+		}
+	}
+
+	return nil
+}
+
+// CheckURL проверяет все адреса хоста rawURL. Имя, которое не удалось
+// разрешить, не отклоняется: при доставке адрес всё равно проверит Client.
+func (g *Guard) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return g.Check(addr)
+	}
+
+	addrs, err := g.resolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := g.Check(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Client возвращает HTTP-клиент, который соединяется только с разрешёнными
+// адресами. Прокси из окружения не используется: через него проверка
+// адреса теряет смысл.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return g.Check(addrPort.Addr())
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard_Check(t *testing.T) {
+	guard := NewGuard([]netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")})
+
+	for _, addr := range []string{
+		"127.0.0.1", "::1", "10.0.0.5", "172.16.0.1", "192.168.1.1",
+		"169.254.169.254", "fe80::1", "fd00:ec2::254", "100.100.100.200",
+		"0.0.0.0", "::", "::ffff:127.0.0.1", "224.0.0.1",
+	} {
+		assert.ErrorIs(t, guard.Check(netip.MustParseAddr(addr)), ErrForbiddenAddress, addr)
+	}
+
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1::1", "10.1.2.3"} {
+		assert.NoError(t, guard.Check(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestGuard_CheckURL(t *testing.T) {
+	guard := NewGuard(nil)
+
+	assert.ErrorIs(t, guard.CheckURL(t.Context(), "http://127.0.0.1:8080/hook"), ErrForbiddenAddress)
+	assert.ErrorIs(t, guard.CheckURL(t.Context(), "http://[::1]/hook"), ErrForbiddenAddress)
+	assert.ErrorIs(t, guard.CheckURL(t.Context(), "http://localhost/hook"), ErrForbiddenAddress)
+	assert.NoError(t, guard.CheckURL(t.Context(), "https://93.184.216.34/hook"))
+}
+
+func TestGuard_Client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	t.Run("internal address is refused on connect", func(t *testing.T) {
+		_, err := NewGuard(nil).Client(0).Get(server.URL)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrForbiddenAddress)
+	})
+
+	t.Run("allowed network is reachable", func(t *testing.T) {
+		resp, err := testGuard.Client(0).Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
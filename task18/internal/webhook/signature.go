@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign возвращает значение заголовка X-Webhook-Signature для тела body,
+// отправленного в момент timestamp: "t=<unix>,v1=<hex HMAC-SHA256>".
+// Подписывается строка "<unix>.<body>", чтобы подпись нельзя было
+// переиспользовать с другим временем отправки.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(signature(secret, unix, body))
+}
+
+// Verify проверяет заголовок X-Webhook-Signature и возвращает время отправки
+// из подписи; получателю стоит отклонять слишком старые доставки.
+func Verify(secret, header string, body []byte) (time.Time, error) {
+	var unix, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			sig = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signature(secret, unix, body)) {
+		return time.Time{}, ErrInvalidSignature
+	}
+
+	return time.Unix(seconds, 0), nil
+}
+
+func signature(secret, unix string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
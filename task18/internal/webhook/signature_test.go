@@ -0,0 +1,28 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	sent := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"created"}`)
+
+	header := Sign("secret", sent, body)
+	assert.Regexp(t, `^t=\d+,v1=[0-9a-f]{64}$`, header)
+
+	signed, err := Verify("secret", header, body)
+	require.NoError(t, err)
+	assert.True(t, signed.Equal(sent))
+
+	_, err = Verify("other", header, body)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = Verify("secret", header, []byte(`{"type":"deleted"}`))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = Verify("secret", "v1=abc", body)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
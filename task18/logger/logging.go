@@ -10,16 +10,21 @@ var (
 	AppLogger     *slog.Logger
 	RequestLogger *slog.Logger
 	logFile       *os.File
+
+	// level общий для обоих логгеров, чтобы SetLevel менял его без пересоздания.
+	level = new(slog.LevelVar)
 )
 
-func InitLogger(level string, logToFile string, logFilePath string) {
+func InitLogger(env string, logToFile bool, logFilePath string) {
+	SetLevel(env)
+
 	appHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: levelForEnv(level),
+		Level: level,
 	})
 	AppLogger = slog.New(appHandler)
 
 	var requestOutput io.Writer = os.Stdout
-	if logToFile == "true" {
+	if logToFile {
 		file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 		if err != nil {
 			panic(err)
@@ -29,13 +34,18 @@ func InitLogger(level string, logToFile string, logFilePath string) {
 	}
 
 	requestHandler := slog.NewJSONHandler(requestOutput, &slog.HandlerOptions{
-		Level: levelForEnv(level),
+		Level: level,
 	})
 	RequestLogger = slog.New(requestHandler)
 
 	slog.SetDefault(AppLogger)
 }
 
+// SetLevel меняет уровень логирования уже созданных логгеров.
+func SetLevel(env string) {
+	level.Set(levelForEnv(env))
+}
+
 func levelForEnv(env string) slog.Level {
 	switch env {
 	case "dev", "local":